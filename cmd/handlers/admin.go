@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/reloader"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewControllerAdmin returns a new instance of an admin controller.
+func NewControllerAdmin(rl *reloader.ReloaderVehicle) *ControllerAdmin {
+	return &ControllerAdmin{rl: rl}
+}
+
+// ControllerAdmin is an struct that represents the controller of the administration tasks.
+type ControllerAdmin struct {
+	// rl is the reloader of the dataset of vehicles.
+	rl *reloader.ReloaderVehicle
+}
+
+// ReloadVehicles reloads the dataset of vehicles, reporting what changed.
+func (c *ControllerAdmin) ReloadVehicles() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		res, err := c.rl.Reload()
+		body := NewResponseReload(res)
+		if err != nil {
+			code := http.StatusInternalServerError
+			body.Message = "Internal server error"
+			switch {
+			case errors.Is(err, reloader.ErrReloaderVehicleInvalid):
+				code = http.StatusUnprocessableEntity
+				body.Message = "Dataset rejected: " + err.Error()
+			case errors.Is(err, reloader.ErrReloaderVehicleUnpersisted):
+				code = http.StatusConflict
+				body.Message = "Dataset not reloaded: " + err.Error()
+			}
+			body.Error = true
+			ctx.JSON(code, body)
+			return
+		}
+
+		body.Message = "Vehicles reloaded successfully"
+		ctx.JSON(http.StatusOK, body)
+	}
+}
+
+// NewResponseReload returns the response of a reload.
+func NewResponseReload(res reloader.Result) web.ResponseReload {
+	body := web.ResponseReload{
+		Added:      res.Diff.Added,
+		Removed:    res.Diff.Removed,
+		Modified:   res.Diff.Modified,
+		Read:       res.Stats.Read,
+		Loaded:     res.Stats.Loaded,
+		Skipped:    res.Stats.Skipped,
+		Duplicates: res.Stats.Duplicates,
+		Issues:     make([]web.ReloadIssue, 0, len(res.RecordErrors)+len(res.Validation.Issues)),
+	}
+	for _, e := range res.RecordErrors {
+		body.Issues = append(body.Issues, web.ReloadIssue{Record: e.Record, Line: e.Line, Field: e.Field, Message: e.Err.Error()})
+	}
+	for _, i := range res.Validation.Issues {
+		body.Issues = append(body.Issues, web.ReloadIssue{Id: i.Id, Field: i.Field, Rule: i.Rule, Message: i.Message})
+	}
+	return body
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UpdateByFilter sets the fields of the json object of the body in every vehicle that matches the filter
+// expression of the filter query param, all or none. With dry_run=true it only returns the affected ids.
+func (c *ControllerVehicle) UpdateByFilter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		dryRun, ok := bulkDryRun(ctx)
+		if !ok {
+			return
+		}
+		var changes map[string]any
+		if err := ctx.ShouldBindJSON(&changes); err != nil {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: the body must be a json object of the fields to change"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		ids, err := c.st.UpdateByFilter(ctx.Query("filter"), changes, dryRun)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		message := "Vehicles updated successfully"
+		if dryRun {
+			message = "Vehicles that would be updated"
+		}
+		ctx.JSON(http.StatusOK, web.ResponseBulk{Message: message, DryRun: dryRun, Count: len(ids), Ids: ids})
+	}
+}
+
+// DeleteByFilter removes every vehicle that matches the filter expression of the filter query param,
+// all or none. With dry_run=true it only returns the affected ids.
+func (c *ControllerVehicle) DeleteByFilter() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		dryRun, ok := bulkDryRun(ctx)
+		if !ok {
+			return
+		}
+
+		ids, err := c.st.DeleteByFilter(ctx.Query("filter"), dryRun)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		message := "Vehicles deleted successfully"
+		if dryRun {
+			message = "Vehicles that would be deleted"
+		}
+		ctx.JSON(http.StatusOK, web.ResponseBulk{Message: message, DryRun: dryRun, Count: len(ids), Ids: ids})
+	}
+}
+
+// bulkDryRun returns the dry_run query param, false by default, answering 400 when it isn't a boolean.
+func bulkDryRun(ctx *gin.Context) (dryRun bool, ok bool) {
+	param := ctx.Query("dry_run")
+	if param == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(param)
+	if err != nil {
+		httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: dry_run must be true or false"}
+		ctx.JSON(httpErr.Code, httpErr)
+		return false, false
+	}
+	return dryRun, true
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+func TestControllerVehicle_Bulk(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		query  string
+		body   string
+		code   int
+		ids    []int
+		// left are the vehicles left afterwards, red the ones left red.
+		left int
+		red  int
+	}{
+		{name: "update", method: http.MethodPatch, query: "filter=" + url.QueryEscape("brand = Ford"), body: `{"color":"Blue"}`,
+			code: http.StatusOK, ids: []int{1}, left: 2, red: 1},
+		{name: "update dry run", method: http.MethodPatch, query: "dry_run=true&filter=" + url.QueryEscape("color = Red"), body: `{"color":"Blue"}`,
+			code: http.StatusOK, ids: []int{1, 2}, left: 2, red: 2},
+		{name: "update breaking a domain rule", method: http.MethodPatch, query: "filter=" + url.QueryEscape("color = Red"), body: `{"year":1800}`,
+			code: http.StatusUnprocessableEntity, left: 2, red: 2},
+		{name: "update without changes", method: http.MethodPatch, query: "filter=" + url.QueryEscape("color = Red"), body: `[]`,
+			code: http.StatusBadRequest, left: 2, red: 2},
+		{name: "delete", method: http.MethodDelete, query: "filter=" + url.QueryEscape("year < 2000"),
+			code: http.StatusOK, ids: []int{2}, left: 1, red: 1},
+		{name: "delete dry run", method: http.MethodDelete, query: "dry_run=1&filter=" + url.QueryEscape("color = Red"),
+			code: http.StatusOK, ids: []int{1, 2}, left: 2, red: 2},
+		{name: "delete without a filter", method: http.MethodDelete, code: http.StatusBadRequest, left: 2, red: 2},
+		{name: "wrong dry run", method: http.MethodDelete, query: "dry_run=maybe&filter=" + url.QueryEscape("color = Red"),
+			code: http.StatusBadRequest, left: 2, red: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, rp := newControllerTest(t)
+			handler := ct.DeleteByFilter()
+			if tt.method == http.MethodPatch {
+				handler = ct.UpdateByFilter()
+			}
+			res := serveTest(handler, tt.method, "/", "/?"+tt.query, map[string]string{"Content-Type": "application/json"}, tt.body)
+			if res.Code != tt.code {
+				t.Fatalf("the bulk operation answered %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+			if res.Code == http.StatusOK {
+				var got web.ResponseBulk
+				if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(got.Ids, tt.ids) || got.Count != len(tt.ids) {
+					t.Errorf("the bulk operation answered %+v, want the ids %v", got, tt.ids)
+				}
+			}
+
+			vehicles, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			red := 0
+			for _, v := range vehicles {
+				if v.Attributes.Color == "Red" {
+					red++
+				}
+			}
+			if len(vehicles) != tt.left || red != tt.red {
+				t.Errorf("%d vehicles are left, %d red, want %d and %d", len(vehicles), red, tt.left, tt.red)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// setValidators sets the ETag and Last-Modified headers of the vehicle, asking clients to revalidate their copy.
+func setValidators(ctx *gin.Context, v *domain.Vehicle) {
+	ctx.Header("ETag", v.ETag())
+	ctx.Header("Last-Modified", lastModified(v).Format(http.TimeFormat))
+	ctx.Header("Cache-Control", "no-cache")
+}
+
+// notModified reports whether the copy of the vehicle of the client is current, by the If-None-Match header or,
+// when it is missing, by the If-Modified-Since header, as RFC 9110 sets.
+func notModified(ctx *gin.Context, v *domain.Vehicle) bool {
+	if header := ctx.GetHeader("If-None-Match"); header != "" {
+		return matchETag(header, v.ETag(), true)
+	}
+	if header := ctx.GetHeader("If-Modified-Since"); header != "" {
+		since, err := http.ParseTime(header)
+		return err == nil && !lastModified(v).After(since)
+	}
+	return false
+}
+
+// matchETag reports whether any tag of a comma separated list of a conditional header, or *, matches the etag.
+// The weak comparison ignores the W/ prefix of weak tags.
+func matchETag(header string, etag string, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if weak {
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if tag == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the version that a write of the vehicle with the id expects by the If-Match header,
+// 0 when it is missing. Weak tags never match, as RFC 9110 sets.
+func (c *ControllerVehicle) ifMatch(ctx *gin.Context, id int) (int, error) {
+	header := ctx.GetHeader("If-Match")
+	if header == "" {
+		return 0, nil
+	}
+	tags := strings.Split(header, ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+	return c.st.MatchETag(id, tags)
+}
+
+// lastModified returns the time of the last change of the vehicle with the precision of http dates.
+func lastModified(v *domain.Vehicle) time.Time {
+	return v.UpdatedAt.UTC().Truncate(time.Second)
+}
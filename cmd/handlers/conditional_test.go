@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/http-error"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/mapper"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newControllerTest returns a controller over an in-memory repository with two vehicles.
+func newControllerTest(t *testing.T) (*ControllerVehicle, *repository.RepositoryVehicleInMemory) {
+	t.Helper()
+	rp := repository.NewRepositoryVehicleInMemory(map[int]*domain.VehicleAttributes{
+		1: {Brand: "Ford", Model: "Fiesta", Registration: "AA100", Year: 2001, Color: "Red", MaxSpeed: 180, FuelType: "gasoline", Transmission: "manual", Passengers: 5, Height: 150, Width: 170, Weight: 1100},
+		2: {Brand: "Fiat", Model: "Panda", Registration: "CC100", Year: 1999, Color: "Red", MaxSpeed: 150, FuelType: "gasoline", Transmission: "manual", Passengers: 4, Height: 150, Width: 160, Weight: 900},
+	})
+	sv := service.NewServiceVehicleDefault(rp, service.ErrorAdapter)
+	return NewControllerVehicle(sv, http_error.ErrorAdapter, mapper.NewStructMapper()), rp
+}
+
+// serveTest serves a request to the url with the headers and the body by the handler of the route, and returns the response.
+func serveTest(handler gin.HandlerFunc, method string, route string, url string, headers map[string]string, body string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Handle(method, route, handler)
+
+	var rd io.Reader
+	if body != "" {
+		rd = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, url, rd)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	res := httptest.NewRecorder()
+	r.ServeHTTP(res, req)
+	return res
+}
+
+func TestMatchETag(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{name: "same tag", header: `"1-a"`, want: true},
+		{name: "other tag", header: `"2-a"`, want: false},
+		{name: "list", header: `"2-a", "1-a"`, want: true},
+		{name: "any", header: `*`, want: true},
+		{name: "weak tag in a weak comparison", header: `W/"1-a"`, weak: true, want: true},
+		{name: "weak tag in a strong comparison", header: `W/"1-a"`, want: false},
+		{name: "tag without quotes", header: `1-a`, weak: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchETag(tt.header, `"1-a"`, tt.weak); got != tt.want {
+				t.Errorf("matchETag returned %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControllerVehicle_GetById(t *testing.T) {
+	ct, rp := newControllerTest(t)
+	v, err := rp.GetById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := v.ETag()
+	modified := v.UpdatedAt.UTC().Truncate(time.Second)
+	before := modified.Add(-time.Hour).Format(http.TimeFormat)
+	after := modified.Add(time.Hour).Format(http.TimeFormat)
+
+	tests := []struct {
+		name    string
+		id      string
+		headers map[string]string
+		code    int
+	}{
+		{name: "no condition", id: "1", code: http.StatusOK},
+		{name: "current etag", id: "1", headers: map[string]string{"If-None-Match": etag}, code: http.StatusNotModified},
+		{name: "weak current etag", id: "1", headers: map[string]string{"If-None-Match": "W/" + etag}, code: http.StatusNotModified},
+		{name: "list with the current etag", id: "1", headers: map[string]string{"If-None-Match": `"0-0", ` + etag}, code: http.StatusNotModified},
+		{name: "any etag", id: "1", headers: map[string]string{"If-None-Match": "*"}, code: http.StatusNotModified},
+		{name: "stale etag", id: "1", headers: map[string]string{"If-None-Match": `"0-0"`}, code: http.StatusOK},
+		{name: "etag of another vehicle", id: "2", headers: map[string]string{"If-None-Match": etag}, code: http.StatusOK},
+		{name: "not modified since", id: "1", headers: map[string]string{"If-Modified-Since": after}, code: http.StatusNotModified},
+		{name: "not modified since the last change", id: "1", headers: map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, code: http.StatusNotModified},
+		{name: "modified since", id: "1", headers: map[string]string{"If-Modified-Since": before}, code: http.StatusOK},
+		{name: "wrong date", id: "1", headers: map[string]string{"If-Modified-Since": "yesterday"}, code: http.StatusOK},
+		{
+			// If-None-Match takes precedence over If-Modified-Since
+			name: "stale etag not modified since", id: "1",
+			headers: map[string]string{"If-None-Match": `"0-0"`, "If-Modified-Since": after}, code: http.StatusOK,
+		},
+		{name: "missing vehicle", id: "99", headers: map[string]string{"If-None-Match": "*"}, code: http.StatusNotFound},
+		{name: "wrong id", id: "one", code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := serveTest(ct.GetById(), http.MethodGet, "/:id", "/"+tt.id, tt.headers, "")
+			if res.Code != tt.code {
+				t.Fatalf("GetById answered %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+			if res.Code >= http.StatusBadRequest {
+				return
+			}
+			if tt.id == "1" && res.Header().Get("ETag") != etag {
+				t.Errorf("GetById answered the ETag %s, want %s", res.Header().Get("ETag"), etag)
+			}
+			if res.Header().Get("Last-Modified") == "" || res.Header().Get("Cache-Control") != "no-cache" {
+				t.Errorf("GetById answered the headers %v", res.Header())
+			}
+			if res.Code == http.StatusNotModified && res.Body.Len() != 0 {
+				t.Errorf("GetById answered 304 with the body %s", res.Body)
+			}
+		})
+	}
+
+	// a write changes the etag, which no longer matches
+	if err := rp.PatchFuel(1, "diesel", 0); err != nil {
+		t.Fatal(err)
+	}
+	res := serveTest(ct.GetById(), http.MethodGet, "/:id", "/1", map[string]string{"If-None-Match": etag}, "")
+	if res.Code != http.StatusOK || res.Header().Get("ETag") == etag {
+		t.Errorf("GetById after a write answered %d with the ETag %s, want 200 with a new one", res.Code, res.Header().Get("ETag"))
+	}
+}
+
+func TestControllerVehicle_IfMatch(t *testing.T) {
+	put := `{"brand":"Ford","model":"Fiesta","registration":"AA100","year":2001,"color":"Blue","max_speed":180,"fuel_type":"diesel",
+		"transmission":"manual","passengers":5,"height":150,"width":170,"weight":1100}`
+	writes := []struct {
+		name    string
+		handler func(ct *ControllerVehicle) gin.HandlerFunc
+		method  string
+		route   string
+		url     string
+		body    string
+		headers map[string]string
+		code    int
+	}{
+		{name: "patch fuel", handler: (*ControllerVehicle).PatchFuel, method: http.MethodPatch, route: "/:id/update_fuel", url: "/1/update_fuel",
+			body: `{"fuel_type":"diesel"}`, code: http.StatusOK},
+		{name: "put", handler: (*ControllerVehicle).PutFuel, method: http.MethodPut, route: "/:id/update_fuel", url: "/1/update_fuel",
+			body: put, code: http.StatusOK},
+		{name: "patch", handler: (*ControllerVehicle).PatchVehicle, method: http.MethodPatch, route: "/:id", url: "/1",
+			body: `{"color":"Blue"}`, headers: map[string]string{"Content-Type": "application/merge-patch+json"}, code: http.StatusOK},
+		{name: "delete", handler: (*ControllerVehicle).Delete, method: http.MethodDelete, route: "/:id", url: "/1", code: http.StatusNoContent},
+	}
+	conditions := []struct {
+		name string
+		// ifMatch returns the If-Match header of the current etag, none when it is empty.
+		ifMatch func(etag string) string
+		applied bool
+	}{
+		{name: "no condition", ifMatch: func(etag string) string { return "" }, applied: true},
+		{name: "current etag", ifMatch: func(etag string) string { return etag }, applied: true},
+		{name: "list with the current etag", ifMatch: func(etag string) string { return `"0-0", ` + etag }, applied: true},
+		{name: "any etag", ifMatch: func(etag string) string { return "*" }, applied: true},
+		{name: "stale etag", ifMatch: func(etag string) string { return `"0-0"` }},
+		// weak tags never match in a strong comparison
+		{name: "weak current etag", ifMatch: func(etag string) string { return "W/" + etag }},
+	}
+	for _, w := range writes {
+		for _, c := range conditions {
+			t.Run(w.name+" with "+c.name, func(t *testing.T) {
+				ct, rp := newControllerTest(t)
+				v, err := rp.GetById(1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				headers := map[string]string{"Content-Type": "application/json"}
+				for k, v := range w.headers {
+					headers[k] = v
+				}
+				if h := c.ifMatch(v.ETag()); h != "" {
+					headers["If-Match"] = h
+				}
+
+				res := serveTest(w.handler(ct), w.method, w.route, w.url, headers, w.body)
+				code := w.code
+				if !c.applied {
+					code = http.StatusPreconditionFailed
+				}
+				if res.Code != code {
+					t.Fatalf("the write answered %d, want %d: %s", res.Code, code, res.Body)
+				}
+				after, err := rp.GetById(1)
+				switch {
+				case !c.applied && (err != nil || after.Version != v.Version):
+					t.Errorf("the write was applied after 412: %+v, %v", after, err)
+				case c.applied && err == nil && after.Version == v.Version:
+					t.Errorf("the write wasn't applied")
+				}
+			})
+		}
+	}
+
+	t.Run("missing vehicle", func(t *testing.T) {
+		ct, _ := newControllerTest(t)
+		res := serveTest(ct.Delete(), http.MethodDelete, "/:id", "/99", map[string]string{"If-Match": "*"}, "")
+		if res.Code != http.StatusNotFound {
+			t.Errorf("the write answered %d, want %d", res.Code, http.StatusNotFound)
+		}
+	})
+}
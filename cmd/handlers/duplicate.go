@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetDuplicates returns the groups of vehicles that share a registration or have the same brand, model, year and
+// color with near-identical dimensions. The query param tolerance is the greatest relative difference of the
+// dimensions, from 0 to 1 and 0.01 by default.
+func (c *ControllerVehicle) GetDuplicates() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tolerance, err := service.ParseDuplicateTolerance(ctx.Query("tolerance"))
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		groups, err := c.st.GetDuplicates(tolerance)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		response := web.ResponseBodyDuplicates{Tolerance: tolerance, Total: len(groups), Groups: make([]web.DuplicateGroup, 0, len(groups))}
+		for _, g := range groups {
+			group := web.DuplicateGroup{Reason: string(g.Reason), Vehicles: make([]web.VehicleHandlerGetAll, 0, len(g.Vehicles))}
+			for _, v := range g.Vehicles {
+				group.Vehicles = append(group.Vehicles, c.sm.MapToVehicleHandlerGetAll(*v))
+			}
+			response.Groups = append(response.Groups, group)
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
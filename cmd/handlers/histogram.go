@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetHistogram returns the histogram of the field of the path of the vehicles selected by the query param filter.
+// The bins are set by one of the query params width (and origin), bins or edges, and split by the field of split_by.
+func (c *ControllerVehicle) GetHistogram() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		opts, httpErr := histogramOptions(ctx)
+		if httpErr != nil {
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		h, err := c.st.GetHistogram(ctx.Query("filter"), opts)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		ctx.JSON(http.StatusOK, NewResponseHistogram(h))
+	}
+}
+
+// histogramOptions returns the histogram options of the path param field and the query params
+// width, origin, bins, edges and split_by.
+func histogramOptions(ctx *gin.Context) (opts domain.HistogramOptions, httpErr *web.ResponseError) {
+	badRequest := func(format string, args ...any) *web.ResponseError {
+		return &web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: " + fmt.Sprintf(format, args...)}
+	}
+
+	opts.Field = domain.VehicleField(ctx.Param("field"))
+	opts.SplitBy = domain.VehicleField(ctx.Query("split_by"))
+
+	var err error
+	if param := ctx.Query("width"); param != "" {
+		if opts.Width, err = strconv.ParseFloat(param, 64); err != nil || opts.Width <= 0 {
+			return opts, badRequest("width must be a positive number")
+		}
+	}
+	if param := ctx.Query("origin"); param != "" {
+		if opts.Origin, err = strconv.ParseFloat(param, 64); err != nil || math.IsNaN(opts.Origin) || math.IsInf(opts.Origin, 0) {
+			return opts, badRequest("origin must be a finite number")
+		}
+	}
+	if param := ctx.Query("bins"); param != "" {
+		if opts.Bins, err = strconv.Atoi(param); err != nil || opts.Bins <= 0 {
+			return opts, badRequest("bins must be a positive integer")
+		}
+	}
+	if param := ctx.Query("edges"); param != "" {
+		for _, part := range strings.Split(param, ",") {
+			edge, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if err != nil {
+				return opts, badRequest("edges must be a comma separated list of numbers, got %q", part)
+			}
+			opts.Edges = append(opts.Edges, edge)
+		}
+	}
+	return
+}
+
+// NewResponseHistogram returns the response of the histogram.
+func NewResponseHistogram(h domain.Histogram) web.ResponseHistogram {
+	response := web.ResponseHistogram{
+		Field:   string(h.Options.Field),
+		SplitBy: string(h.Options.SplitBy),
+		Count:   h.Count,
+		Outside: h.Outside,
+		Bins:    make([]web.HistogramBin, 0, len(h.Bins)),
+	}
+	for _, b := range h.Bins {
+		response.Bins = append(response.Bins, web.HistogramBin{Min: b.Min, Max: b.Max, Count: b.Count, Split: b.Split})
+	}
+	return response
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// pageOptions returns the sort and pagination options of the query params sort, limit, offset and cursor.
+func pageOptions(ctx *gin.Context) (opts domain.PageOptions, httpErr *web.ResponseError) {
+	badRequest := func(format string, args ...any) *web.ResponseError {
+		return &web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: " + fmt.Sprintf(format, args...)}
+	}
+
+	var err error
+	if opts.Sort, err = service.ParseSort(ctx.Query("sort")); err != nil {
+		return opts, badRequest("sort: %v", err)
+	}
+	if param := ctx.Query("limit"); param != "" {
+		if opts.Limit, err = strconv.Atoi(param); err != nil || opts.Limit < 0 {
+			return opts, badRequest("limit must be a non negative integer")
+		}
+	}
+	if param := ctx.Query("offset"); param != "" {
+		if opts.Offset, err = strconv.Atoi(param); err != nil || opts.Offset < 0 {
+			return opts, badRequest("offset must be a non negative integer")
+		}
+	}
+	opts.Cursor = ctx.Query("cursor")
+	if opts.Cursor != "" && opts.Offset > 0 {
+		return opts, badRequest("offset can't be used with cursor")
+	}
+	return
+}
+
+// paginate returns the page of the vehicles selected by the query params of the request,
+// writing the error response when they are wrong.
+func (c *ControllerVehicle) paginate(ctx *gin.Context, vehicles []*domain.Vehicle) (p domain.Page, pg *web.Pagination, ok bool) {
+	opts, httpErr := pageOptions(ctx)
+	if httpErr != nil {
+		ctx.JSON(httpErr.Code, httpErr)
+		return
+	}
+
+	p, err := c.st.Paginate(vehicles, opts)
+	if err != nil {
+		httpErr := c.errAdapter(err)
+		ctx.JSON(httpErr.Code, httpErr)
+		return
+	}
+
+	pg = &web.Pagination{
+		Total:      p.Total,
+		Offset:     p.Offset,
+		Limit:      p.Limit,
+		NextCursor: p.NextCursor,
+		PrevCursor: p.PrevCursor,
+	}
+	// the links keep the kind of pagination of the request
+	if p.Limit > 0 {
+		cursor := opts.Cursor != ""
+		if p.HasNext() {
+			if cursor {
+				pg.Next = pageLink(ctx, "cursor", p.NextCursor)
+			} else {
+				pg.Next = pageLink(ctx, "offset", strconv.Itoa(p.Offset+len(p.Vehicles)))
+			}
+		}
+		if p.HasPrev() {
+			if cursor {
+				pg.Prev = pageLink(ctx, "cursor", p.PrevCursor)
+			} else {
+				pg.Prev = pageLink(ctx, "offset", strconv.Itoa(max(0, min(p.Offset, p.Total)-p.Limit)))
+			}
+		}
+	}
+	ok = true
+	return
+}
+
+// pageLink returns the url of the request with the query param key set to value.
+func pageLink(ctx *gin.Context, key string, value string) string {
+	u := *ctx.Request.URL
+	q := u.Query()
+	q.Set(key, value)
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
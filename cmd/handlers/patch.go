@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// patchFormats are the patch formats by their media type. Plain json is taken as a merge patch.
+var patchFormats = map[string]domain.PatchFormat{
+	"application/merge-patch+json": domain.PatchFormatMerge,
+	"application/json":             domain.PatchFormatMerge,
+	"application/json-patch+json":  domain.PatchFormatJSON,
+}
+
+// PatchVehicle changes any attribute of the vehicle of the path param id with a JSON Merge Patch
+// (application/merge-patch+json) or a JSON Patch (application/json-patch+json), honoring If-Match.
+// It returns the updated vehicle with its ETag and Last-Modified headers.
+func (c *ControllerVehicle) PatchVehicle() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad id format"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		format, ok := patchFormats[ctx.ContentType()]
+		if !ok {
+			httpErr := web.ResponseError{Code: http.StatusUnsupportedMediaType, Message: "Unsupported Media Type: expected application/merge-patch+json or application/json-patch+json"}
+			ctx.Header("Accept-Patch", "application/merge-patch+json, application/json-patch+json")
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		document, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad request format"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		version, err := c.ifMatch(ctx, id)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		vehicle, err := c.st.Update(id, version, domain.Patch{Format: format, Document: document})
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		setValidators(ctx, vehicle)
+		ctx.JSON(http.StatusOK, web.ResponseBodyGetById{
+			Message: "Vehicle updated successfully",
+			Data:    c.sm.MapToVehicleHandlerGetById(*vehicle),
+			Error:   false,
+		})
+	}
+}
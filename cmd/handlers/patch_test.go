@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestControllerVehicle_PatchVehicle(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		code        int
+	}{
+		{name: "merge patch", contentType: "application/merge-patch+json", body: `{"color":"Blue"}`, code: http.StatusOK},
+		{name: "plain json", contentType: "application/json; charset=utf-8", body: `{"color":"Blue"}`, code: http.StatusOK},
+		{name: "json patch", contentType: "application/json-patch+json", body: `[{"op":"replace","path":"/color","value":"Blue"}]`, code: http.StatusOK},
+		{name: "failed test", contentType: "application/json-patch+json", body: `[{"op":"test","path":"/color","value":"Blue"}]`, code: http.StatusConflict},
+		{name: "wrong patch", contentType: "application/merge-patch+json", body: `{"wheels":4}`, code: http.StatusBadRequest},
+		{name: "domain rule", contentType: "application/merge-patch+json", body: `{"year":1800}`, code: http.StatusUnprocessableEntity},
+		{name: "unsupported media type", contentType: "text/plain", body: `color=Blue`, code: http.StatusUnsupportedMediaType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, rp := newControllerTest(t)
+			res := serveTest(ct.PatchVehicle(), http.MethodPatch, "/:id", "/1", map[string]string{"Content-Type": tt.contentType}, tt.body)
+			if res.Code != tt.code {
+				t.Fatalf("PatchVehicle answered %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+			v, err := rp.GetById(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			switch tt.code {
+			case http.StatusOK:
+				if v.Attributes.Color != "Blue" || res.Header().Get("ETag") != v.ETag() {
+					t.Errorf("PatchVehicle stored %+v and answered the ETag %s", v, res.Header().Get("ETag"))
+				}
+			case http.StatusUnsupportedMediaType:
+				if res.Header().Get("Accept-Patch") == "" {
+					t.Error("PatchVehicle answered 415 without Accept-Patch")
+				}
+				fallthrough
+			default:
+				if v.Version != 1 {
+					t.Errorf("PatchVehicle failed but changed the vehicle to %+v", v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unboundedRange is the range of every value, used for the ranges left out of a request.
+var unboundedRange = domain.Range{Min: domain.RangeBound{Unbounded: true}, Max: domain.RangeBound{Unbounded: true}}
+
+// badRequestRange returns the bad request response of a wrong range param.
+func badRequestRange(format string, args ...any) *web.ResponseError {
+	return &web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: " + fmt.Sprintf(format, args...)}
+}
+
+// queryRange returns the range of the first of the query params that is present, in the syntax of service.ParseRange.
+// It returns an unbounded range when none is present.
+func queryRange(ctx *gin.Context, names ...string) (r domain.Range, httpErr *web.ResponseError) {
+	for _, name := range names {
+		param, ok := ctx.GetQuery(name)
+		if !ok {
+			continue
+		}
+		r, err := service.ParseRange(param)
+		if err != nil {
+			return r, badRequestRange("%s: %v", name, err)
+		}
+		return r, nil
+	}
+	return unboundedRange, nil
+}
+
+// queryRangeMinMax returns the inclusive range of the query params min and max, either of which may be left out.
+// Like the bounds of service.ParseRange, they must be finite numbers.
+func queryRangeMinMax(ctx *gin.Context, min string, max string) (r domain.Range, httpErr *web.ResponseError) {
+	r = unboundedRange
+	if param := ctx.Query(min); param != "" {
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return r, badRequestRange("%s must be a finite number, got %q", min, param)
+		}
+		r.Min = domain.RangeBound{Value: v, Inclusive: true}
+	}
+	if param := ctx.Query(max); param != "" {
+		v, err := strconv.ParseFloat(param, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return r, badRequestRange("%s must be a finite number, got %q", max, param)
+		}
+		r.Max = domain.RangeBound{Value: v, Inclusive: true}
+	}
+	if r.IsEmpty() {
+		return r, badRequestRange("%s can't be greater than %s", min, max)
+	}
+	return
+}
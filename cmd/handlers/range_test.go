@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// contextTest returns a gin context of a GET request with the given query.
+func contextTest(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	return ctx
+}
+
+func TestQueryRange(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// want is the range in interval notation, bad whether the request is rejected.
+		want string
+		bad  bool
+	}{
+		{name: "missing", query: "", want: "(,)"},
+		{name: "first name", query: "height=[100,200)", want: "[100,200)"},
+		{name: "alias", query: "length=>=150", want: "[150,)"},
+		{name: "not a number", query: "height=tall", bad: true},
+		{name: "NaN", query: "height=NaN", bad: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, httpErr := queryRange(contextTest(tt.query), "height", "length")
+			if (httpErr != nil) != tt.bad {
+				t.Fatalf("queryRange returned %v, want a bad request %t", httpErr, tt.bad)
+			}
+			if httpErr != nil {
+				if httpErr.Code != http.StatusBadRequest {
+					t.Errorf("queryRange returned code %d, want %d", httpErr.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if r.String() != tt.want {
+				t.Errorf("queryRange returned %s, want %s", r, tt.want)
+			}
+		})
+	}
+}
+
+func TestQueryRangeMinMax(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		bad   bool
+	}{
+		{name: "both", query: "min=100&max=200", want: "[100,200]"},
+		{name: "only min", query: "min=100", want: "[100,)"},
+		{name: "only max", query: "max=200", want: "(,200]"},
+		{name: "none", query: "", want: "(,)"},
+		{name: "same value", query: "min=100&max=100", want: "[100,100]"},
+		{name: "min greater than max", query: "min=200&max=100", bad: true},
+		{name: "not a number", query: "min=heavy", bad: true},
+		{name: "NaN min", query: "min=NaN", bad: true},
+		{name: "NaN max", query: "max=nan", bad: true},
+		{name: "infinite min", query: "min=-Inf&max=100", bad: true},
+		{name: "infinite max", query: "max=%2BInf", bad: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, httpErr := queryRangeMinMax(contextTest(tt.query), "min", "max")
+			if (httpErr != nil) != tt.bad {
+				t.Fatalf("queryRangeMinMax returned %v, want a bad request %t", httpErr, tt.bad)
+			}
+			if httpErr != nil {
+				if httpErr.Code != http.StatusBadRequest {
+					t.Errorf("queryRangeMinMax returned code %d, want %d", httpErr.Code, http.StatusBadRequest)
+				}
+				return
+			}
+			if r.String() != tt.want {
+				t.Errorf("queryRangeMinMax returned %s, want %s", r, tt.want)
+			}
+		})
+	}
+}
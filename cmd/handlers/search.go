@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// searchLimit is the default number of results of a search.
+	searchLimit = 20
+	// searchMaxLimit is the greatest number of results of a search.
+	searchMaxLimit = 100
+)
+
+// Search returns the vehicles that best match the full-text query of the query param q, with their score.
+// The query param limit sets the number of results, 20 by default and 100 at most.
+func (c *ControllerVehicle) Search() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		query := ctx.Query("q")
+		limit := searchLimit
+		if param := ctx.Query("limit"); param != "" {
+			var err error
+			if limit, err = strconv.Atoi(param); err != nil || limit <= 0 || limit > searchMaxLimit {
+				httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: limit must be an integer from 1 to " + strconv.Itoa(searchMaxLimit)}
+				ctx.JSON(httpErr.Code, httpErr)
+				return
+			}
+		}
+
+		results, err := c.st.Search(query)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		response := web.ResponseBodySearch{Query: query, Total: len(results), Data: make([]web.VehicleHandlerSearch, 0, limit)}
+		for _, r := range results[:min(limit, len(results))] {
+			response.Data = append(response.Data, c.sm.MapToVehicleHandlerSearch(r))
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetSimilar returns the vehicles nearest to the one of the path param id, the nearest first.
+// The query param k sets the number of vehicles, 5 by default and 100 at most, and weights the weights of the
+// fields as a comma separated list of field:weight pairs, e.g. year:2,weight:0.5,fuel_type:0, 1 when left out.
+func (c *ControllerVehicle) GetSimilar() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad id format"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		opts := domain.SimilarOptions{K: service.SimilarK}
+		if param := ctx.Query("k"); param != "" {
+			if opts.K, err = strconv.Atoi(param); err != nil || opts.K <= 0 || opts.K > service.SimilarMaxK {
+				httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: k must be an integer from 1 to " + strconv.Itoa(service.SimilarMaxK)}
+				ctx.JSON(httpErr.Code, httpErr)
+				return
+			}
+		}
+		if opts.Weights, err = service.ParseSimilarWeights(ctx.Query("weights")); err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		results, err := c.st.GetSimilar(id, opts)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		response := web.ResponseBodySimilar{Id: id, Weights: make(map[string]float64, len(opts.Weights)), Data: make([]web.VehicleHandlerSimilar, 0, len(results))}
+		for field, w := range opts.Weights {
+			response.Weights[string(field)] = w
+		}
+		for _, r := range results {
+			response.Data = append(response.Data, c.sm.MapToVehicleHandlerSimilar(r))
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatistics returns the statistics of the vehicles selected by the query param filter, grouped by the fields
+// of group_by and summarizing the fields of fields with the percentiles of percentiles, see service.ParseStatisticsOptions.
+func (c *ControllerVehicle) GetStatistics() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		opts, err := service.ParseStatisticsOptions(ctx.Query("group_by"), ctx.Query("fields"), ctx.Query("percentiles"))
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		st, err := c.st.GetStatistics(ctx.Query("filter"), opts)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		ctx.JSON(http.StatusOK, NewResponseStatistics(st))
+	}
+}
+
+// GetAverageByBrands returns the average of the numeric field of the path of the vehicles of every brand
+// of the query param brands, a comma separated list that can be repeated.
+func (c *ControllerVehicle) GetAverageByBrands() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		var brands []string
+		for _, param := range ctx.QueryArray("brands") {
+			for _, brand := range strings.Split(param, ",") {
+				if brand = strings.TrimSpace(brand); brand != "" {
+					brands = append(brands, brand)
+				}
+			}
+		}
+		if len(brands) == 0 {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: brands is required, as brands=Ford,Toyota"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		field := domain.VehicleField(ctx.Param("field"))
+		averages, err := c.st.GetAverageByBrands(field, brands)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		response := web.ResponseAverages{Field: string(field), Averages: make([]web.Average, 0, len(averages))}
+		for _, a := range averages {
+			response.Averages = append(response.Averages, NewAverage(a))
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
+
+// NewAverage returns the response of an average.
+func NewAverage(a domain.Average) web.Average {
+	return web.Average{Brand: a.Brand, Field: string(a.Field), Average: a.Average, Count: a.Count, Models: a.Models}
+}
+
+// NewResponseStatistics returns the response of the statistics, with the percentiles named p<percentile>, e.g. p99.9.
+func NewResponseStatistics(st domain.Statistics) web.ResponseStatistics {
+	response := web.ResponseStatistics{
+		Count:   st.Count,
+		GroupBy: make([]string, 0, len(st.Options.GroupBy)),
+		Groups:  make([]web.StatisticsGroup, 0, len(st.Groups)),
+	}
+	for _, field := range st.Options.GroupBy {
+		response.GroupBy = append(response.GroupBy, string(field))
+	}
+
+	for _, g := range st.Groups {
+		group := web.StatisticsGroup{
+			Group:      make(map[string]any, len(g.Key)),
+			Count:      g.Count,
+			Statistics: make(map[string]web.Summary, len(g.Summaries)),
+		}
+		for field, value := range g.Key {
+			group.Group[string(field)] = value
+		}
+		for field, s := range g.Summaries {
+			summary := web.Summary{
+				Count:       s.Count,
+				Min:         s.Min,
+				Max:         s.Max,
+				Mean:        s.Mean,
+				Median:      s.Median,
+				Percentiles: make(map[string]float64, len(s.Percentiles)),
+			}
+			for i, p := range st.Options.Percentiles {
+				summary.Percentiles["p"+strconv.FormatFloat(p, 'g', -1, 64)] = s.Percentiles[i]
+			}
+			group.Statistics[string(field)] = summary
+		}
+		response.Groups = append(response.Groups, group)
+	}
+	return response
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// suggestLimit is the default number of suggestions.
+	suggestLimit = 10
+	// suggestMaxLimit is the greatest number of suggestions.
+	suggestMaxLimit = 100
+)
+
+// Suggest returns the distinct values of the path param field (brand, model or color) that start with the
+// query param prefix, with their number of vehicles and the most common first. Models need the query param brand.
+// The query param limit sets the number of suggestions, 10 by default and 100 at most.
+func (c *ControllerVehicle) Suggest() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		prefix := ctx.Query("prefix")
+		limit := suggestLimit
+		if param := ctx.Query("limit"); param != "" {
+			var err error
+			if limit, err = strconv.Atoi(param); err != nil || limit <= 0 || limit > suggestMaxLimit {
+				httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad Request: limit must be an integer from 1 to " + strconv.Itoa(suggestMaxLimit)}
+				ctx.JSON(httpErr.Code, httpErr)
+				return
+			}
+		}
+
+		field := domain.VehicleField(ctx.Param("field"))
+		suggestions, err := c.st.Suggest(field, ctx.Query("brand"), prefix, limit)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		response := web.ResponseBodySuggest{Field: string(field), Prefix: prefix, Suggestions: make([]web.Suggestion, 0, len(suggestions))}
+		for _, s := range suggestions {
+			response.Suggestions = append(response.Suggestions, web.Suggestion{Value: s.Value, Count: s.Count})
+		}
+		ctx.JSON(http.StatusOK, response)
+	}
+}
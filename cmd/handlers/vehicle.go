@@ -4,11 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/mapper"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -32,14 +32,20 @@ type ControllerVehicle struct {
 
 type HandlerErrorAdapter func(error) web.ResponseError
 
-// GetAll returns all vehicles.
+// GetAll returns all vehicles, or the ones that match the filter expression of the filter query param.
 func (c *ControllerVehicle) GetAll() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		// request
-		// ...
+		filter, hasFilter := ctx.GetQuery("filter")
 
 		// process
-		vehicles, err := c.st.GetAll()
+		var vehicles []*domain.Vehicle
+		var err error
+		if hasFilter {
+			vehicles, err = c.st.GetByFilter(filter)
+		} else {
+			vehicles, err = c.st.GetAll()
+		}
 		if err != nil {
 			var code int
 			var body web.ResponseBodyGetAll
@@ -47,6 +53,9 @@ func (c *ControllerVehicle) GetAll() gin.HandlerFunc {
 			case errors.Is(err, service.ErrServiceVehicleNotFound):
 				code = http.StatusNotFound
 				body = web.ResponseBodyGetAll{Message: "Not found", Error: true}
+			case errors.Is(err, service.ErrServiceVehicleInvalidFilter):
+				code = http.StatusBadRequest
+				body = web.ResponseBodyGetAll{Message: "Bad Request: " + err.Error(), Error: true}
 			default:
 				code = http.StatusInternalServerError
 				body = web.ResponseBodyGetAll{Message: "Internal server error", Error: true}
@@ -56,10 +65,15 @@ func (c *ControllerVehicle) GetAll() gin.HandlerFunc {
 			return
 		}
 
+		page, pagination, ok := c.paginate(ctx, vehicles)
+		if !ok {
+			return
+		}
+
 		// response
 		code := http.StatusOK
-		body := web.ResponseBodyGetAll{Message: "Success", Data: make([]*web.VehicleHandlerGetAll, 0, len(vehicles)), Error: false}
-		for _, vehicle := range vehicles {
+		body := web.ResponseBodyGetAll{Message: "Success", Data: make([]*web.VehicleHandlerGetAll, 0, len(page.Vehicles)), Error: false, Pagination: pagination}
+		for _, vehicle := range page.Vehicles {
 			body.Data = append(body.Data, &web.VehicleHandlerGetAll{
 				Id:           vehicle.Id,
 				Brand:        vehicle.Attributes.Brand,
@@ -81,73 +95,38 @@ func (c *ControllerVehicle) GetAll() gin.HandlerFunc {
 	}
 }
 
+// GetByDimensions returns the vehicles whose height and width are in the ranges of the query params
+// height (or length) and width, see service.ParseRange. Either of them may be left out.
 func (c *ControllerVehicle) GetByDimensions() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		lengthParam := ctx.Query("length")
-		widthParam := ctx.Query("width")
-
-		length := strings.Split(lengthParam, "-")
-		width := strings.Split(widthParam, "-")
-
-		if len(length) != 2 || len(width) != 2 {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
-			ctx.JSON(httpError.Code, httpError)
+		height, httpErr := queryRange(ctx, "height", "length")
+		if httpErr != nil {
+			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-
-		minLength, err := strconv.ParseFloat(length[0], 64)
-		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
-			ctx.JSON(httpError.Code, httpError)
+		width, httpErr := queryRange(ctx, "width")
+		if httpErr != nil {
+			ctx.JSON(httpErr.Code, httpErr)
 			return
-
 		}
-		maxLength, err := strconv.ParseFloat(length[1], 64)
-		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
-			ctx.JSON(httpError.Code, httpError)
+		if height.IsUnbounded() && width.IsUnbounded() {
+			httpErr := badRequestRange("a height or width range is required, as height=[min,max] or width=[min,max]")
+			ctx.JSON(httpErr.Code, httpErr)
 			return
-
 		}
 
-		minWidth, err := strconv.ParseFloat(width[0], 64)
+		vehicles, err := c.st.GetByDimensions(height, width)
 		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
-			ctx.JSON(httpError.Code, httpError)
-			return
-
-		}
-		maxWidth, err := strconv.ParseFloat(width[1], 64)
-		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
+			httpError := c.errAdapter(err)
 			ctx.JSON(httpError.Code, httpError)
 			return
-
 		}
-
-		vehicles, err := c.st.GetByDimensions(minLength, maxLength, minWidth, maxWidth)
-		if err != nil {
-			httpError := c.errAdapter(err)
-			ctx.JSON(httpError.Code, httpError)
+		page, pagination, ok := c.paginate(ctx, vehicles)
+		if !ok {
 			return
 		}
-		var response web.ResponseBodyGetByDimension
-		for _, v := range vehicles {
+		response := web.ResponseBodyGetByDimension{Pagination: pagination}
+		for _, v := range page.Vehicles {
 			response.Data = append(response.Data, c.sm.MapToVehicleHandlerGetByDimension(*v))
 		}
 		ctx.JSON(http.StatusOK, response)
@@ -156,49 +135,44 @@ func (c *ControllerVehicle) GetByDimensions() gin.HandlerFunc {
 	}
 }
 
+// GetByWeight returns the vehicles whose weight is in the range of the query param weight, see service.ParseRange,
+// or between the query params min and max, both inclusive.
 func (c *ControllerVehicle) GetByWeight() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		minParam := ctx.Query("min")
-		maxParam := ctx.Query("max")
-
-		if minParam == "" || maxParam == "" {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
-			}
-			ctx.JSON(httpError.Code, httpError)
+		weight, httpErr := queryRange(ctx, "weight")
+		if httpErr != nil {
+			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-
-		minWeight, err := strconv.ParseFloat(minParam, 64)
-		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
+		if ctx.Query("min") != "" || ctx.Query("max") != "" {
+			if !weight.IsUnbounded() {
+				httpErr := badRequestRange("weight can't be used with min and max")
+				ctx.JSON(httpErr.Code, httpErr)
+				return
 			}
-			ctx.JSON(httpError.Code, httpError)
-			return
-
-		}
-		maxWeight, err := strconv.ParseFloat(maxParam, 64)
-		if err != nil {
-			httpError := web.ResponseError{
-				Code:    http.StatusBadRequest,
-				Message: "Wrong param format",
+			if weight, httpErr = queryRangeMinMax(ctx, "min", "max"); httpErr != nil {
+				ctx.JSON(httpErr.Code, httpErr)
+				return
 			}
-			ctx.JSON(httpError.Code, httpError)
+		}
+		if weight.IsUnbounded() {
+			httpErr := badRequestRange("a weight range is required, as weight=[min,max] or min and max")
+			ctx.JSON(httpErr.Code, httpErr)
 			return
-
 		}
 
-		vehicles, err := c.st.GetByWeight(minWeight, maxWeight)
+		vehicles, err := c.st.GetByWeight(weight)
 		if err != nil {
 			httpError := c.errAdapter(err)
 			ctx.JSON(httpError.Code, httpError)
 			return
 		}
-		var response web.ResponseBodyGetByWeight
-		for _, v := range vehicles {
+		page, pagination, ok := c.paginate(ctx, vehicles)
+		if !ok {
+			return
+		}
+		response := web.ResponseBodyGetByWeight{Pagination: pagination}
+		for _, v := range page.Vehicles {
 			response.Data = append(response.Data, c.sm.MapToVehicleHandlerGetByWeight(*v))
 		}
 		ctx.JSON(http.StatusOK, response)
@@ -228,8 +202,12 @@ func (c *ControllerVehicle) GetByColorAndYear() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		var response web.ResponseBodyGetByYearAndColor
-		for _, v := range vehicles {
+		page, pagination, ok := c.paginate(ctx, vehicles)
+		if !ok {
+			return
+		}
+		response := web.ResponseBodyGetByYearAndColor{Pagination: pagination}
+		for _, v := range page.Vehicles {
 			response.Data = append(response.Data, c.sm.MapFromModelVehicleHandlerGetByColorAndDate(*v))
 		}
 		ctx.JSON(http.StatusOK, response)
@@ -243,11 +221,10 @@ func (c *ControllerVehicle) GetAverageCapacityByBrand() gin.HandlerFunc {
 		average, err := c.st.GetAverageCapacityByBrand(brand)
 		if err != nil {
 			httpErr := c.errAdapter(err)
-			ctx.JSON(httpErr.Code, httpErr.Message)
+			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		respond := fmt.Sprintf("The average capacity for the brand %s is %f", brand, average)
-		ctx.JSON(http.StatusOK, respond)
+		ctx.JSON(http.StatusOK, NewAverage(average))
 		return
 
 	}
@@ -273,7 +250,13 @@ func (c *ControllerVehicle) PatchFuel() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		err = c.st.PatchFuel(id, vehicle.FuelType)
+		version, err := c.ifMatch(ctx, id)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		err = c.st.PatchFuel(id, vehicle.FuelType, version)
 		if err != nil {
 			httpErr := c.errAdapter(err)
 			ctx.JSON(httpErr.Code, httpErr)
@@ -287,8 +270,23 @@ func (c *ControllerVehicle) PatchFuel() gin.HandlerFunc {
 	}
 }
 
+// Batch creates the vehicles of the body. With mode=atomic, the default, either all of them are created or none
+// and the failing vehicle is reported; with mode=partial every valid vehicle is created and the failures are
+// reported with 207 Multi-Status.
 func (c *ControllerVehicle) Batch() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		var atomic bool
+		switch mode := ctx.DefaultQuery("mode", "atomic"); mode {
+		case "atomic":
+			atomic = true
+		case "partial":
+			atomic = false
+		default:
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: fmt.Sprintf("Bad Request: unknown mode %q, expected atomic or partial", mode)}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
 		var vehicles []web.VehicleHandlerPost
 		err := ctx.ShouldBindJSON(&vehicles)
 		if err != nil {
@@ -296,16 +294,35 @@ func (c *ControllerVehicle) Batch() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		err = c.st.Batch(c.sm.MapToVehicleHandlerBatch(vehicles))
+		failures, err := c.st.Batch(c.sm.MapToVehicleHandlerBatch(vehicles), atomic)
 		if err != nil {
 			httpErr := c.errAdapter(err)
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		ctx.JSON(http.StatusCreated, web.ResponsePost{
-			Message: "Vehicles created successfully",
-		})
-		return
+
+		response := web.ResponseBatch{
+			Message:  "Vehicles created successfully",
+			Created:  len(vehicles) - len(failures),
+			Failed:   len(failures),
+			Failures: make([]web.BatchFailure, 0, len(failures)),
+		}
+		for _, f := range failures {
+			httpErr := c.errAdapter(f.Err)
+			response.Failures = append(response.Failures, web.BatchFailure{Index: f.Index, Id: f.Id, Code: httpErr.Code, Error: httpErr.Message})
+		}
+		switch {
+		case len(failures) == 0:
+			ctx.JSON(http.StatusCreated, response)
+		case atomic:
+			// the failure of the vehicle rolled back the batch
+			response.Message = "No vehicle was created: " + response.Failures[0].Error
+			response.Created = 0
+			ctx.JSON(response.Failures[0].Code, response)
+		default:
+			response.Message = "Some vehicles couldn't be created"
+			ctx.JSON(http.StatusMultiStatus, response)
+		}
 	}
 }
 
@@ -352,7 +369,13 @@ func (c *ControllerVehicle) PutFuel() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		err = c.st.Put(c.sm.MapFromVehicleHandlerPutFuel(id, vehicle))
+		v := c.sm.MapFromVehicleHandlerPutFuel(id, vehicle)
+		if v.Version, err = c.ifMatch(ctx, id); err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		err = c.st.Put(v)
 		if err != nil {
 			httpErr := c.errAdapter(err)
 			ctx.JSON(httpErr.Code, httpErr)
@@ -375,14 +398,48 @@ func (c *ControllerVehicle) GetByTransmission() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		var response web.ResponseBodyGetByTransmission
-		for _, v := range vehicles {
+		page, pagination, ok := c.paginate(ctx, vehicles)
+		if !ok {
+			return
+		}
+		response := web.ResponseBodyGetByTransmission{Pagination: pagination}
+		for _, v := range page.Vehicles {
 			response.Data = append(response.Data, c.sm.MapToVehicleHandlerGetByTransmission(*v))
 		}
 		ctx.JSON(http.StatusOK, response)
 	}
 }
 
+// GetById returns the vehicle of the path param id with its ETag and Last-Modified headers, or 304 Not Modified
+// when the If-None-Match or If-Modified-Since headers show the copy of the client is current.
+func (c *ControllerVehicle) GetById() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		id, err := strconv.Atoi(ctx.Param("id"))
+		if err != nil {
+			httpErr := web.ResponseError{Code: http.StatusBadRequest, Message: "Bad id format"}
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		vehicle, err := c.st.GetById(id)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+
+		setValidators(ctx, vehicle)
+		if notModified(ctx, vehicle) {
+			ctx.Status(http.StatusNotModified)
+			return
+		}
+		ctx.JSON(http.StatusOK, web.ResponseBodyGetById{
+			Message: "Success",
+			Data:    c.sm.MapToVehicleHandlerGetById(*vehicle),
+			Error:   false,
+		})
+	}
+}
+
 func (c *ControllerVehicle) Delete() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		idParam := ctx.Param("id")
@@ -392,7 +449,13 @@ func (c *ControllerVehicle) Delete() gin.HandlerFunc {
 			ctx.JSON(httpErr.Code, httpErr)
 			return
 		}
-		err = c.st.Delete(id)
+		version, err := c.ifMatch(ctx, id)
+		if err != nil {
+			httpErr := c.errAdapter(err)
+			ctx.JSON(httpErr.Code, httpErr)
+			return
+		}
+		err = c.st.Delete(id, version)
 		if err != nil {
 			httpErr := c.errAdapter(err)
 			ctx.JSON(httpErr.Code, httpErr)
@@ -408,11 +471,5 @@ func (c *ControllerVehicle) Delete() gin.HandlerFunc {
 
 }
 func validateFuel(fuel string) bool {
-	allowedFuels := []string{"diesel", "biodisel", "gas", "gasoline"}
-	for _, allowed := range allowedFuels {
-		if strings.ToLower(fuel) == allowed {
-			return true
-		}
-	}
-	return false
+	return domain.IsFuelType(fuel)
 }
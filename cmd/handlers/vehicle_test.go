@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"encoding/json"
+	"github.com/abrahamkarina/code-review-exercise-one/cmd/web"
+	"net/http"
+	"testing"
+)
+
+func TestControllerVehicle_Batch(t *testing.T) {
+	vehicle := func(id string) string {
+		return `{"id":` + id + `,"brand":"Seat","model":"Ibiza","registration":"DD` + id + `","year":2010,"color":"Grey","max_speed":180,` +
+			`"fuel_type":"gasoline","transmission":"manual","passengers":5,"height":145,"width":170,"weight":1100}`
+	}
+	tests := []struct {
+		name  string
+		query string
+		body  string
+		code  int
+		// want is the response, whose message isn't compared.
+		want web.ResponseBatch
+		// stored are the ids of the batch stored afterwards.
+		stored []int
+	}{
+		{name: "atomic", body: "[" + vehicle("7") + "," + vehicle("8") + "]", code: http.StatusCreated,
+			want: web.ResponseBatch{Created: 2, Failures: []web.BatchFailure{}}, stored: []int{7, 8}},
+		{name: "atomic by default with an id in use", body: "[" + vehicle("7") + "," + vehicle("1") + "]", code: http.StatusNotFound,
+			want: web.ResponseBatch{Failed: 1, Failures: []web.BatchFailure{{Index: 1, Id: 1, Code: http.StatusNotFound, Error: "Id already in use"}}}},
+		{name: "atomic with an id in use", query: "?mode=atomic", body: "[" + vehicle("7") + "," + vehicle("1") + "]", code: http.StatusNotFound,
+			want: web.ResponseBatch{Failed: 1, Failures: []web.BatchFailure{{Index: 1, Id: 1, Code: http.StatusNotFound, Error: "Id already in use"}}}},
+		{name: "partial", query: "?mode=partial", body: "[" + vehicle("7") + "," + vehicle("8") + "]", code: http.StatusCreated,
+			want: web.ResponseBatch{Created: 2, Failures: []web.BatchFailure{}}, stored: []int{7, 8}},
+		{name: "partial with an id in use", query: "?mode=partial", body: "[" + vehicle("7") + "," + vehicle("1") + "," + vehicle("8") + "]",
+			code:   http.StatusMultiStatus,
+			want:   web.ResponseBatch{Created: 2, Failed: 1, Failures: []web.BatchFailure{{Index: 1, Id: 1, Code: http.StatusNotFound, Error: "Id already in use"}}},
+			stored: []int{7, 8}},
+		{name: "unknown mode", query: "?mode=some", body: "[" + vehicle("7") + "]", code: http.StatusBadRequest},
+		{name: "not an array", body: vehicle("7"), code: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ct, rp := newControllerTest(t)
+			res := serveTest(ct.Batch(), http.MethodPost, "/batch", "/batch"+tt.query, map[string]string{"Content-Type": "application/json"}, tt.body)
+			if res.Code != tt.code {
+				t.Fatalf("Batch answered %d, want %d: %s", res.Code, tt.code, res.Body)
+			}
+			if res.Code != http.StatusBadRequest {
+				var got web.ResponseBatch
+				if err := json.Unmarshal(res.Body.Bytes(), &got); err != nil {
+					t.Fatal(err)
+				}
+				if got.Created != tt.want.Created || got.Failed != tt.want.Failed || len(got.Failures) != len(tt.want.Failures) {
+					t.Fatalf("Batch answered %+v, want %+v", got, tt.want)
+				}
+				for i := range got.Failures {
+					if got.Failures[i] != tt.want.Failures[i] {
+						t.Errorf("Batch answered the failure %+v, want %+v", got.Failures[i], tt.want.Failures[i])
+					}
+				}
+			}
+
+			vehicles, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vehicles) != 2+len(tt.stored) {
+				t.Errorf("the batch stored %d vehicles, want %v", len(vehicles)-2, tt.stored)
+			}
+			for _, id := range tt.stored {
+				if _, err := rp.GetById(id); err != nil {
+					t.Errorf("vehicle %d wasn't stored: %v", id, err)
+				}
+			}
+		})
+	}
+}
@@ -19,6 +19,41 @@ func ErrorAdapter(err error) web.ResponseError {
 			Code:    http.StatusNotFound,
 			Message: "Id already in use",
 		}
+	case errors.Is(err, service.ErrServiceVehicleInvalidFilter),
+		errors.Is(err, service.ErrServiceVehicleInvalidPage),
+		errors.Is(err, service.ErrServiceVehicleInvalidRange),
+		errors.Is(err, service.ErrServiceVehicleInvalidStatistics),
+		errors.Is(err, service.ErrServiceVehicleInvalidHistogram),
+		errors.Is(err, service.ErrServiceVehicleInvalidSearch),
+		errors.Is(err, service.ErrServiceVehicleInvalidSuggest),
+		errors.Is(err, service.ErrServiceVehicleInvalidSimilar),
+		errors.Is(err, service.ErrServiceVehicleInvalidDuplicates),
+		errors.Is(err, service.ErrServiceVehicleInvalidPatch),
+		errors.Is(err, service.ErrServiceVehicleInvalidBulk):
+		return web.ResponseError{
+			Code:    http.StatusBadRequest,
+			Message: "Bad Request: " + err.Error(),
+		}
+	case errors.Is(err, service.ErrServiceVehicleVersionConflict):
+		return web.ResponseError{
+			Code:    http.StatusPreconditionFailed,
+			Message: "Precondition Failed: " + err.Error(),
+		}
+	case errors.Is(err, service.ErrServiceVehiclePatchTestFailed):
+		return web.ResponseError{
+			Code:    http.StatusConflict,
+			Message: "Conflict: " + err.Error(),
+		}
+	case errors.Is(err, service.ErrServiceVehicleInvalidAttributes):
+		return web.ResponseError{
+			Code:    http.StatusUnprocessableEntity,
+			Message: "Unprocessable Entity: " + err.Error(),
+		}
+	case errors.Is(err, service.ErrServiceVehicleDuplicate):
+		return web.ResponseError{
+			Code:    http.StatusConflict,
+			Message: "Conflict: " + err.Error(),
+		}
 
 	default:
 		return web.ResponseError{
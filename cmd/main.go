@@ -1,31 +1,198 @@
 package main
 
 import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
 	"github.com/abrahamkarina/code-review-exercise-one/cmd/handlers"
 	httpErr "github.com/abrahamkarina/code-review-exercise-one/cmd/http-error"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/mapper"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/loader"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/reloader"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/service"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/wal"
+	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	_ "github.com/mattn/go-sqlite3"
 )
 
 func main() {
 	// env
 	godotenv.Load(".env")
 
+	// stop on interrupt, letting in-flight requests finish and saving the vehicles
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// dependencies
-	ldVh := loader.NewLoaderVehicleJSON(os.Getenv("FILE_PATH_VEHICLES_JSON"))
-	dbVh, err := ldVh.Load()
+	ldVh, err := loader.NewLoaderVehicle(os.Getenv("FILE_PATH_VEHICLES_JSON"), os.Getenv("FILE_FORMAT_VEHICLES"))
 	if err != nil {
 		panic(err)
 	}
+	policy, err := loader.ParseValidationPolicy(os.Getenv("VEHICLES_VALIDATION_POLICY"))
+	if err != nil {
+		panic(err)
+	}
+
+	var rpVh repository.RepositoryVehicle
+	var persist func() error
+	var ctAdmin *handlers.ControllerAdmin
+	switch os.Getenv("REPOSITORY_VEHICLES") {
+	case "sqlite":
+		db, err := sql.Open("sqlite3", repository.DSNVehicleSQLite(os.Getenv("FILE_PATH_VEHICLES_SQLITE")))
+		if err != nil {
+			panic(err)
+		}
+		defer db.Close()
+
+		rp := repository.NewRepositoryVehicleSQLite(db)
+		if err := rp.CreateSchema(); err != nil {
+			panic(err)
+		}
+		// the json dataset is only used as seed data when the database is empty,
+		// keeping the first vehicle of every id
+		dbVh := make(map[int]*domain.VehicleAttributes)
+		seed := func(id int, a *domain.VehicleAttributes) error {
+			if _, ok := dbVh[id]; ok {
+				return loader.ErrLoaderVehicleDuplicate
+			}
+			dbVh[id] = a
+			return nil
+		}
+		if err := loadVehicles(ldVh, loader.NewValidatorVehicle(policy), seed); err != nil {
+			panic(err)
+		}
+		if _, err := rp.Seed(dbVh); err != nil {
+			panic(err)
+		}
+		rpVh = rp
+	default:
+		rpMem := repository.NewRepositoryVehicleInMemory(nil)
+		rpVh = rpMem
+		post := func(id int, a *domain.VehicleAttributes) error {
+			err := rpMem.Post(&domain.Vehicle{Id: id, Attributes: *a})
+			if errors.Is(err, repository.ErrRepositoryIdInUse) {
+				return loader.ErrLoaderVehicleDuplicate
+			}
+			return err
+		}
+		if err := loadVehicles(ldVh, loader.NewValidatorVehicle(policy), post); err != nil {
+			panic(err)
+		}
+		// the vehicles loaded are the content of the dataset, so they aren't writes to persist
+		rpMem.Saved(rpMem.Written())
+		psVh := loader.NewPersisterVehicleJSON(os.Getenv("FILE_PATH_VEHICLES_JSON"))
+		// the dataset is written back as json, so it can't be persisted when it has other format
+		if _, ok := ldVh.(*loader.LoaderVehicleJSON); !ok && (os.Getenv("FILE_PATH_VEHICLES_WAL") != "" || os.Getenv("PERSIST_VEHICLES_JSON_INTERVAL") != "") {
+			panic("persisting the vehicles requires a json dataset")
+		}
+
+		// with a write-ahead log every mutation is durable: the log is replayed over the json dataset
+		// and saving the dataset compacts the log into it
+		if path := os.Getenv("FILE_PATH_VEHICLES_WAL"); path != "" {
+			lg, records, err := wal.Open(path, os.Getenv("WAL_VEHICLES_RECOVER") == "true")
+			if err != nil {
+				panic(err)
+			}
+			defer lg.Close()
+
+			rp := repository.NewRepositoryVehicleWAL(rpMem, lg)
+			if err := rp.Replay(records); err != nil {
+				panic(err)
+			}
+			rpVh = rp
+			persist = func() error {
+				return rp.Compact(func(rp repository.RepositoryVehicle) error {
+					written := rpMem.Written()
+					if err := persistVehicles(rp, psVh); err != nil {
+						return err
+					}
+					rpMem.Saved(written)
+					return nil
+				})
+			}
+		}
+
+		// the dataset is reloaded on demand and, when an interval is configured, whenever the file changes;
+		// the writes of the persistence go through the reloader so they aren't taken as changes
+		rl := reloader.NewReloaderVehicle(os.Getenv("FILE_PATH_VEHICLES_JSON"), ldVh, policy, rpVh.(repository.RepositoryVehicleReplacer))
+		ctAdmin = handlers.NewControllerAdmin(rl)
+		if persist != nil {
+			compact := persist
+			persist = func() error {
+				return rl.Write(compact)
+			}
+		}
+		if interval := os.Getenv("WATCH_VEHICLES_INTERVAL"); interval != "" {
+			d, err := time.ParseDuration(interval)
+			if err != nil {
+				panic(err)
+			}
+			go rl.Watch(ctx, d, func(res reloader.Result, err error) {
+				if err != nil {
+					log.Printf("vehicles not reloaded: %v", err)
+					return
+				}
+				log.Printf("vehicles reloaded: %d added, %d removed, %d modified",
+					len(res.Diff.Added), len(res.Diff.Removed), len(res.Diff.Modified))
+			})
+		}
 
-	rpVh := repository.NewRepositoryVehicleInMemory(dbVh)
+		// the json dataset is written back periodically and on shutdown when an interval is configured
+		if interval := os.Getenv("PERSIST_VEHICLES_JSON_INTERVAL"); interval != "" {
+			d, err := time.ParseDuration(interval)
+			if err != nil {
+				panic(err)
+			}
+			// without a write-ahead log the writes are only durable once persisted, so the dataset isn't
+			// reloaded over the ones that aren't yet
+			if persist == nil {
+				persist = func() error {
+					return rl.Write(func() error {
+						written := rpMem.Written()
+						if err := persistVehicles(rpMem, psVh); err != nil {
+							return err
+						}
+						rpMem.Saved(written)
+						return nil
+					})
+				}
+			}
+			go func() {
+				ticker := time.NewTicker(d)
+				defer ticker.Stop()
+				for {
+					select {
+					case <-ctx.Done():
+						return
+					case <-ticker.C:
+						if err := persist(); err != nil {
+							log.Println(err)
+						}
+					}
+				}
+			}()
+		}
+	}
 	svVh := service.NewServiceVehicleDefault(rpVh, service.ErrorAdapter)
+	// posting a vehicle that duplicates a stored one is rejected when configured
+	if os.Getenv("VEHICLES_REJECT_DUPLICATES") == "true" {
+		tolerance, err := service.ParseDuplicateTolerance(os.Getenv("VEHICLES_DUPLICATES_TOLERANCE"))
+		if err != nil {
+			panic(err)
+		}
+		svVh.RejectDuplicates(tolerance)
+	}
 	ctVh := handlers.NewControllerVehicle(svVh, httpErr.ErrorAdapter, mapper.NewStructMapper())
 
 	// server
@@ -37,19 +204,123 @@ func main() {
 	api := rt.Group("/api/v1")
 	grVh := api.Group("/vehicles")
 	grVh.GET("", ctVh.GetAll())
+	grVh.PATCH("", ctVh.UpdateByFilter())
+	grVh.DELETE("", ctVh.DeleteByFilter())
 	grVh.PATCH("/:id/update_fuel", ctVh.PatchFuel())
 	grVh.PUT("/:id/update_fuel", ctVh.PutFuel())
 	grVh.POST("/batch", ctVh.Batch())
 	grVh.POST("/post", ctVh.Post())
+	grVh.GET("/:id", ctVh.GetById())
+	grVh.PATCH("/:id", ctVh.PatchVehicle())
 	grVh.DELETE("/:id", ctVh.Delete())
+	grVh.GET("/:id/similar", ctVh.GetSimilar())
 	grVh.GET("/color/:color/year/:year", ctVh.GetByColorAndYear())
 	grVh.GET("/average_capacity/brand/:brand", ctVh.GetAverageCapacityByBrand())
+	grVh.GET("/average/:field", ctVh.GetAverageByBrands())
+	grVh.GET("/statistics", ctVh.GetStatistics())
+	grVh.GET("/search", ctVh.Search())
+	grVh.GET("/duplicates", ctVh.GetDuplicates())
+	grVh.GET("/suggest/:field", ctVh.Suggest())
+	grVh.GET("/histogram/:field", ctVh.GetHistogram())
 	grVh.GET("/dimensions", ctVh.GetByDimensions())
 	grVh.GET("/weight", ctVh.GetByWeight())
 	grVh.GET("/transmission/:type", ctVh.GetByTransmission())
+	if ctAdmin != nil {
+		grAdmin := api.Group("/admin")
+		grAdmin.POST("/vehicles/reload", ctAdmin.ReloadVehicles())
+	}
 
 	// run
-	if err := rt.Run(os.Getenv("SERVER_ADDR")); err != nil {
+	srv := &http.Server{Addr: os.Getenv("SERVER_ADDR"), Handler: rt}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errCh:
 		panic(err)
+	case <-ctx.Done():
+	}
+
+	// shutdown
+	ctxShutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctxShutdown); err != nil {
+		log.Println(err)
+	}
+	if persist != nil {
+		if err := persist(); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+// persistVehicles saves the current content of the repository through the persister.
+func persistVehicles(rp repository.RepositoryVehicle, ps loader.PersisterVehicle) error {
+	vehicles, err := rp.GetAll()
+	if err != nil && !errors.Is(err, repository.ErrRepositoryVehicleNotFound) {
+		return err
+	}
+
+	db := make(map[int]*domain.VehicleAttributes, len(vehicles))
+	for _, v := range vehicles {
+		attributes := v.Attributes
+		db[v.Id] = &attributes
+	}
+	return ps.Save(db)
+}
+
+// loadVehicles loads the dataset into the sink, streaming it when the loader supports it.
+// Every vehicle goes through the validator, whose report is logged and saved to VEHICLES_VALIDATION_REPORT.
+func loadVehicles(ld loader.LoaderVehicle, vl *loader.ValidatorVehicle, sink loader.SinkVehicle) (err error) {
+	post := vl.Sink(sink)
+	defer func() {
+		if err == nil {
+			err = reportValidation(vl)
+		}
+	}()
+
+	stats, err := loader.LoadInto(ld, post, func(stats loader.LoadStats) {
+		log.Printf("loading vehicles: %d records read in %s", stats.Read, stats.Elapsed)
+	})
+	if err := logRecordErrors(err); err != nil {
+		return err
+	}
+	log.Printf("vehicles loaded: %d records read, %d loaded, %d skipped, %d duplicates in %s",
+		stats.Read, stats.Loaded, stats.Skipped, stats.Duplicates, stats.Elapsed)
+	if stats.Buffered {
+		log.Printf("vehicles loaded from a document parsed as a whole, not streamed")
+	}
+	return nil
+}
+
+// reportValidation logs the summary of the validation of the dataset and saves its report as json.
+// It returns an error when the dataset is rejected.
+func reportValidation(vl *loader.ValidatorVehicle) error {
+	report, errReport := vl.Report()
+	log.Printf("vehicles validated with policy %s: %d valid, %d invalid, %d issues",
+		report.Policy, report.Valid, report.Invalid, len(report.Issues))
+
+	if path := os.Getenv("VEHICLES_VALIDATION_REPORT"); path != "" {
+		b, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, b, 0644); err != nil {
+			return err
+		}
+	}
+	return errReport
+}
+
+// logRecordErrors reports the records of the dataset that can't be read, returning any other error.
+func logRecordErrors(err error) error {
+	var errs loader.RecordErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+	for _, e := range errs {
+		log.Printf("skipped record: %v", e)
 	}
+	return nil
 }
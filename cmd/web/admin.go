@@ -0,0 +1,23 @@
+package web
+
+type ReloadIssue struct {
+	Id      int    `json:"id,omitempty"`
+	Record  int    `json:"record,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule,omitempty"`
+	Message string `json:"message"`
+}
+
+type ResponseReload struct {
+	Message    string        `json:"message"`
+	Added      []int         `json:"added"`
+	Removed    []int         `json:"removed"`
+	Modified   []int         `json:"modified"`
+	Read       int           `json:"read"`
+	Loaded     int           `json:"loaded"`
+	Skipped    int           `json:"skipped"`
+	Duplicates int           `json:"duplicates"`
+	Issues     []ReloadIssue `json:"issues"`
+	Error      bool          `json:"error"`
+}
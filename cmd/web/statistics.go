@@ -0,0 +1,50 @@
+package web
+
+type Summary struct {
+	Count       int                `json:"count"`
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	Median      float64            `json:"median"`
+	Percentiles map[string]float64 `json:"percentiles"`
+}
+
+type StatisticsGroup struct {
+	Group      map[string]any     `json:"group"`
+	Count      int                `json:"count"`
+	Statistics map[string]Summary `json:"statistics"`
+}
+
+type ResponseStatistics struct {
+	Count   int               `json:"count"`
+	GroupBy []string          `json:"group_by"`
+	Groups  []StatisticsGroup `json:"groups"`
+}
+
+type HistogramBin struct {
+	Min   float64        `json:"min"`
+	Max   float64        `json:"max"`
+	Count int            `json:"count"`
+	Split map[string]int `json:"split,omitempty"`
+}
+
+type ResponseHistogram struct {
+	Field   string         `json:"field"`
+	SplitBy string         `json:"split_by,omitempty"`
+	Count   int            `json:"count"`
+	Outside int            `json:"outside"`
+	Bins    []HistogramBin `json:"bins"`
+}
+
+type Average struct {
+	Brand   string   `json:"brand"`
+	Field   string   `json:"field"`
+	Average float64  `json:"average"`
+	Count   int      `json:"count"`
+	Models  []string `json:"models"`
+}
+
+type ResponseAverages struct {
+	Field    string    `json:"field"`
+	Averages []Average `json:"averages"`
+}
@@ -1,5 +1,7 @@
 package web
 
+import "time"
+
 type VehicleHandlerGetAll struct {
 	Id           int     `json:"id"`
 	Brand        string  `json:"brand"`
@@ -15,6 +17,24 @@ type VehicleHandlerGetAll struct {
 	Width        float64 `json:"width"`
 	Weight       float64 `json:"weight"`
 }
+type VehicleHandlerGetById struct {
+	Id           int       `json:"id"`
+	Brand        string    `json:"brand"`
+	Model        string    `json:"model"`
+	Registration string    `json:"registration"`
+	Year         int       `json:"year"`
+	Color        string    `json:"color"`
+	MaxSpeed     int       `json:"max_speed"`
+	FuelType     string    `json:"fuel_type"`
+	Transmission string    `json:"transmission"`
+	Passengers   int       `json:"passengers"`
+	Height       float64   `json:"height"`
+	Width        float64   `json:"width"`
+	Weight       float64   `json:"weight"`
+	Version      int       `json:"version"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 type VehicleHandlerGetByColorAndDate struct {
 	Id           int     `json:"id"`
 	Brand        string  `json:"brand"`
@@ -115,21 +135,32 @@ type ResponseBodyGetAll struct {
 	Message string                  `json:"message"`
 	Data    []*VehicleHandlerGetAll `json:"vehicles"`
 	Error   bool                    `json:"error"`
+	*Pagination
+}
+
+type ResponseBodyGetById struct {
+	Message string                `json:"message"`
+	Data    VehicleHandlerGetById `json:"vehicle"`
+	Error   bool                  `json:"error"`
 }
 
 type ResponseBodyGetByYearAndColor struct {
 	Data []VehicleHandlerGetByColorAndDate `json:"vehicles"`
+	*Pagination
 }
 
 type ResponseBodyGetByDimension struct {
 	Data []VehicleHandlerGetByDimension `json:"vehicles"`
+	*Pagination
 }
 
 type ResponseBodyGetByWeight struct {
 	Data []VehicleHandlerGetByWeight `json:"vehicles"`
+	*Pagination
 }
 type ResponseBodyGetByTransmission struct {
 	Data []VehicleHandlerGetByTransmission `json:"vehicles"`
+	*Pagination
 }
 type ResponseUpdateFuel struct {
 	Message string `json:"message"`
@@ -141,3 +172,107 @@ type ResponseDelete struct {
 type ResponsePost struct {
 	Message string `json:"message"`
 }
+
+type BatchFailure struct {
+	Index int    `json:"index"`
+	Id    int    `json:"id"`
+	Code  int    `json:"code"`
+	Error string `json:"error"`
+}
+
+type ResponseBatch struct {
+	Message  string         `json:"message"`
+	Created  int            `json:"created"`
+	Failed   int            `json:"failed"`
+	Failures []BatchFailure `json:"failures"`
+}
+
+type ResponseBulk struct {
+	Message string `json:"message"`
+	DryRun  bool   `json:"dry_run"`
+	Count   int    `json:"count"`
+	Ids     []int  `json:"ids"`
+}
+
+type Pagination struct {
+	Total      int    `json:"total"`
+	Offset     int    `json:"offset"`
+	Limit      int    `json:"limit,omitempty"`
+	Next       string `json:"next,omitempty"`
+	Prev       string `json:"prev,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+type VehicleHandlerSearch struct {
+	Score        float64 `json:"score"`
+	Id           int     `json:"id"`
+	Brand        string  `json:"brand"`
+	Model        string  `json:"model"`
+	Registration string  `json:"registration"`
+	Year         int     `json:"year"`
+	Color        string  `json:"color"`
+	MaxSpeed     int     `json:"max_speed"`
+	FuelType     string  `json:"fuel_type"`
+	Transmission string  `json:"transmission"`
+	Passengers   int     `json:"passengers"`
+	Height       float64 `json:"height"`
+	Width        float64 `json:"width"`
+	Weight       float64 `json:"weight"`
+}
+
+type VehicleHandlerSimilar struct {
+	Distance     float64 `json:"distance"`
+	Id           int     `json:"id"`
+	Brand        string  `json:"brand"`
+	Model        string  `json:"model"`
+	Registration string  `json:"registration"`
+	Year         int     `json:"year"`
+	Color        string  `json:"color"`
+	MaxSpeed     int     `json:"max_speed"`
+	FuelType     string  `json:"fuel_type"`
+	Transmission string  `json:"transmission"`
+	Passengers   int     `json:"passengers"`
+	Height       float64 `json:"height"`
+	Width        float64 `json:"width"`
+	Weight       float64 `json:"weight"`
+}
+
+// ResponseBodySimilar are the vehicles similar to another, with the weights of the distance.
+type ResponseBodySimilar struct {
+	Id      int                     `json:"id"`
+	Weights map[string]float64      `json:"weights"`
+	Data    []VehicleHandlerSimilar `json:"vehicles"`
+}
+
+type ResponseBodySearch struct {
+	Query string                 `json:"query"`
+	Total int                    `json:"total"`
+	Data  []VehicleHandlerSearch `json:"vehicles"`
+}
+
+// Suggestion is a distinct value of a field with its number of vehicles.
+type Suggestion struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// ResponseBodySuggest are the suggestions of a field for a prefix.
+type ResponseBodySuggest struct {
+	Field       string       `json:"field"`
+	Prefix      string       `json:"prefix"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// DuplicateGroup are vehicles that are duplicates of each other for a reason.
+type DuplicateGroup struct {
+	Reason   string                 `json:"reason"`
+	Vehicles []VehicleHandlerGetAll `json:"vehicles"`
+}
+
+// ResponseBodyDuplicates are the groups of duplicated vehicles.
+type ResponseBodyDuplicates struct {
+	Tolerance float64          `json:"tolerance"`
+	Total     int              `json:"total"`
+	Groups    []DuplicateGroup `json:"groups"`
+}
@@ -0,0 +1,9 @@
+package domain
+
+// BatchFailure is a vehicle of a batch that couldn't be inserted.
+type BatchFailure struct {
+	// Index is the position of the vehicle in the batch.
+	Index int
+	Id    int
+	Err   error
+}
@@ -0,0 +1,17 @@
+package domain
+
+// DuplicateReason is why vehicles are taken as the same one.
+type DuplicateReason string
+
+const (
+	// DuplicateReasonRegistration is for vehicles with the same registration.
+	DuplicateReasonRegistration DuplicateReason = "registration"
+	// DuplicateReasonAttributes is for vehicles with the same brand, model, year and color and near-identical dimensions.
+	DuplicateReasonAttributes DuplicateReason = "attributes"
+)
+
+// DuplicateGroup are vehicles that are duplicates of each other for a reason, sorted by id.
+type DuplicateGroup struct {
+	Reason   DuplicateReason
+	Vehicles []*Vehicle
+}
@@ -0,0 +1,56 @@
+package domain
+
+// Filter is a condition over the fields of a vehicle, represented as a tree of
+// FilterAnd, FilterOr, FilterNot and FilterCondition nodes.
+type Filter interface {
+	filter()
+}
+
+// FilterAnd matches the vehicles matched by every filter.
+type FilterAnd struct {
+	Filters []Filter
+}
+
+// FilterOr matches the vehicles matched by any filter.
+type FilterOr struct {
+	Filters []Filter
+}
+
+// FilterNot matches the vehicles not matched by the filter.
+type FilterNot struct {
+	Filter Filter
+}
+
+// FilterOperator is the comparison of a FilterCondition.
+type FilterOperator string
+
+const (
+	FilterOperatorEq FilterOperator = "="
+	FilterOperatorNe FilterOperator = "!="
+	FilterOperatorLt FilterOperator = "<"
+	FilterOperatorLe FilterOperator = "<="
+	FilterOperatorGt FilterOperator = ">"
+	FilterOperatorGe FilterOperator = ">="
+	FilterOperatorIn FilterOperator = "in"
+	// FilterOperatorIEq is the equality of text fields ignoring case.
+	FilterOperatorIEq FilterOperator = "~"
+)
+
+// FilterValue is a value of a FilterCondition: Number for numeric fields and Text for text fields.
+type FilterValue struct {
+	Text   string
+	Number float64
+}
+
+// FilterCondition matches the vehicles whose field compares to the values with the operator.
+// Every operator has a single value except FilterOperatorIn, that matches any of them.
+type FilterCondition struct {
+	Field    VehicleField
+	Operator FilterOperator
+	Values   []FilterValue
+}
+
+func (FilterAnd) filter()       {}
+func (FilterOr) filter()        {}
+func (FilterNot) filter()       {}
+func (FilterCondition) filter() {}
@@ -0,0 +1,39 @@
+package domain
+
+// HistogramOptions selects the bins of a histogram of a numeric field. The bins are set by exactly one of
+// Width, Bins or Edges.
+type HistogramOptions struct {
+	// Field is the numeric field whose values are counted.
+	Field VehicleField
+	// Width is the width of the bins, which start at Origin plus a multiple of it, e.g. 10 for decades.
+	Width float64
+	// Origin is the value the bins of Width are aligned to.
+	Origin float64
+	// Bins is the number of bins of the same width between the least and the greatest value.
+	Bins int
+	// Edges are the increasing limits of the bins.
+	Edges []float64
+	// SplitBy is the text field whose values split the count of every bin, none if empty.
+	SplitBy VehicleField
+}
+
+// HistogramBin is a bin of a histogram: the values from Min, inclusive, to Max, exclusive except in the last bin.
+type HistogramBin struct {
+	Min   float64
+	Max   float64
+	Count int
+	// Split is the count of every value of the split by field in the bin.
+	Split map[string]int
+}
+
+// Histogram is the distribution of the values of a numeric field of a set of vehicles.
+type Histogram struct {
+	// Options are the options the histogram was computed with.
+	Options HistogramOptions
+	// Count is the number of vehicles.
+	Count int
+	// Bins are the bins, sorted by value. Empty bins between others are included.
+	Bins []HistogramBin
+	// Outside is the number of vehicles out of the edges of the options.
+	Outside int
+}
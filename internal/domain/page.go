@@ -0,0 +1,47 @@
+package domain
+
+// SortKey is a field to sort vehicles by.
+type SortKey struct {
+	// Field is the field to sort by.
+	Field VehicleField
+	// Desc is whether the order is descending.
+	Desc bool
+}
+
+// PageOptions is an struct that represents how to sort and split a list of vehicles into pages.
+type PageOptions struct {
+	// Sort are the fields to sort by, in order of precedence. Ties are always sorted by id.
+	Sort []SortKey
+	// Offset is the number of vehicles to skip. It can't be used with Cursor.
+	Offset int
+	// Limit is the maximum number of vehicles of the page, 0 for no limit.
+	Limit int
+	// Cursor is the cursor of a page returned by a previous request with the same sort.
+	Cursor string
+}
+
+// Page is an struct that represents a page of a list of vehicles.
+type Page struct {
+	// Vehicles are the vehicles of the page.
+	Vehicles []*Vehicle
+	// Total is the number of vehicles of the whole list.
+	Total int
+	// Offset is the position of the first vehicle of the page in the list.
+	Offset int
+	// Limit is the limit the page was taken with.
+	Limit int
+	// NextCursor is the cursor of the following page, empty in the last page.
+	NextCursor string
+	// PrevCursor is the cursor of the preceding page, empty in the first page.
+	PrevCursor string
+}
+
+// HasNext reports whether there are vehicles after the page.
+func (p Page) HasNext() bool {
+	return p.Offset+len(p.Vehicles) < p.Total
+}
+
+// HasPrev reports whether there are vehicles before the page.
+func (p Page) HasPrev() bool {
+	return p.Offset > 0
+}
@@ -0,0 +1,17 @@
+package domain
+
+// PatchFormat is the format of a patch document.
+type PatchFormat string
+
+const (
+	// PatchFormatMerge is a JSON Merge Patch, RFC 7396.
+	PatchFormatMerge PatchFormat = "merge"
+	// PatchFormatJSON is a JSON Patch, RFC 6902.
+	PatchFormatJSON PatchFormat = "json"
+)
+
+// Patch is a document that changes the attributes of a vehicle, as json with the names of VehicleField.
+type Patch struct {
+	Format   PatchFormat
+	Document []byte
+}
@@ -0,0 +1,96 @@
+package domain
+
+import (
+	"strconv"
+)
+
+// RangeBound is an end of a Range.
+type RangeBound struct {
+	// Value is the limit of the bound.
+	Value float64
+	// Inclusive reports whether Value is part of the range.
+	Inclusive bool
+	// Unbounded reports whether the range has no limit at this end, in which case Value is ignored.
+	Unbounded bool
+}
+
+// Range is an interval of the values of a numeric field, each end of it inclusive, exclusive or open.
+type Range struct {
+	Min RangeBound
+	Max RangeBound
+}
+
+// AboveMin reports whether the value satisfies the lower bound of the range.
+func (r Range) AboveMin(v float64) bool {
+	return r.Min.Unbounded || v > r.Min.Value || r.Min.Inclusive && v == r.Min.Value
+}
+
+// BelowMax reports whether the value satisfies the upper bound of the range.
+func (r Range) BelowMax(v float64) bool {
+	return r.Max.Unbounded || v < r.Max.Value || r.Max.Inclusive && v == r.Max.Value
+}
+
+// Contains reports whether the value is in the range.
+func (r Range) Contains(v float64) bool {
+	return r.AboveMin(v) && r.BelowMax(v)
+}
+
+// IsUnbounded reports whether the range has no limit at either end.
+func (r Range) IsUnbounded() bool {
+	return r.Min.Unbounded && r.Max.Unbounded
+}
+
+// IsEmpty reports whether no value can be in the range.
+func (r Range) IsEmpty() bool {
+	if r.Min.Unbounded || r.Max.Unbounded {
+		return false
+	}
+	return r.Min.Value > r.Max.Value || r.Min.Value == r.Max.Value && !(r.Min.Inclusive && r.Max.Inclusive)
+}
+
+// String returns the range in interval notation, as [100,200) or (200,].
+func (r Range) String() string {
+	s := "("
+	if r.Min.Inclusive && !r.Min.Unbounded {
+		s = "["
+	}
+	if !r.Min.Unbounded {
+		s += strconv.FormatFloat(r.Min.Value, 'g', -1, 64)
+	}
+	s += ","
+	if !r.Max.Unbounded {
+		s += strconv.FormatFloat(r.Max.Value, 'g', -1, 64)
+	}
+	if r.Max.Inclusive && !r.Max.Unbounded {
+		return s + "]"
+	}
+	return s + ")"
+}
+
+// Filter returns the filter of the vehicles whose field is in the range.
+func (r Range) Filter(field VehicleField) Filter {
+	filters := make([]Filter, 0, 2)
+	if !r.Min.Unbounded {
+		op := FilterOperatorGt
+		if r.Min.Inclusive {
+			op = FilterOperatorGe
+		}
+		filters = append(filters, FilterCondition{Field: field, Operator: op, Values: []FilterValue{rangeFilterValue(r.Min.Value)}})
+	}
+	if !r.Max.Unbounded {
+		op := FilterOperatorLt
+		if r.Max.Inclusive {
+			op = FilterOperatorLe
+		}
+		filters = append(filters, FilterCondition{Field: field, Operator: op, Values: []FilterValue{rangeFilterValue(r.Max.Value)}})
+	}
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return FilterAnd{Filters: filters}
+}
+
+// rangeFilterValue returns the value of a bound in a filter.
+func rangeFilterValue(v float64) FilterValue {
+	return FilterValue{Text: strconv.FormatFloat(v, 'g', -1, 64), Number: v}
+}
@@ -0,0 +1,72 @@
+package domain
+
+import "testing"
+
+func TestRange_Contains(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Range
+		// in are values in the range, out values that aren't.
+		in  []float64
+		out []float64
+	}{
+		{
+			name: "inclusive",
+			r:    Range{Min: RangeBound{Value: 100, Inclusive: true}, Max: RangeBound{Value: 200, Inclusive: true}},
+			in:   []float64{100, 150, 200},
+			out:  []float64{99.9, 200.1},
+		},
+		{
+			name: "exclusive",
+			r:    Range{Min: RangeBound{Value: 100}, Max: RangeBound{Value: 200}},
+			in:   []float64{100.1, 199.9},
+			out:  []float64{100, 200},
+		},
+		{
+			name: "open min",
+			r:    Range{Min: RangeBound{Unbounded: true}, Max: RangeBound{Value: 200, Inclusive: true}},
+			in:   []float64{-1e9, 200},
+			out:  []float64{200.1},
+		},
+		{
+			name: "open max",
+			r:    Range{Min: RangeBound{Value: 100}, Max: RangeBound{Unbounded: true}},
+			in:   []float64{100.1, 1e9},
+			out:  []float64{100},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, v := range tt.in {
+				if !tt.r.Contains(v) {
+					t.Errorf("%s doesn't contain %v", tt.r, v)
+				}
+			}
+			for _, v := range tt.out {
+				if tt.r.Contains(v) {
+					t.Errorf("%s contains %v", tt.r, v)
+				}
+			}
+		})
+	}
+}
+
+func TestRange_IsEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		r    Range
+		want bool
+	}{
+		{name: "single value", r: Range{Min: RangeBound{Value: 1, Inclusive: true}, Max: RangeBound{Value: 1, Inclusive: true}}, want: false},
+		{name: "single value excluded", r: Range{Min: RangeBound{Value: 1, Inclusive: true}, Max: RangeBound{Value: 1}}, want: true},
+		{name: "min greater than max", r: Range{Min: RangeBound{Value: 2, Inclusive: true}, Max: RangeBound{Value: 1, Inclusive: true}}, want: true},
+		{name: "open end", r: Range{Min: RangeBound{Value: 2}, Max: RangeBound{Unbounded: true}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.r.IsEmpty(); got != tt.want {
+				t.Errorf("%s IsEmpty returned %t, want %t", tt.r, got, tt.want)
+			}
+		})
+	}
+}
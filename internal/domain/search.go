@@ -0,0 +1,7 @@
+package domain
+
+// SearchResult is a vehicle that matches a search, with its relevance: the greater the score, the better the match.
+type SearchResult struct {
+	Vehicle *Vehicle
+	Score   float64
+}
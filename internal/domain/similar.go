@@ -0,0 +1,15 @@
+package domain
+
+// SimilarOptions selects how the vehicles similar to another are found.
+type SimilarOptions struct {
+	// K is the number of similar vehicles.
+	K int
+	// Weights are the weights of the fields in the distance, the fields left out weigh nothing.
+	Weights map[VehicleField]float64
+}
+
+// SimilarResult is a vehicle similar to another, with its distance: the smaller, the more similar.
+type SimilarResult struct {
+	Vehicle  *Vehicle
+	Distance float64
+}
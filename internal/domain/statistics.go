@@ -0,0 +1,54 @@
+package domain
+
+// StatisticsOptions selects the statistics computed over the vehicles.
+type StatisticsOptions struct {
+	// GroupBy are the fields whose values split the vehicles in groups, none for a single group.
+	GroupBy []VehicleField
+	// Fields are the numeric fields whose values are summarized.
+	Fields []VehicleField
+	// Percentiles are the percentiles computed for every field, from 0 to 100.
+	Percentiles []float64
+}
+
+// Summary is the summary of the values of a numeric field.
+type Summary struct {
+	Count  int
+	Min    float64
+	Max    float64
+	Mean   float64
+	Median float64
+	// Percentiles are the values of the percentiles of the options, in the same order.
+	Percentiles []float64
+}
+
+// StatisticsGroup are the statistics of the vehicles that share the values of the group by fields.
+type StatisticsGroup struct {
+	// Key are the values of the group by fields: a float64 for numeric fields and a string for text fields.
+	Key map[VehicleField]any
+	// Count is the number of vehicles of the group.
+	Count int
+	// Summaries are the summaries of the fields.
+	Summaries map[VehicleField]Summary
+}
+
+// Average is the average of a numeric field of the vehicles of a brand.
+type Average struct {
+	// Brand is the brand as stored, or as requested when it has no vehicles.
+	Brand   string
+	Field   VehicleField
+	Average float64
+	// Count is the number of vehicles of the brand.
+	Count int
+	// Models are the distinct models of the vehicles, sorted.
+	Models []string
+}
+
+// Statistics are the statistics of a set of vehicles.
+type Statistics struct {
+	// Options are the options the statistics were computed with.
+	Options StatisticsOptions
+	// Count is the number of vehicles.
+	Count int
+	// Groups are the groups of vehicles, sorted by their key.
+	Groups []StatisticsGroup
+}
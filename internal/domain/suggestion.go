@@ -0,0 +1,7 @@
+package domain
+
+// Suggestion is a distinct value of a field with the number of vehicles that have it.
+type Suggestion struct {
+	Value string
+	Count int
+}
@@ -1,5 +1,7 @@
 package domain
 
+import "time"
+
 // VehicleAttributes is an struct that represents the attributes of a vehicle.
 type VehicleAttributes struct {
 	// Brand is the brand of the vehicle.
@@ -39,4 +41,9 @@ type Vehicle struct {
 	
 	// Attributes is the attributes of the vehicle.
 	Attributes 	 VehicleAttributes
+
+	// Version is the version of the vehicle, that starts at 1 and increases on every change.
+	Version 	 int
+	// UpdatedAt is the time of the last change of the vehicle.
+	UpdatedAt 	 time.Time
 }
\ No newline at end of file
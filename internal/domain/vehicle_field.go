@@ -0,0 +1,131 @@
+package domain
+
+import "strings"
+
+// VehicleField is the name of an attribute of a vehicle, as used in the json of the api.
+type VehicleField string
+
+const (
+	VehicleFieldId           VehicleField = "id"
+	VehicleFieldBrand        VehicleField = "brand"
+	VehicleFieldModel        VehicleField = "model"
+	VehicleFieldRegistration VehicleField = "registration"
+	VehicleFieldYear         VehicleField = "year"
+	VehicleFieldColor        VehicleField = "color"
+	VehicleFieldMaxSpeed     VehicleField = "max_speed"
+	VehicleFieldFuelType     VehicleField = "fuel_type"
+	VehicleFieldTransmission VehicleField = "transmission"
+	VehicleFieldPassengers   VehicleField = "passengers"
+	VehicleFieldHeight       VehicleField = "height"
+	VehicleFieldWidth        VehicleField = "width"
+	VehicleFieldWeight       VehicleField = "weight"
+)
+
+// VehicleFields are all the fields of a vehicle.
+var VehicleFields = []VehicleField{
+	VehicleFieldId, VehicleFieldBrand, VehicleFieldModel, VehicleFieldRegistration, VehicleFieldYear, VehicleFieldColor,
+	VehicleFieldMaxSpeed, VehicleFieldFuelType, VehicleFieldTransmission, VehicleFieldPassengers,
+	VehicleFieldHeight, VehicleFieldWidth, VehicleFieldWeight,
+}
+
+// ParseVehicleField returns the field with the given name, ignoring case, and whether it exists.
+func ParseVehicleField(name string) (VehicleField, bool) {
+	for _, f := range VehicleFields {
+		if strings.EqualFold(string(f), name) {
+			return f, true
+		}
+	}
+	return "", false
+}
+
+// IsNumeric reports whether the field has a numeric value.
+func (f VehicleField) IsNumeric() bool {
+	switch f {
+	case VehicleFieldId, VehicleFieldYear, VehicleFieldMaxSpeed, VehicleFieldPassengers,
+		VehicleFieldHeight, VehicleFieldWidth, VehicleFieldWeight:
+		return true
+	default:
+		return false
+	}
+}
+
+// Number returns the value of a numeric field of the vehicle, 0 for other fields.
+func (v *Vehicle) Number(f VehicleField) float64 {
+	switch f {
+	case VehicleFieldId:
+		return float64(v.Id)
+	case VehicleFieldYear:
+		return float64(v.Attributes.Year)
+	case VehicleFieldMaxSpeed:
+		return float64(v.Attributes.MaxSpeed)
+	case VehicleFieldPassengers:
+		return float64(v.Attributes.Passengers)
+	case VehicleFieldHeight:
+		return v.Attributes.Height
+	case VehicleFieldWidth:
+		return v.Attributes.Width
+	case VehicleFieldWeight:
+		return v.Attributes.Weight
+	default:
+		return 0
+	}
+}
+
+// Text returns the value of a text field of the vehicle, empty for other fields.
+func (v *Vehicle) Text(f VehicleField) string {
+	switch f {
+	case VehicleFieldBrand:
+		return v.Attributes.Brand
+	case VehicleFieldModel:
+		return v.Attributes.Model
+	case VehicleFieldRegistration:
+		return v.Attributes.Registration
+	case VehicleFieldColor:
+		return v.Attributes.Color
+	case VehicleFieldFuelType:
+		return v.Attributes.FuelType
+	case VehicleFieldTransmission:
+		return v.Attributes.Transmission
+	default:
+		return ""
+	}
+}
+
+// SetNumber sets the value of a numeric field of the vehicle, truncating it for integer fields.
+// Other fields are left as they are.
+func (v *Vehicle) SetNumber(f VehicleField, n float64) {
+	switch f {
+	case VehicleFieldId:
+		v.Id = int(n)
+	case VehicleFieldYear:
+		v.Attributes.Year = int(n)
+	case VehicleFieldMaxSpeed:
+		v.Attributes.MaxSpeed = int(n)
+	case VehicleFieldPassengers:
+		v.Attributes.Passengers = int(n)
+	case VehicleFieldHeight:
+		v.Attributes.Height = n
+	case VehicleFieldWidth:
+		v.Attributes.Width = n
+	case VehicleFieldWeight:
+		v.Attributes.Weight = n
+	}
+}
+
+// SetText sets the value of a text field of the vehicle. Other fields are left as they are.
+func (v *Vehicle) SetText(f VehicleField, s string) {
+	switch f {
+	case VehicleFieldBrand:
+		v.Attributes.Brand = s
+	case VehicleFieldModel:
+		v.Attributes.Model = s
+	case VehicleFieldRegistration:
+		v.Attributes.Registration = s
+	case VehicleFieldColor:
+		v.Attributes.Color = s
+	case VehicleFieldFuelType:
+		v.Attributes.FuelType = s
+	case VehicleFieldTransmission:
+		v.Attributes.Transmission = s
+	}
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// FuelTypes are the fuel types a vehicle can have.
+	FuelTypes = []string{"diesel", "biodiesel", "gas", "gasoline"}
+	// Transmissions are the transmissions a vehicle can have.
+	Transmissions = []string{"automatic", "manual", "semi-automatic"}
+)
+
+// MinYear is the fabrication year of the first vehicle.
+const MinYear = 1886
+
+// ValidationError is an struct that describes an attribute of a vehicle that breaks a rule.
+type ValidationError struct {
+	// Field is the json name of the attribute.
+	Field string `json:"field"`
+	// Rule is the name of the rule.
+	Rule string `json:"rule"`
+	// Message is the description of the error.
+	Message string `json:"message"`
+}
+
+// Error returns the description of the error.
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// IsFuelType reports whether fuelType is one of FuelTypes, ignoring case.
+func IsFuelType(fuelType string) bool {
+	return contains(FuelTypes, fuelType)
+}
+
+// IsTransmission reports whether transmission is one of Transmissions, ignoring case.
+func IsTransmission(transmission string) bool {
+	return contains(Transmissions, transmission)
+}
+
+// Validate returns the errors of the attributes of the vehicle, empty when it's valid.
+func (a VehicleAttributes) Validate() (errs []ValidationError) {
+	required := func(field string, value string) {
+		if strings.TrimSpace(value) == "" {
+			errs = append(errs, ValidationError{Field: field, Rule: "required", Message: "must not be empty"})
+		}
+	}
+	positive := func(field string, value float64) {
+		if value <= 0 {
+			errs = append(errs, ValidationError{Field: field, Rule: "positive", Message: fmt.Sprintf("must be greater than 0, got %v", value)})
+		}
+	}
+
+	required("brand", a.Brand)
+	required("model", a.Model)
+	required("registration", a.Registration)
+	required("color", a.Color)
+	if maxYear := time.Now().Year(); a.Year < MinYear || a.Year > maxYear {
+		errs = append(errs, ValidationError{Field: "year", Rule: "range", Message: fmt.Sprintf("must be between %d and %d, got %d", MinYear, maxYear, a.Year)})
+	}
+	positive("max_speed", float64(a.MaxSpeed))
+	if !IsFuelType(a.FuelType) {
+		errs = append(errs, ValidationError{Field: "fuel_type", Rule: "enum", Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(FuelTypes, ", "), a.FuelType)})
+	}
+	if !IsTransmission(a.Transmission) {
+		errs = append(errs, ValidationError{Field: "transmission", Rule: "enum", Message: fmt.Sprintf("must be one of %s, got %q", strings.Join(Transmissions, ", "), a.Transmission)})
+	}
+	positive("passengers", float64(a.Passengers))
+	positive("height", a.Height)
+	positive("width", a.Width)
+	positive("weight", a.Weight)
+	return
+}
+
+// contains reports whether value is one of values, ignoring case.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
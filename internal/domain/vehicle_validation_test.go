@@ -0,0 +1,45 @@
+package domain
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestVehicleAttributes_Validate(t *testing.T) {
+	valid := VehicleAttributes{Brand: "Ford", Model: "Ka", Registration: "AB123", Year: 2001, Color: "Red", MaxSpeed: 150,
+		FuelType: "gasoline", Transmission: "manual", Passengers: 4, Height: 150, Width: 170, Weight: 900}
+
+	tests := []struct {
+		name   string
+		change func(a *VehicleAttributes)
+		// rules are the field and rule of every error, as field:rule.
+		rules []string
+	}{
+		{name: "valid", change: func(a *VehicleAttributes) {}, rules: []string{}},
+		{name: "case of the enums", change: func(a *VehicleAttributes) { a.FuelType, a.Transmission = "DIESEL", "Semi-Automatic" }, rules: []string{}},
+		{name: "first year", change: func(a *VehicleAttributes) { a.Year = MinYear }, rules: []string{}},
+		{name: "blank texts", change: func(a *VehicleAttributes) { a.Brand, a.Model, a.Registration, a.Color = "", " ", "", "\t" },
+			rules: []string{"brand:required", "model:required", "registration:required", "color:required"}},
+		{name: "year before the first vehicle", change: func(a *VehicleAttributes) { a.Year = MinYear - 1 }, rules: []string{"year:range"}},
+		{name: "year in the future", change: func(a *VehicleAttributes) { a.Year = time.Now().Year() + 1 }, rules: []string{"year:range"}},
+		{name: "unknown enums", change: func(a *VehicleAttributes) { a.FuelType, a.Transmission = "steam", "cvt" },
+			rules: []string{"fuel_type:enum", "transmission:enum"}},
+		{name: "not positive numbers", change: func(a *VehicleAttributes) {
+			a.MaxSpeed, a.Passengers, a.Height, a.Width, a.Weight = 0, -1, 0, -0.5, 0
+		}, rules: []string{"max_speed:positive", "passengers:positive", "height:positive", "width:positive", "weight:positive"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := valid
+			tt.change(&a)
+			rules := make([]string, 0)
+			for _, e := range a.Validate() {
+				rules = append(rules, e.Field+":"+e.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.rules) {
+				t.Errorf("Validate returned %v, want %v", rules, tt.rules)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"fmt"
+	"hash/fnv"
+)
+
+// ETag returns the entity tag of the vehicle, a quoted string with its version and a hash of its attributes.
+// The hash tells apart vehicles with the same version and different attributes, as the versions of the
+// repositories in memory start over when the vehicles are loaded again.
+func (v *Vehicle) ETag() string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%#v", v.Attributes)
+	return fmt.Sprintf("\"%d-%016x\"", v.Version, h.Sum64())
+}
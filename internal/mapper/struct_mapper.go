@@ -13,6 +13,10 @@ type StructMapper interface {
 	MapToVehicleHandlerGetByTransmission(vehicle domain.Vehicle) web.VehicleHandlerGetByTransmission
 	MapToVehicleHandlerBatch(vehicles []web.VehicleHandlerPost) []*domain.Vehicle
 	MapToVehicleHandlerPost(vehicles web.VehicleHandlerPost) *domain.Vehicle
+	MapToVehicleHandlerSearch(result domain.SearchResult) web.VehicleHandlerSearch
+	MapToVehicleHandlerSimilar(result domain.SimilarResult) web.VehicleHandlerSimilar
+	MapToVehicleHandlerGetAll(vehicle domain.Vehicle) web.VehicleHandlerGetAll
+	MapToVehicleHandlerGetById(vehicle domain.Vehicle) web.VehicleHandlerGetById
 }
 
 type structMapper struct {
@@ -139,3 +143,81 @@ func (sm *structMapper) MapToVehicleHandlerPost(vehicle web.VehicleHandlerPost)
 		},
 	}
 }
+
+func (sm *structMapper) MapToVehicleHandlerSearch(result domain.SearchResult) web.VehicleHandlerSearch {
+	vehicle := result.Vehicle
+	return web.VehicleHandlerSearch{
+		Score:        result.Score,
+		Id:           vehicle.Id,
+		Brand:        vehicle.Attributes.Brand,
+		Model:        vehicle.Attributes.Model,
+		Registration: vehicle.Attributes.Registration,
+		Year:         vehicle.Attributes.Year,
+		Color:        vehicle.Attributes.Color,
+		MaxSpeed:     vehicle.Attributes.MaxSpeed,
+		FuelType:     vehicle.Attributes.FuelType,
+		Transmission: vehicle.Attributes.Transmission,
+		Passengers:   vehicle.Attributes.Passengers,
+		Height:       vehicle.Attributes.Height,
+		Width:        vehicle.Attributes.Width,
+		Weight:       vehicle.Attributes.Weight,
+	}
+}
+
+func (sm *structMapper) MapToVehicleHandlerSimilar(result domain.SimilarResult) web.VehicleHandlerSimilar {
+	vehicle := result.Vehicle
+	return web.VehicleHandlerSimilar{
+		Distance:     result.Distance,
+		Id:           vehicle.Id,
+		Brand:        vehicle.Attributes.Brand,
+		Model:        vehicle.Attributes.Model,
+		Registration: vehicle.Attributes.Registration,
+		Year:         vehicle.Attributes.Year,
+		Color:        vehicle.Attributes.Color,
+		MaxSpeed:     vehicle.Attributes.MaxSpeed,
+		FuelType:     vehicle.Attributes.FuelType,
+		Transmission: vehicle.Attributes.Transmission,
+		Passengers:   vehicle.Attributes.Passengers,
+		Height:       vehicle.Attributes.Height,
+		Width:        vehicle.Attributes.Width,
+		Weight:       vehicle.Attributes.Weight,
+	}
+}
+
+func (sm *structMapper) MapToVehicleHandlerGetAll(vehicle domain.Vehicle) web.VehicleHandlerGetAll {
+	return web.VehicleHandlerGetAll{
+		Id:           vehicle.Id,
+		Brand:        vehicle.Attributes.Brand,
+		Model:        vehicle.Attributes.Model,
+		Registration: vehicle.Attributes.Registration,
+		Year:         vehicle.Attributes.Year,
+		Color:        vehicle.Attributes.Color,
+		MaxSpeed:     vehicle.Attributes.MaxSpeed,
+		FuelType:     vehicle.Attributes.FuelType,
+		Transmission: vehicle.Attributes.Transmission,
+		Passengers:   vehicle.Attributes.Passengers,
+		Height:       vehicle.Attributes.Height,
+		Width:        vehicle.Attributes.Width,
+		Weight:       vehicle.Attributes.Weight,
+	}
+}
+
+func (sm *structMapper) MapToVehicleHandlerGetById(vehicle domain.Vehicle) web.VehicleHandlerGetById {
+	return web.VehicleHandlerGetById{
+		Id:           vehicle.Id,
+		Brand:        vehicle.Attributes.Brand,
+		Model:        vehicle.Attributes.Model,
+		Registration: vehicle.Attributes.Registration,
+		Year:         vehicle.Attributes.Year,
+		Color:        vehicle.Attributes.Color,
+		MaxSpeed:     vehicle.Attributes.MaxSpeed,
+		FuelType:     vehicle.Attributes.FuelType,
+		Transmission: vehicle.Attributes.Transmission,
+		Passengers:   vehicle.Attributes.Passengers,
+		Height:       vehicle.Attributes.Height,
+		Width:        vehicle.Attributes.Width,
+		Weight:       vehicle.Attributes.Weight,
+		Version:      vehicle.Version,
+		UpdatedAt:    vehicle.UpdatedAt,
+	}
+}
@@ -1,16 +1,84 @@
 package loader
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"strings"
 )
 
 var (
 	// ErrLoaderVehicleInternal is returned when an internal error occurs.
 	ErrLoaderVehicleInternal = errors.New("loader: internal error")
+
+	// ErrLoaderVehicleRecord is returned when some records of the dataset can't be read, or repeat the id of
+	// a previous one. The other records are still loaded.
+	ErrLoaderVehicleRecord = errors.New("loader: invalid record")
 )
 
 // LoaderVehicle is the interface that wraps the basic methods for a vehicle loader.
 type LoaderVehicle interface {
 	Load() (v map[int]*domain.VehicleAttributes, err error)
 }
+
+// RecordError is an struct that describes why a record of the dataset can't be read.
+type RecordError struct {
+	// Record is the position of the record in the file, starting at 1, when the format doesn't track lines.
+	Record int `json:"record,omitempty"`
+	// Line is the line of the file where the record or the field is.
+	Line int `json:"line,omitempty"`
+	// Field is the field of the record, empty when the whole record is wrong.
+	Field string `json:"field,omitempty"`
+	// Err is the cause of the error.
+	Err error `json:"-"`
+}
+
+// Error returns the description of the error.
+func (e *RecordError) Error() string {
+	var position string
+	switch {
+	case e.Line != 0:
+		position = fmt.Sprintf("line %d", e.Line)
+	case e.Record != 0:
+		position = fmt.Sprintf("record %d", e.Record)
+	}
+	switch {
+	case e.Field == "":
+		return fmt.Sprintf("%s: %v", position, e.Err)
+	case position == "":
+		return fmt.Sprintf("field %s: %v", e.Field, e.Err)
+	}
+	return fmt.Sprintf("%s, field %s: %v", position, e.Field, e.Err)
+}
+
+// MarshalJSON returns the json of the error, with its description in message.
+func (e *RecordError) MarshalJSON() ([]byte, error) {
+	type recordError RecordError
+	return json.Marshal(struct {
+		*recordError
+		Message string `json:"message"`
+	}{(*recordError)(e), e.Err.Error()})
+}
+
+// Unwrap returns ErrLoaderVehicleRecord, so every record error matches it.
+func (e *RecordError) Unwrap() error {
+	return ErrLoaderVehicleRecord
+}
+
+// RecordErrors is the list of errors of the records that can't be read, returned by Load along the records that could.
+type RecordErrors []*RecordError
+
+// Error returns the description of every error.
+func (e RecordErrors) Error() string {
+	msgs := make([]string, 0, len(e))
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%v. %d errors: %s", ErrLoaderVehicleRecord, len(e), strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrLoaderVehicleRecord.
+func (e RecordErrors) Is(target error) bool {
+	return target == ErrLoaderVehicleRecord
+}
@@ -0,0 +1,97 @@
+package loader
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"io"
+	"os"
+	"strings"
+)
+
+// NewLoaderVehicleCSV returns a new instance of a vehicle loader.
+func NewLoaderVehicleCSV(path string) *LoaderVehicleCSV {
+	return &LoaderVehicleCSV{Path: path}
+}
+
+// LoaderVehicleCSV is an struct that implements the LoaderVehicle interface.
+// The first row of the file is a header with the json names of the fields, in any order.
+type LoaderVehicleCSV struct {
+	Path string
+}
+
+// Load returns all vehicles.
+// Rows that can't be read are reported in a RecordErrors along the vehicles of the other rows.
+func (l *LoaderVehicleCSV) Load() (v map[int]*domain.VehicleAttributes, err error) {
+	return loadFromStream(l)
+}
+
+// Stream sends every vehicle to sink, a row at a time.
+func (l *LoaderVehicleCSV) Stream(sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error) {
+	// open file
+	f, err := os.Open(l.Path)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+	defer f.Close()
+
+	// read header
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	header, err := r.Read()
+	if err != nil {
+		err = fmt.Errorf("%w. header: %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+	for i := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(header[i]))
+		if errors.Is(new(VehicleJSON).SetField(header[i], ""), errVehicleJSONUnknownField) {
+			err = fmt.Errorf("%w. header: unknown column %q", ErrLoaderVehicleInternal, header[i])
+			return
+		}
+	}
+
+	// read rows
+	st := newStreamer(sink, progress)
+	for {
+		row, errRead := r.Read()
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(errRead, &parseErr) {
+				err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, errRead)
+				return
+			}
+			st.skip(&RecordError{Line: parseErr.Line, Err: parseErr.Err})
+			continue
+		}
+
+		line, _ := r.FieldPos(0)
+		if len(row) != len(header) {
+			st.skip(&RecordError{Line: line, Err: fmt.Errorf("expected %d fields, got %d", len(header), len(row))})
+			continue
+		}
+
+		var vehicleJSON VehicleJSON
+		var errs []*RecordError
+		for i, value := range row {
+			if errField := vehicleJSON.SetField(header[i], value); errField != nil {
+				errs = append(errs, &RecordError{Line: line, Field: header[i], Err: errField})
+			}
+		}
+		if len(errs) > 0 {
+			st.skip(errs...)
+			continue
+		}
+
+		if err = st.send(&vehicleJSON, 0, line); err != nil {
+			return
+		}
+	}
+
+	return st.done()
+}
@@ -1,10 +1,14 @@
 package loader
 
 import (
+	"bufio"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
 	"os"
+	"strconv"
+	"strings"
 )
 
 // NewLoaderVehicleJSON returns a new instance of a vehicle loader.
@@ -34,8 +38,94 @@ type VehicleJSON struct {
 	Weight       float64 `json:"weight"`
 }
 
+// NewVehicleJSON returns the json representation of a vehicle.
+func NewVehicleJSON(id int, a *domain.VehicleAttributes) *VehicleJSON {
+	return &VehicleJSON{
+		ID:           id,
+		Brand:        a.Brand,
+		Model:        a.Model,
+		Registration: a.Registration,
+		Year:         a.Year,
+		Color:        a.Color,
+		MaxSpeed:     a.MaxSpeed,
+		FuelType:     a.FuelType,
+		Transmission: a.Transmission,
+		Passengers:   a.Passengers,
+		Height:       a.Height,
+		Width:        a.Width,
+		Weight:       a.Weight,
+	}
+}
+
+// Attributes returns the attributes of the vehicle.
+func (v *VehicleJSON) Attributes() *domain.VehicleAttributes {
+	return &domain.VehicleAttributes{
+		Brand:        v.Brand,
+		Model:        v.Model,
+		Registration: v.Registration,
+		Year:         v.Year,
+		Color:        v.Color,
+		MaxSpeed:     v.MaxSpeed,
+		FuelType:     v.FuelType,
+		Transmission: v.Transmission,
+		Passengers:   v.Passengers,
+		Height:       v.Height,
+		Width:        v.Width,
+		Weight:       v.Weight,
+	}
+}
+
+// errVehicleJSONUnknownField is returned by SetField for a field that VehicleJSON doesn't have.
+var errVehicleJSONUnknownField = errors.New("unknown field")
+
+// SetField parses value as the field with the given json name, for formats where every value is text.
+func (v *VehicleJSON) SetField(field string, value string) (err error) {
+	value = strings.TrimSpace(value)
+	switch field {
+	case "id":
+		v.ID, err = strconv.Atoi(value)
+	case "brand":
+		v.Brand = value
+	case "model":
+		v.Model = value
+	case "registration":
+		v.Registration = value
+	case "year":
+		v.Year, err = strconv.Atoi(value)
+	case "color":
+		v.Color = value
+	case "max_speed":
+		v.MaxSpeed, err = strconv.Atoi(value)
+	case "fuel_type":
+		v.FuelType = value
+	case "transmission":
+		v.Transmission = value
+	case "passengers":
+		v.Passengers, err = strconv.Atoi(value)
+	case "height":
+		v.Height, err = strconv.ParseFloat(value, 64)
+	case "width":
+		v.Width, err = strconv.ParseFloat(value, 64)
+	case "weight":
+		v.Weight, err = strconv.ParseFloat(value, 64)
+	default:
+		err = errVehicleJSONUnknownField
+	}
+	// strconv errors repeat the value and function name, only the reason is kept
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = fmt.Errorf("invalid value %q: %v", value, numErr.Err)
+	}
+	return
+}
+
 // Load returns all vehicles.
 func (l *LoaderVehicleJSON) Load() (v map[int]*domain.VehicleAttributes, err error) {
+	return loadFromStream(l)
+}
+
+// Stream sends every vehicle to sink, decoding the array an element at a time.
+func (l *LoaderVehicleJSON) Stream(sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error) {
 	// open file
 	f, err := os.Open(l.Path)
 	if err != nil {
@@ -44,32 +134,41 @@ func (l *LoaderVehicleJSON) Load() (v map[int]*domain.VehicleAttributes, err err
 	}
 	defer f.Close()
 
-	// read file
-	var vehiclesJSON []*VehicleJSON
-	err = json.NewDecoder(f).Decode(&vehiclesJSON)
+	// read array
+	dec := json.NewDecoder(bufio.NewReader(f))
+	token, err := dec.Token()
 	if err != nil {
 		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
 		return
 	}
+	if token != json.Delim('[') {
+		err = fmt.Errorf("%w. the document must be an array of vehicles", ErrLoaderVehicleInternal)
+		return
+	}
 
-	// serialize vehicles
-	v = make(map[int]*domain.VehicleAttributes)
-	for _, vehicleJSON := range vehiclesJSON {
-		v[vehicleJSON.ID] = &domain.VehicleAttributes{
-			Brand:        vehicleJSON.Brand,
-			Model:        vehicleJSON.Model,
-			Registration: vehicleJSON.Registration,
-			Year:         vehicleJSON.Year,
-			Color:        vehicleJSON.Color,
-			MaxSpeed:     vehicleJSON.MaxSpeed,
-			FuelType:     vehicleJSON.FuelType,
-			Transmission: vehicleJSON.Transmission,
-			Passengers:   vehicleJSON.Passengers,
-			Height:       vehicleJSON.Height,
-			Width:        vehicleJSON.Width,
-			Weight:       vehicleJSON.Weight,
+	st := newStreamer(sink, progress)
+	for record := 1; dec.More(); record++ {
+		var vehicleJSON VehicleJSON
+		if errDecode := dec.Decode(&vehicleJSON); errDecode != nil {
+			// a value of the wrong type is consumed by the decoder, any other error breaks the array
+			var typeErr *json.UnmarshalTypeError
+			if !errors.As(errDecode, &typeErr) {
+				err = fmt.Errorf("%w. record %d: %v", ErrLoaderVehicleInternal, record, errDecode)
+				return
+			}
+			st.skip(&RecordError{Record: record, Field: typeErr.Field,
+				Err: fmt.Errorf("invalid %s value, expected %s", typeErr.Value, typeErr.Type)})
+			continue
+		}
+
+		if err = st.send(&vehicleJSON, record, 0); err != nil {
+			return
 		}
 	}
+	if _, err = dec.Token(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
 
-	return
+	return st.done()
 }
@@ -0,0 +1,72 @@
+package loader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+)
+
+// NewLoaderVehicleNDJSON returns a new instance of a vehicle loader.
+func NewLoaderVehicleNDJSON(path string) *LoaderVehicleNDJSON {
+	return &LoaderVehicleNDJSON{Path: path}
+}
+
+// LoaderVehicleNDJSON is an struct that implements the LoaderVehicle interface.
+// The file has a VehicleJSON per line.
+type LoaderVehicleNDJSON struct {
+	Path string
+}
+
+// Load returns all vehicles.
+// Lines that can't be read are reported in a RecordErrors along the vehicles of the other lines.
+func (l *LoaderVehicleNDJSON) Load() (v map[int]*domain.VehicleAttributes, err error) {
+	return loadFromStream(l)
+}
+
+// Stream sends every vehicle to sink, a line at a time.
+func (l *LoaderVehicleNDJSON) Stream(sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error) {
+	// open file
+	f, err := os.Open(l.Path)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+	defer f.Close()
+
+	// read lines
+	st := newStreamer(sink, progress)
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		b := bytes.TrimSpace(sc.Bytes())
+		if len(b) == 0 {
+			continue
+		}
+
+		var vehicleJSON VehicleJSON
+		if errDecode := json.Unmarshal(b, &vehicleJSON); errDecode != nil {
+			recordErr := &RecordError{Line: line, Err: errDecode}
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(errDecode, &typeErr) {
+				recordErr.Field = typeErr.Field
+				recordErr.Err = fmt.Errorf("invalid %s value, expected %s", typeErr.Value, typeErr.Type)
+			}
+			st.skip(recordErr)
+			continue
+		}
+
+		if err = st.send(&vehicleJSON, 0, line); err != nil {
+			return
+		}
+	}
+	if err = sc.Err(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+
+	return st.done()
+}
@@ -0,0 +1,56 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var (
+	// ErrLoaderVehicleFormat is returned when there is no loader for the format of a dataset.
+	ErrLoaderVehicleFormat = errors.New("loader: unknown format")
+)
+
+// LoaderVehicleFactory returns a loader of the dataset at path.
+type LoaderVehicleFactory func(path string) LoaderVehicle
+
+var (
+	// muFactories guards factories.
+	muFactories sync.RWMutex
+	// factories are the loaders by format.
+	factories = map[string]LoaderVehicleFactory{
+		"json":   func(path string) LoaderVehicle { return NewLoaderVehicleJSON(path) },
+		"ndjson": func(path string) LoaderVehicle { return NewLoaderVehicleNDJSON(path) },
+		"jsonl":  func(path string) LoaderVehicle { return NewLoaderVehicleNDJSON(path) },
+		"csv":    func(path string) LoaderVehicle { return NewLoaderVehicleCSV(path) },
+		"yaml":   func(path string) LoaderVehicle { return NewLoaderVehicleYAML(path) },
+		"yml":    func(path string) LoaderVehicle { return NewLoaderVehicleYAML(path) },
+	}
+)
+
+// RegisterLoaderVehicle adds or replaces the loader of a format.
+func RegisterLoaderVehicle(format string, factory LoaderVehicleFactory) {
+	muFactories.Lock()
+	defer muFactories.Unlock()
+
+	factories[strings.ToLower(format)] = factory
+}
+
+// NewLoaderVehicle returns the loader of the dataset at path.
+// The format is taken from the extension of the file when it is empty.
+func NewLoaderVehicle(path string, format string) (LoaderVehicle, error) {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+
+	muFactories.RLock()
+	defer muFactories.RUnlock()
+
+	factory, ok := factories[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("%w. %q", ErrLoaderVehicleFormat, format)
+	}
+	return factory(path), nil
+}
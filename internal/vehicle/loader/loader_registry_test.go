@@ -0,0 +1,52 @@
+package loader
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+// loaderVehicleTest is a loader registered by the tests.
+type loaderVehicleTest struct {
+	path string
+}
+
+func (l *loaderVehicleTest) Load() (map[int]*domain.VehicleAttributes, error) {
+	return nil, nil
+}
+
+func TestNewLoaderVehicle(t *testing.T) {
+	RegisterLoaderVehicle("TEST", func(path string) LoaderVehicle { return &loaderVehicleTest{path: path} })
+
+	tests := []struct {
+		name   string
+		path   string
+		format string
+		want   LoaderVehicle
+		err    error
+	}{
+		{name: "json by extension", path: "data/vehicles.json", want: NewLoaderVehicleJSON("data/vehicles.json")},
+		{name: "ndjson by extension", path: "vehicles.ndjson", want: NewLoaderVehicleNDJSON("vehicles.ndjson")},
+		{name: "jsonl by extension", path: "vehicles.jsonl", want: NewLoaderVehicleNDJSON("vehicles.jsonl")},
+		{name: "csv by extension in upper case", path: "vehicles.CSV", want: NewLoaderVehicleCSV("vehicles.CSV")},
+		{name: "yaml by extension", path: "vehicles.yaml", want: NewLoaderVehicleYAML("vehicles.yaml")},
+		{name: "yml by extension", path: "vehicles.yml", want: NewLoaderVehicleYAML("vehicles.yml")},
+		{name: "format over extension", path: "vehicles.txt", format: "csv", want: NewLoaderVehicleCSV("vehicles.txt")},
+		{name: "registered format", path: "vehicles.test", want: &loaderVehicleTest{path: "vehicles.test"}},
+		{name: "unknown extension", path: "vehicles.xml", err: ErrLoaderVehicleFormat},
+		{name: "no extension", path: "vehicles", err: ErrLoaderVehicleFormat},
+		{name: "unknown format", path: "vehicles.json", format: "xml", err: ErrLoaderVehicleFormat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld, err := NewLoaderVehicle(tt.path, tt.format)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("NewLoaderVehicle returned %v, want %v", err, tt.err)
+			}
+			if !reflect.DeepEqual(ld, tt.want) {
+				t.Errorf("NewLoaderVehicle returned %#v, want %#v", ld, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sort"
+	"time"
+)
+
+var (
+	// ErrLoaderVehicleDuplicate is returned by a SinkVehicle to reject a vehicle whose id was already loaded.
+	ErrLoaderVehicleDuplicate = errors.New("loader: duplicated vehicle")
+)
+
+// progressEvery is the number of records read between two progress reports.
+const progressEvery = 100000
+
+// SinkVehicle receives each vehicle read by a LoaderVehicleStream.
+// Returning ErrLoaderVehicleDuplicate or ErrLoaderVehicleInvalid skips the vehicle, any other error stops the load.
+type SinkVehicle func(id int, v *domain.VehicleAttributes) error
+
+// LoaderVehicleStream is the interface implemented by loaders that send the dataset to a sink a record at a time,
+// so the vehicles are never fully held in memory. A format that can't be decoded incrementally holds the parsed
+// document instead, which is reported by LoadStats.Buffered.
+type LoaderVehicleStream interface {
+	// Stream sends every vehicle to sink and calls progress, when not nil, as records are read.
+	// Records that can't be read are reported in a RecordErrors along the stats.
+	Stream(sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error)
+}
+
+// LoadStats is an struct that represents the statistics of a load.
+type LoadStats struct {
+	// Read is the number of records read, valid or not.
+	Read int `json:"read"`
+	// Loaded is the number of vehicles accepted by the sink.
+	Loaded int `json:"loaded"`
+	// Skipped is the number of records that can't be read or were rejected by the sink as invalid.
+	Skipped int `json:"skipped"`
+	// Duplicates is the number of vehicles rejected by the sink as duplicated.
+	Duplicates int `json:"duplicates"`
+	// Elapsed is the duration of the load.
+	Elapsed time.Duration `json:"elapsed"`
+	// Buffered tells whether the whole document was parsed in memory before its records were read.
+	Buffered bool `json:"buffered"`
+}
+
+// newStreamer returns a new instance of a streamer.
+func newStreamer(sink SinkVehicle, progress func(stats LoadStats)) *streamer {
+	return &streamer{sink: sink, progress: progress, start: time.Now()}
+}
+
+// streamer is an struct that keeps the state of a load shared by every LoaderVehicleStream.
+type streamer struct {
+	sink     SinkVehicle
+	progress func(stats LoadStats)
+	start    time.Time
+	stats    LoadStats
+	errs     RecordErrors
+}
+
+// send passes a record to the sink, at a record or line of the file, 0 when unknown.
+// A vehicle rejected as duplicated is reported as an error of its record.
+func (s *streamer) send(v *VehicleJSON, record int, line int) (err error) {
+	s.read()
+	err = s.sink(v.ID, v.Attributes())
+	switch {
+	case err == nil:
+		s.stats.Loaded++
+	case errors.Is(err, ErrLoaderVehicleDuplicate):
+		s.stats.Duplicates++
+		s.errs = append(s.errs, &RecordError{Record: record, Line: line, Field: "id",
+			Err: fmt.Errorf("id %d is already used by a previous vehicle", v.ID)})
+		err = nil
+	case errors.Is(err, ErrLoaderVehicleInvalid):
+		s.stats.Skipped++
+		err = nil
+	}
+	return
+}
+
+// skip records the errors of a record that can't be read.
+func (s *streamer) skip(errs ...*RecordError) {
+	s.read()
+	s.stats.Skipped++
+	s.errs = append(s.errs, errs...)
+}
+
+// read counts a record and reports the progress.
+func (s *streamer) read() {
+	s.stats.Read++
+	if s.progress != nil && s.stats.Read%progressEvery == 0 {
+		s.stats.Elapsed = time.Since(s.start)
+		s.progress(s.stats)
+	}
+}
+
+// done returns the stats of the load and the errors of the records that can't be read.
+func (s *streamer) done() (stats LoadStats, err error) {
+	s.stats.Elapsed = time.Since(s.start)
+	stats = s.stats
+	if len(s.errs) > 0 {
+		err = s.errs
+	}
+	return
+}
+
+// loadFromStream reads the whole dataset of a LoaderVehicleStream into a map.
+// The first vehicle of every id is kept, the next ones are reported as errors of their records.
+func loadFromStream(l LoaderVehicleStream) (v map[int]*domain.VehicleAttributes, err error) {
+	v = make(map[int]*domain.VehicleAttributes)
+	_, err = l.Stream(func(id int, a *domain.VehicleAttributes) error {
+		if _, ok := v[id]; ok {
+			return ErrLoaderVehicleDuplicate
+		}
+		v[id] = a
+		return nil
+	}, nil)
+	if err != nil && !errors.Is(err, ErrLoaderVehicleRecord) {
+		v = nil
+	}
+	return
+}
+
+// LoadInto sends every vehicle of the dataset to sink, streaming it when the loader supports it.
+// Records that can't be read are reported in a RecordErrors along the stats.
+func LoadInto(ld LoaderVehicle, sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error) {
+	if ldSt, ok := ld.(LoaderVehicleStream); ok {
+		return ldSt.Stream(sink, progress)
+	}
+
+	st := newStreamer(sink, progress)
+	st.stats.Buffered = true
+	v, err := ld.Load()
+	var errs RecordErrors
+	if err != nil && !errors.As(err, &errs) {
+		return
+	}
+	for i, e := range errs {
+		// the errors of the same record are consecutive and count as a single skipped record
+		if i > 0 && e.Line == errs[i-1].Line && e.Record == errs[i-1].Record {
+			st.errs = append(st.errs, e)
+			continue
+		}
+		st.skip(e)
+	}
+
+	ids := make([]int, 0, len(v))
+	for id := range v {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		// the position of the vehicles in the file is unknown
+		if err = st.send(NewVehicleJSON(id, v[id]), 0, 0); err != nil {
+			return
+		}
+	}
+
+	return st.done()
+}
@@ -0,0 +1,242 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// writeFileTest writes the content to a new file with the given name and returns its path.
+func writeFileTest(t *testing.T, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// positionsTest returns the record, line and field of every record error of err.
+func positionsTest(err error) []string {
+	var errs RecordErrors
+	if !errors.As(err, &errs) {
+		return nil
+	}
+	positions := make([]string, 0, len(errs))
+	for _, e := range errs {
+		positions = append(positions, fmt.Sprintf("%d:%d:%s", e.Record, e.Line, e.Field))
+	}
+	return positions
+}
+
+func TestLoaderVehicle_Stream(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		loader  func(path string) LoaderVehicleStream
+		// ids are the vehicles loaded, errs the positions of the record errors as record:line:field.
+		ids      []int
+		errs     []string
+		err      error
+		msg      string
+		stats    LoadStats
+		buffered bool
+	}{
+		{
+			name: "csv", file: "vehicles.csv",
+			content: "id,brand,year\n1,Ford,2001\n2,Fiat,2002\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleCSV(path) },
+			ids:     []int{1, 2},
+			stats:   LoadStats{Read: 2, Loaded: 2},
+		},
+		{
+			name: "csv with wrong rows", file: "vehicles.csv",
+			content: "id,brand,year\n1,Ford,old\n2,Fiat\n3,Seat,2003\n1,Ford,2001\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleCSV(path) },
+			ids:     []int{3, 1},
+			errs:    []string{"0:2:year", "0:3:"},
+			err:     ErrLoaderVehicleRecord,
+			stats:   LoadStats{Read: 4, Loaded: 2, Skipped: 2},
+		},
+		{
+			name: "csv with an unknown column", file: "vehicles.csv",
+			content: "id,brand,wheels\n1,Ford,4\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleCSV(path) },
+			err:     ErrLoaderVehicleInternal,
+		},
+		{
+			name: "json", file: "vehicles.json",
+			content: `[{"id":1,"brand":"Ford","year":2001},{"id":2,"brand":"Fiat","year":2002}]`,
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleJSON(path) },
+			ids:     []int{1, 2},
+			stats:   LoadStats{Read: 2, Loaded: 2},
+		},
+		{
+			name: "json with wrong records", file: "vehicles.json",
+			content: `[{"id":1,"year":"old"},{"id":2,"year":2002},{"id":2,"year":2003}]`,
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleJSON(path) },
+			ids:     []int{2},
+			errs:    []string{"1:0:year", "3:0:id"},
+			err:     ErrLoaderVehicleRecord,
+			stats:   LoadStats{Read: 3, Loaded: 1, Skipped: 1, Duplicates: 1},
+		},
+		{
+			name: "json object", file: "vehicles.json",
+			content: `{"id":1,"brand":"Ford"}`,
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleJSON(path) },
+			err:     ErrLoaderVehicleInternal,
+			msg:     "the document must be an array",
+		},
+		{
+			name: "json with a broken array", file: "vehicles.json",
+			content: `[{"id":1},{"id":`,
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleJSON(path) },
+			err:     ErrLoaderVehicleInternal,
+		},
+		{
+			name: "ndjson", file: "vehicles.ndjson",
+			content: "{\"id\":1,\"brand\":\"Ford\"}\n\n{\"id\":2,\"brand\":\"Fiat\"}\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleNDJSON(path) },
+			ids:     []int{1, 2},
+			stats:   LoadStats{Read: 2, Loaded: 2},
+		},
+		{
+			name: "ndjson with wrong lines", file: "vehicles.ndjson",
+			content: "{\"id\":1,\"year\":\"old\"}\nnot json\n{\"id\":3}\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleNDJSON(path) },
+			ids:     []int{3},
+			errs:    []string{"0:1:year", "0:2:"},
+			err:     ErrLoaderVehicleRecord,
+			stats:   LoadStats{Read: 3, Loaded: 1, Skipped: 2},
+		},
+		{
+			name: "yaml", file: "vehicles.yaml",
+			content:  "- id: 1\n  brand: Ford\n- id: 2\n  brand: Fiat\n",
+			loader:   func(path string) LoaderVehicleStream { return NewLoaderVehicleYAML(path) },
+			ids:      []int{1, 2},
+			stats:    LoadStats{Read: 2, Loaded: 2},
+			buffered: true,
+		},
+		{
+			name: "yaml with wrong items", file: "vehicles.yaml",
+			content:  "- id: 1\n  year: old\n- just text\n- id: 3\n  colors: [red]\n- id: 4\n",
+			loader:   func(path string) LoaderVehicleStream { return NewLoaderVehicleYAML(path) },
+			ids:      []int{4},
+			errs:     []string{"0:2:year", "0:3:", "0:5:colors"},
+			err:      ErrLoaderVehicleRecord,
+			stats:    LoadStats{Read: 4, Loaded: 1, Skipped: 3},
+			buffered: true,
+		},
+		{
+			name: "yaml mapping", file: "vehicles.yaml",
+			content: "id: 1\nbrand: Ford\n",
+			loader:  func(path string) LoaderVehicleStream { return NewLoaderVehicleYAML(path) },
+			err:     ErrLoaderVehicleInternal,
+			msg:     "the document must be a sequence",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ld := tt.loader(writeFileTest(t, tt.file, tt.content))
+			var ids []int
+			sink := func(id int, v *domain.VehicleAttributes) error {
+				for _, loaded := range ids {
+					if loaded == id {
+						return ErrLoaderVehicleDuplicate
+					}
+				}
+				ids = append(ids, id)
+				return nil
+			}
+
+			stats, err := ld.Stream(sink, nil)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Stream returned %v, want %v", err, tt.err)
+			}
+			if errors.Is(err, ErrLoaderVehicleInternal) {
+				if !strings.Contains(err.Error(), tt.msg) {
+					t.Errorf("Stream returned %q, want it to contain %q", err, tt.msg)
+				}
+				return
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("Stream loaded %v, want %v", ids, tt.ids)
+			}
+			if got := positionsTest(err); !reflect.DeepEqual(got, tt.errs) {
+				t.Errorf("Stream reported errors at %v, want %v", got, tt.errs)
+			}
+			if stats.Buffered != tt.buffered {
+				t.Errorf("Stream returned buffered %t, want %t", stats.Buffered, tt.buffered)
+			}
+			stats.Elapsed, stats.Buffered = 0, false
+			if stats != tt.stats {
+				t.Errorf("Stream returned stats %+v, want %+v", stats, tt.stats)
+			}
+		})
+	}
+}
+
+func TestLoaderVehicle_Load(t *testing.T) {
+	path := writeFileTest(t, "vehicles.ndjson", "{\"id\":1,\"brand\":\"Ford\"}\n{\"id\":1,\"brand\":\"Fiat\"}\n{\"id\":2,\"year\":\"old\"}\n")
+
+	v, err := NewLoaderVehicleNDJSON(path).Load()
+	if !errors.Is(err, ErrLoaderVehicleRecord) {
+		t.Fatalf("Load returned %v, want %v", err, ErrLoaderVehicleRecord)
+	}
+	// the first vehicle of an id is kept
+	ids := make([]int, 0, len(v))
+	for id := range v {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	if !reflect.DeepEqual(ids, []int{1}) || v[1].Brand != "Ford" {
+		t.Errorf("Load returned %v", v)
+	}
+	if got := positionsTest(err); !reflect.DeepEqual(got, []string{"0:2:id", "0:3:year"}) {
+		t.Errorf("Load reported errors at %v", got)
+	}
+}
+
+func TestRecordError(t *testing.T) {
+	cause := errors.New("invalid value")
+	tests := []struct {
+		name string
+		err  *RecordError
+		want string
+		json string
+	}{
+		{name: "line and field", err: &RecordError{Line: 3, Field: "year", Err: cause}, want: "line 3, field year: invalid value",
+			json: `{"line":3,"field":"year","message":"invalid value"}`},
+		{name: "record", err: &RecordError{Record: 2, Err: cause}, want: "record 2: invalid value",
+			json: `{"record":2,"message":"invalid value"}`},
+		{name: "line over record", err: &RecordError{Record: 2, Line: 5, Err: cause}, want: "line 5: invalid value",
+			json: `{"record":2,"line":5,"message":"invalid value"}`},
+		{name: "only field", err: &RecordError{Field: "id", Err: cause}, want: "field id: invalid value",
+			json: `{"field":"id","message":"invalid value"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Error(); got != tt.want {
+				t.Errorf("Error returned %q, want %q", got, tt.want)
+			}
+			b, err := json.Marshal(tt.err)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.json {
+				t.Errorf("the json is %s, want %s", b, tt.json)
+			}
+			if !errors.Is(tt.err, ErrLoaderVehicleRecord) || !errors.Is(RecordErrors{tt.err}, ErrLoaderVehicleRecord) {
+				t.Errorf("the error doesn't match %v", ErrLoaderVehicleRecord)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// NewLoaderVehicleYAML returns a new instance of a vehicle loader.
+func NewLoaderVehicleYAML(path string) *LoaderVehicleYAML {
+	return &LoaderVehicleYAML{Path: path}
+}
+
+// LoaderVehicleYAML is an struct that implements the LoaderVehicle interface.
+// The file is a sequence of mappings with the json names of the fields.
+type LoaderVehicleYAML struct {
+	Path string
+}
+
+// Load returns all vehicles.
+// Items that can't be read are reported in a RecordErrors along the vehicles of the other items.
+func (l *LoaderVehicleYAML) Load() (v map[int]*domain.VehicleAttributes, err error) {
+	return loadFromStream(l)
+}
+
+// Stream sends every vehicle to sink, an item at a time. It doesn't stream the file: the document is parsed
+// as a whole first, as the items of a sequence can't be decoded one by one, so the stats are Buffered and
+// the memory of the load grows with the size of the file. Large datasets should use the csv, json or ndjson loaders.
+func (l *LoaderVehicleYAML) Stream(sink SinkVehicle, progress func(stats LoadStats)) (stats LoadStats, err error) {
+	// open file
+	f, err := os.Open(l.Path)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+	defer f.Close()
+
+	// read file
+	var doc yaml.Node
+	if err = yaml.NewDecoder(f).Decode(&doc); err != nil {
+		err = fmt.Errorf("%w. %v", ErrLoaderVehicleInternal, err)
+		return
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.SequenceNode {
+		err = fmt.Errorf("%w. the document must be a sequence of vehicles", ErrLoaderVehicleInternal)
+		return
+	}
+
+	// read items
+	st := newStreamer(sink, progress)
+	st.stats.Buffered = true
+	for _, item := range doc.Content[0].Content {
+		if item.Kind != yaml.MappingNode {
+			st.skip(&RecordError{Line: item.Line, Err: errors.New("the vehicle must be a mapping")})
+			continue
+		}
+
+		var vehicleJSON VehicleJSON
+		var errs RecordErrors
+		for i := 0; i+1 < len(item.Content); i += 2 {
+			key, value := item.Content[i], item.Content[i+1]
+			errField := errors.New("the value must be a scalar")
+			if value.Kind == yaml.ScalarNode {
+				errField = vehicleJSON.SetField(key.Value, value.Value)
+			}
+			if errField != nil {
+				errs = append(errs, &RecordError{Line: value.Line, Field: key.Value, Err: errField})
+			}
+		}
+		if len(errs) > 0 {
+			st.skip(errs...)
+			continue
+		}
+
+		if err = st.send(&vehicleJSON, 0, item.Line); err != nil {
+			return
+		}
+	}
+
+	return st.done()
+}
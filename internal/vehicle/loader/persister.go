@@ -0,0 +1,17 @@
+package loader
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+)
+
+var (
+	// ErrPersisterVehicleInternal is returned when an internal error occurs.
+	ErrPersisterVehicleInternal = errors.New("persister: internal error")
+)
+
+// PersisterVehicle is the interface that wraps the basic methods for a vehicle persister.
+// It is the counterpart of LoaderVehicle: what is saved can be loaded back.
+type PersisterVehicle interface {
+	Save(v map[int]*domain.VehicleAttributes) (err error)
+}
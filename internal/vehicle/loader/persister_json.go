@@ -0,0 +1,112 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// NewPersisterVehicleJSON returns a new instance of a vehicle persister.
+func NewPersisterVehicleJSON(path string) *PersisterVehicleJSON {
+	return &PersisterVehicleJSON{Path: path}
+}
+
+// PersisterVehicleJSON is an struct that implements the PersisterVehicle interface.
+// It writes the same array of VehicleJSON read by LoaderVehicleJSON.
+type PersisterVehicleJSON struct {
+	Path string
+}
+
+// modePersisterVehicleJSON is the mode of the file when it doesn't exist yet.
+const modePersisterVehicleJSON os.FileMode = 0644
+
+// Save writes all vehicles, ordered by id.
+// The file is replaced atomically: vehicles are written to a temporary file in the same directory
+// that is renamed over Path once it is fully synced, so readers never see a partial file, and the
+// directory is synced so the rename survives a crash. The file keeps its mode.
+func (p *PersisterVehicleJSON) Save(v map[int]*domain.VehicleAttributes) (err error) {
+	// create temporary file
+	f, err := os.CreateTemp(filepath.Dir(p.Path), filepath.Base(p.Path)+".*.tmp")
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	// write file
+	ids := make([]int, 0, len(v))
+	for id := range v {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	w := bufio.NewWriter(f)
+	w.WriteString("[")
+	for i, id := range ids {
+		if i > 0 {
+			w.WriteString(",\n")
+		}
+		var b []byte
+		b, err = json.Marshal(NewVehicleJSON(id, v[id]))
+		if err != nil {
+			err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+			return
+		}
+		w.Write(b)
+	}
+	w.WriteString("]")
+	if err = w.Flush(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+
+	// replace file, the temporary one is created only readable by its owner
+	mode := modePersisterVehicleJSON
+	if info, errStat := os.Stat(p.Path); errStat == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = f.Chmod(mode); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+	if err = f.Sync(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+	if err = f.Close(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+	if err = os.Rename(f.Name(), p.Path); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+	if err = syncDir(filepath.Dir(p.Path)); err != nil {
+		err = fmt.Errorf("%w. %v", ErrPersisterVehicleInternal, err)
+		return
+	}
+
+	return
+}
+
+// syncDir flushes the entries of the directory, such as a file renamed into it, to the disk.
+func syncDir(path string) error {
+	d, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err = d.Sync(); err != nil {
+		d.Close()
+		return err
+	}
+	return d.Close()
+}
@@ -0,0 +1,75 @@
+package loader
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPersisterVehicleJSON_Save(t *testing.T) {
+	vehicles := map[int]*domain.VehicleAttributes{
+		2: {Brand: "Fiat", Model: "Panda", Year: 1999, Color: "Red", Height: 150.5},
+		1: {Brand: "Ford", Model: "Ka", Year: 2001, Color: "Blue", Weight: 900},
+	}
+	tests := []struct {
+		name     string
+		vehicles map[int]*domain.VehicleAttributes
+		// existing is the content of the file before saving, none if nil, with its mode.
+		existing []byte
+		mode     os.FileMode
+		// dir is the directory of the file, relative to a temporary one.
+		dir string
+		err error
+	}{
+		{name: "new file", vehicles: vehicles, mode: modePersisterVehicleJSON},
+		{name: "existing file keeps its mode", vehicles: vehicles, existing: []byte("[]"), mode: 0600},
+		{name: "no vehicles", vehicles: map[int]*domain.VehicleAttributes{}, mode: modePersisterVehicleJSON},
+		{name: "missing directory", vehicles: vehicles, dir: "missing", err: ErrPersisterVehicleInternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, tt.dir, "vehicles.json")
+			if tt.existing != nil {
+				if err := os.WriteFile(path, tt.existing, tt.mode); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := NewPersisterVehicleJSON(path).Save(tt.vehicles)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Save returned %v, want %v", err, tt.err)
+			}
+
+			// no temporary file is left behind
+			entries, _ := os.ReadDir(dir)
+			for _, e := range entries {
+				if e.Name() != "vehicles.json" {
+					t.Errorf("Save left %s", e.Name())
+				}
+			}
+			if err != nil {
+				return
+			}
+
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm() != tt.mode {
+				t.Errorf("the file has mode %v, want %v", info.Mode().Perm(), tt.mode)
+			}
+			// what is saved is loaded back
+			loaded, err := NewLoaderVehicleJSON(path).Load()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(loaded, tt.vehicles) {
+				t.Errorf("Load returned %v, want %v", loaded, tt.vehicles)
+			}
+		})
+	}
+}
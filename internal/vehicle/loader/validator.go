@@ -0,0 +1,143 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sync"
+)
+
+var (
+	// ErrLoaderVehicleInvalid is returned by a SinkVehicle to skip a vehicle that breaks the domain rules.
+	ErrLoaderVehicleInvalid = errors.New("loader: invalid vehicle")
+
+	// ErrLoaderVehicleRejected is returned when the dataset is rejected by its validation.
+	ErrLoaderVehicleRejected = errors.New("loader: dataset rejected")
+
+	// ErrLoaderVehiclePolicy is returned for an unknown validation policy.
+	ErrLoaderVehiclePolicy = errors.New("loader: unknown validation policy")
+)
+
+// ValidationPolicy is what a ValidatorVehicle does with the vehicles that break the domain rules.
+type ValidationPolicy string
+
+const (
+	// ValidationPolicyReject rejects the whole dataset.
+	ValidationPolicyReject ValidationPolicy = "reject"
+	// ValidationPolicySkip loads the dataset without the invalid vehicles.
+	ValidationPolicySkip ValidationPolicy = "skip"
+	// ValidationPolicyWarn loads every vehicle, only reporting the issues.
+	ValidationPolicyWarn ValidationPolicy = "warn"
+)
+
+// ParseValidationPolicy returns the policy with the given name, ValidationPolicyWarn when it's empty.
+func ParseValidationPolicy(name string) (ValidationPolicy, error) {
+	switch p := ValidationPolicy(name); p {
+	case "":
+		return ValidationPolicyWarn, nil
+	case ValidationPolicyReject, ValidationPolicySkip, ValidationPolicyWarn:
+		return p, nil
+	default:
+		return "", fmt.Errorf("%w. %q", ErrLoaderVehiclePolicy, name)
+	}
+}
+
+// ValidationIssue is an struct that describes a vehicle of the dataset that breaks a rule.
+type ValidationIssue struct {
+	// Id is the id of the vehicle.
+	Id int `json:"id"`
+	domain.ValidationError
+}
+
+// ValidationReport is an struct that represents the result of the validation of a dataset.
+type ValidationReport struct {
+	// Policy is the policy applied to the invalid vehicles.
+	Policy ValidationPolicy `json:"policy"`
+	// Valid is the number of vehicles without issues.
+	Valid int `json:"valid"`
+	// Invalid is the number of vehicles with issues.
+	Invalid int `json:"invalid"`
+	// Rejected is whether the dataset was rejected.
+	Rejected bool `json:"rejected"`
+	// Issues are the issues of every invalid vehicle.
+	Issues []ValidationIssue `json:"issues"`
+}
+
+// NewValidatorVehicle returns a new instance of a vehicle validator.
+func NewValidatorVehicle(policy ValidationPolicy) *ValidatorVehicle {
+	return &ValidatorVehicle{
+		seen:   make(map[int]bool),
+		report: ValidationReport{Policy: policy, Issues: make([]ValidationIssue, 0)},
+	}
+}
+
+// ValidatorVehicle is an struct that checks every vehicle of a dataset against the domain rules
+// before it reaches the repository, and checks that ids are unique.
+// A validator is meant for a single load.
+type ValidatorVehicle struct {
+	// mu guards seen and report.
+	mu sync.Mutex
+	// seen are the ids already validated.
+	seen map[int]bool
+	// report is the result of the validation.
+	report ValidationReport
+}
+
+// Sink returns a sink that validates each vehicle before sending it to next, according to the policy.
+// With ValidationPolicyReject no vehicle is sent once an issue is found, the remaining ones are only validated.
+func (v *ValidatorVehicle) Sink(next SinkVehicle) SinkVehicle {
+	return func(id int, a *domain.VehicleAttributes) error {
+		v.mu.Lock()
+		issues := v.validate(id, a)
+		duplicated := v.seen[id]
+		v.seen[id] = true
+		if len(issues) == 0 {
+			v.report.Valid++
+		} else {
+			v.report.Invalid++
+			v.report.Issues = append(v.report.Issues, issues...)
+		}
+		policy, rejected := v.report.Policy, v.report.Invalid > 0
+		v.mu.Unlock()
+
+		switch {
+		case policy == ValidationPolicyReject && rejected:
+			return ErrLoaderVehicleInvalid
+		case policy == ValidationPolicySkip && duplicated:
+			return ErrLoaderVehicleDuplicate
+		case policy == ValidationPolicySkip && len(issues) > 0:
+			return ErrLoaderVehicleInvalid
+		}
+		return next(id, a)
+	}
+}
+
+// Report returns the result of the validation, and ErrLoaderVehicleRejected when the dataset must be rejected.
+func (v *ValidatorVehicle) Report() (r ValidationReport, err error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	r = v.report
+	r.Issues = append(make([]ValidationIssue, 0, len(v.report.Issues)), v.report.Issues...)
+	if r.Policy == ValidationPolicyReject && r.Invalid > 0 {
+		r.Rejected = true
+		err = fmt.Errorf("%w. %d invalid vehicles", ErrLoaderVehicleRejected, r.Invalid)
+	}
+	return
+}
+
+// validate returns the issues of a vehicle.
+func (v *ValidatorVehicle) validate(id int, a *domain.VehicleAttributes) (issues []ValidationIssue) {
+	if id <= 0 {
+		issues = append(issues, ValidationIssue{Id: id, ValidationError: domain.ValidationError{
+			Field: "id", Rule: "positive", Message: fmt.Sprintf("must be greater than 0, got %d", id)}})
+	}
+	if v.seen[id] {
+		issues = append(issues, ValidationIssue{Id: id, ValidationError: domain.ValidationError{
+			Field: "id", Rule: "unique", Message: "is already used by a previous vehicle"}})
+	}
+	for _, e := range a.Validate() {
+		issues = append(issues, ValidationIssue{Id: id, ValidationError: e})
+	}
+	return
+}
@@ -0,0 +1,119 @@
+package loader
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+// validTest returns the attributes of a vehicle that follows the domain rules.
+func validTest() *domain.VehicleAttributes {
+	return &domain.VehicleAttributes{Brand: "Ford", Model: "Ka", Registration: "AB123", Year: 2001, Color: "Red", MaxSpeed: 150,
+		FuelType: "gasoline", Transmission: "manual", Passengers: 4, Height: 150, Width: 170, Weight: 900}
+}
+
+func TestParseValidationPolicy(t *testing.T) {
+	tests := []struct {
+		name string
+		want ValidationPolicy
+		err  error
+	}{
+		{name: "", want: ValidationPolicyWarn},
+		{name: "warn", want: ValidationPolicyWarn},
+		{name: "skip", want: ValidationPolicySkip},
+		{name: "reject", want: ValidationPolicyReject},
+		{name: "Reject", err: ErrLoaderVehiclePolicy},
+		{name: "ignore", err: ErrLoaderVehiclePolicy},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := ParseValidationPolicy(tt.name)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseValidationPolicy returned %v, want %v", err, tt.err)
+			}
+			if policy != tt.want {
+				t.Errorf("ParseValidationPolicy returned %q, want %q", policy, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorVehicle_Sink(t *testing.T) {
+	invalid := validTest()
+	invalid.Year = 1800
+	invalid.FuelType = "steam"
+
+	// the dataset has a valid vehicle, an invalid one, another valid one, a repeated id and a wrong id
+	dataset := []struct {
+		id int
+		a  *domain.VehicleAttributes
+	}{{1, validTest()}, {2, invalid}, {3, validTest()}, {1, validTest()}, {0, validTest()}}
+
+	tests := []struct {
+		policy ValidationPolicy
+		// sent are the ids that reach the next sink, errs the errors of the sink by vehicle.
+		sent []int
+		errs []error
+		// issues are the fields of the issues found.
+		issues   []string
+		valid    int
+		rejected bool
+	}{
+		{
+			policy: ValidationPolicyWarn,
+			sent:   []int{1, 2, 3, 1, 0},
+			errs:   []error{nil, nil, nil, nil, nil},
+			issues: []string{"year", "fuel_type", "id", "id"},
+			valid:  2,
+		},
+		{
+			policy: ValidationPolicySkip,
+			sent:   []int{1, 3},
+			errs:   []error{nil, ErrLoaderVehicleInvalid, nil, ErrLoaderVehicleDuplicate, ErrLoaderVehicleInvalid},
+			issues: []string{"year", "fuel_type", "id", "id"},
+			valid:  2,
+		},
+		{
+			policy:   ValidationPolicyReject,
+			sent:     []int{1},
+			errs:     []error{nil, ErrLoaderVehicleInvalid, ErrLoaderVehicleInvalid, ErrLoaderVehicleInvalid, ErrLoaderVehicleInvalid},
+			issues:   []string{"year", "fuel_type", "id", "id"},
+			valid:    2,
+			rejected: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			vl := NewValidatorVehicle(tt.policy)
+			sent := make([]int, 0)
+			sink := vl.Sink(func(id int, a *domain.VehicleAttributes) error {
+				sent = append(sent, id)
+				return nil
+			})
+			for i, v := range dataset {
+				if err := sink(v.id, v.a); !errors.Is(err, tt.errs[i]) || (tt.errs[i] == nil && err != nil) {
+					t.Errorf("the sink returned %v for vehicle %d, want %v", err, i, tt.errs[i])
+				}
+			}
+			if !reflect.DeepEqual(sent, tt.sent) {
+				t.Errorf("the sink sent %v, want %v", sent, tt.sent)
+			}
+
+			report, err := vl.Report()
+			if tt.rejected != errors.Is(err, ErrLoaderVehicleRejected) || tt.rejected != report.Rejected {
+				t.Errorf("Report returned %v and rejected %t, want rejected %t", err, report.Rejected, tt.rejected)
+			}
+			fields := make([]string, 0, len(report.Issues))
+			for _, issue := range report.Issues {
+				fields = append(fields, issue.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.issues) {
+				t.Errorf("Report has issues of %v, want %v", fields, tt.issues)
+			}
+			if report.Valid != tt.valid || report.Invalid != len(dataset)-tt.valid || report.Policy != tt.policy {
+				t.Errorf("Report returned %+v", report)
+			}
+		})
+	}
+}
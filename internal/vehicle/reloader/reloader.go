@@ -0,0 +1,167 @@
+package reloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/loader"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrReloaderVehicleInternal is returned when an internal error occurs.
+	ErrReloaderVehicleInternal = errors.New("reloader: internal error")
+
+	// ErrReloaderVehicleInvalid is returned when the dataset can't be reloaded because of its content.
+	ErrReloaderVehicleInvalid = errors.New("reloader: invalid dataset")
+
+	// ErrReloaderVehicleUnpersisted is returned when the repository has writes that aren't persisted in the dataset.
+	ErrReloaderVehicleUnpersisted = errors.New("reloader: writes not persisted")
+)
+
+// NewReloaderVehicle returns a new instance of a vehicle reloader.
+func NewReloaderVehicle(path string, ld loader.LoaderVehicle, policy loader.ValidationPolicy, rp repository.RepositoryVehicleReplacer) *ReloaderVehicle {
+	return &ReloaderVehicle{path: path, ld: ld, policy: policy, rp: rp}
+}
+
+// ReloaderVehicle is an struct that reloads the dataset of vehicles into a repository.
+type ReloaderVehicle struct {
+	// mu serializes the reloads and the writes of the dataset.
+	mu sync.Mutex
+	// path is the path of the dataset.
+	path string
+	// ld is the loader of the dataset.
+	ld loader.LoaderVehicle
+	// policy is the policy of the validation of the dataset.
+	policy loader.ValidationPolicy
+	// rp is the repository the dataset is swapped into.
+	rp repository.RepositoryVehicleReplacer
+	// seen is the state of the file at the last reload or write.
+	seen fileState
+}
+
+// fileState is an struct that represents the state used to detect changes of a file.
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// Result is an struct that represents the result of a reload.
+type Result struct {
+	// Diff are the ids that changed.
+	Diff repository.VehiclesDiff `json:"diff"`
+	// Stats are the statistics of the load.
+	Stats loader.LoadStats `json:"stats"`
+	// Validation is the report of the validation of the dataset.
+	Validation loader.ValidationReport `json:"validation"`
+	// RecordErrors are the errors of the records that can't be read.
+	RecordErrors loader.RecordErrors `json:"record_errors,omitempty"`
+}
+
+// Reload loads and validates the dataset and, if it's accepted, swaps it into the repository.
+// The repository is untouched when it fails; the result has the validation report when it's rejected.
+// A reload is refused with ErrReloaderVehicleUnpersisted while the repository has acknowledged writes that
+// aren't persisted yet, as the dataset doesn't have them. The edit isn't retried, and the next persist
+// writes the repository over it.
+func (r *ReloaderVehicle) Reload() (res Result, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.reload()
+}
+
+// Watch checks the dataset every interval and reloads it when it changes, until ctx is done.
+// Each reload is reported through report.
+func (r *ReloaderVehicle) Watch(ctx context.Context, interval time.Duration, report func(res Result, err error)) {
+	r.mu.Lock()
+	r.seen, _ = r.stat()
+	r.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		r.mu.Lock()
+		state, err := r.stat()
+		if err != nil || state == r.seen {
+			r.mu.Unlock()
+			continue
+		}
+		res, err := r.reload()
+		r.mu.Unlock()
+		report(res, err)
+	}
+}
+
+// Write runs write, which is expected to save the dataset, so its changes are not taken as an edit to reload.
+func (r *ReloaderVehicle) Write(write func() error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := write()
+	r.seen, _ = r.stat()
+	return err
+}
+
+// reload loads the dataset into the repository.
+func (r *ReloaderVehicle) reload() (res Result, err error) {
+	// the state is taken before loading, so an edit made while loading is detected by the next check
+	r.seen, _ = r.stat()
+	res.Diff = repository.VehiclesDiff{Added: make([]int, 0), Removed: make([]int, 0), Modified: make([]int, 0)}
+
+	// load
+	db := make(map[int]*domain.VehicleAttributes)
+	vl := loader.NewValidatorVehicle(r.policy)
+	res.Stats, err = loader.LoadInto(r.ld, vl.Sink(func(id int, a *domain.VehicleAttributes) error {
+		if _, ok := db[id]; ok {
+			return loader.ErrLoaderVehicleDuplicate
+		}
+		db[id] = a
+		return nil
+	}), nil)
+	if err != nil {
+		if !errors.As(err, &res.RecordErrors) {
+			err = fmt.Errorf("%w. %v", ErrReloaderVehicleInvalid, err)
+			return
+		}
+		err = nil
+	}
+
+	// validate
+	res.Validation, err = vl.Report()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrReloaderVehicleInvalid, err)
+		return
+	}
+
+	// swap
+	res.Diff, err = r.rp.Replace(db)
+	switch {
+	case errors.Is(err, repository.ErrRepositoryVehicleUnpersisted):
+		err = fmt.Errorf("%w. %v", ErrReloaderVehicleUnpersisted, err)
+		return
+	case err != nil:
+		err = fmt.Errorf("%w. %v", ErrReloaderVehicleInternal, err)
+		return
+	}
+	return
+}
+
+// stat returns the state of the dataset file.
+func (r *ReloaderVehicle) stat() (state fileState, err error) {
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return
+	}
+	state = fileState{modTime: info.ModTime(), size: info.Size()}
+	return
+}
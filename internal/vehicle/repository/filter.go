@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"strings"
+)
+
+// matchFilter reports whether the vehicle matches the filter.
+func matchFilter(f domain.Filter, v *domain.Vehicle) bool {
+	switch f := f.(type) {
+	case domain.FilterAnd:
+		for _, sub := range f.Filters {
+			if !matchFilter(sub, v) {
+				return false
+			}
+		}
+		return true
+	case domain.FilterOr:
+		for _, sub := range f.Filters {
+			if matchFilter(sub, v) {
+				return true
+			}
+		}
+		return false
+	case domain.FilterNot:
+		return !matchFilter(f.Filter, v)
+	case domain.FilterCondition:
+		return matchCondition(f, v)
+	default:
+		return false
+	}
+}
+
+// matchCondition reports whether the vehicle matches the condition.
+func matchCondition(c domain.FilterCondition, v *domain.Vehicle) bool {
+	if c.Operator == domain.FilterOperatorIn {
+		for _, value := range c.Values {
+			if compareFilterValue(c.Field, v, value) == 0 {
+				return true
+			}
+		}
+		return false
+	}
+	if len(c.Values) != 1 {
+		return false
+	}
+
+	if c.Operator == domain.FilterOperatorIEq {
+		return strings.EqualFold(v.Text(c.Field), c.Values[0].Text)
+	}
+	cmp := compareFilterValue(c.Field, v, c.Values[0])
+	switch c.Operator {
+	case domain.FilterOperatorEq:
+		return cmp == 0
+	case domain.FilterOperatorNe:
+		return cmp != 0
+	case domain.FilterOperatorLt:
+		return cmp < 0
+	case domain.FilterOperatorLe:
+		return cmp <= 0
+	case domain.FilterOperatorGt:
+		return cmp > 0
+	case domain.FilterOperatorGe:
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// compareFilterValue compares the field of the vehicle with the value, returning -1, 0 or +1.
+func compareFilterValue(field domain.VehicleField, v *domain.Vehicle, value domain.FilterValue) int {
+	if field.IsNumeric() {
+		n := v.Number(field)
+		switch {
+		case n < value.Number:
+			return -1
+		case n > value.Number:
+			return 1
+		default:
+			return 0
+		}
+	}
+	return strings.Compare(v.Text(field), value.Text)
+}
+
+// sqlFilter returns the sql condition of the filter and its arguments.
+// Field names are the column names of the vehicles table.
+func sqlFilter(f domain.Filter) (where string, args []any, err error) {
+	switch f := f.(type) {
+	case domain.FilterAnd:
+		return sqlFilterJoin(f.Filters, " AND ")
+	case domain.FilterOr:
+		return sqlFilterJoin(f.Filters, " OR ")
+	case domain.FilterNot:
+		if where, args, err = sqlFilter(f.Filter); err != nil {
+			return
+		}
+		where = "NOT " + where
+	case domain.FilterCondition:
+		if _, ok := domain.ParseVehicleField(string(f.Field)); !ok || len(f.Values) == 0 {
+			err = fmt.Errorf("%w. invalid condition on %q", ErrRepositoryVehicleInternal, f.Field)
+			return
+		}
+		for _, value := range f.Values {
+			if f.Field.IsNumeric() {
+				args = append(args, value.Number)
+			} else {
+				args = append(args, value.Text)
+			}
+		}
+		column := string(f.Field)
+		switch f.Operator {
+		case domain.FilterOperatorIn:
+			where = fmt.Sprintf("(%s IN (%s))", column, strings.TrimSuffix(strings.Repeat("?, ", len(f.Values)), ", "))
+		case domain.FilterOperatorIEq:
+			where = fmt.Sprintf("(%s = ? COLLATE NOCASE)", column)
+		case domain.FilterOperatorEq, domain.FilterOperatorNe, domain.FilterOperatorLt,
+			domain.FilterOperatorLe, domain.FilterOperatorGt, domain.FilterOperatorGe:
+			where = fmt.Sprintf("(%s %s ?)", column, f.Operator)
+		default:
+			err = fmt.Errorf("%w. unknown operator %q", ErrRepositoryVehicleInternal, f.Operator)
+		}
+	default:
+		err = fmt.Errorf("%w. unknown filter %T", ErrRepositoryVehicleInternal, f)
+	}
+	return
+}
+
+// sqlFilterJoin returns the sql conditions of the filters joined by sep.
+func sqlFilterJoin(filters []domain.Filter, sep string) (where string, args []any, err error) {
+	parts := make([]string, 0, len(filters))
+	for _, f := range filters {
+		var part string
+		var partArgs []any
+		if part, partArgs, err = sqlFilter(f); err != nil {
+			return
+		}
+		parts = append(parts, part)
+		args = append(args, partArgs...)
+	}
+	where = "(" + strings.Join(parts, sep) + ")"
+	return
+}
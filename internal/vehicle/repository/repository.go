@@ -9,15 +9,44 @@ import (
 type RepositoryVehicle interface {
 	// GetAll returns all vehicles
 	GetAll() (v []*domain.Vehicle, err error)
-	GetByDimensions(float64, float64, float64, float64) ([]*domain.Vehicle, error)
+	// GetByFilter returns the vehicles that match the filter
+	GetByFilter(f domain.Filter) (v []*domain.Vehicle, err error)
+	// GetById returns the vehicle with the id
+	GetById(id int) (*domain.Vehicle, error)
+	GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error)
 	GetByColorAndYear(string, int) ([]*domain.Vehicle, error)
-	GetByWeight(float64, float64) ([]*domain.Vehicle, error)
+	GetByWeight(weight domain.Range) ([]*domain.Vehicle, error)
 	GetByBrand(brand string) ([]*domain.Vehicle, error)
-	PatchFuel(id int, fuelType string) error
+	// Search returns the vehicles that match a full-text query, sorted by relevance, see search.Index
+	Search(query string) ([]domain.SearchResult, error)
+	// Suggest returns up to limit distinct values of the brand, color or model (of the brand) that start with
+	// the prefix ignoring case, the most common first. No suggestion isn't an error.
+	Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error)
+	// PatchFuel changes the fuel type of the vehicle when its version is the given one, any if it is 0
+	PatchFuel(id int, fuelType string, version int) error
+	// Put replaces the vehicle when its stored version is the one of vehicle, any if it is 0
 	Put(vehicle *domain.Vehicle) error
 	GetByTransmission(transmission string) ([]*domain.Vehicle, error)
-	Delete(id int) error
+	// Update changes the attributes of the vehicle with update when its version is the given one, any if it is 0,
+	// and returns the updated vehicle. When update fails, nothing changes and its error is returned.
+	Update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error)
+	// Delete removes the vehicle when its version is the given one, any if it is 0
+	Delete(id int, version int) error
 	Post(vehicle *domain.Vehicle) error
+	// Transaction runs fn with the reads and writes of a transaction, whose writes are applied when fn returns
+	// nil and discarded otherwise, returning the error of fn. No other write is applied while fn runs.
+	Transaction(fn func(tx RepositoryVehicleTx) error) error
+}
+
+// RepositoryVehicleTx is the interface of the reads and writes of a transaction over a vehicle repository,
+// which work as the ones of RepositoryVehicle.
+type RepositoryVehicleTx interface {
+	GetById(id int) (*domain.Vehicle, error)
+	GetByFilter(f domain.Filter) ([]*domain.Vehicle, error)
+	Post(vehicle *domain.Vehicle) error
+	Put(vehicle *domain.Vehicle) error
+	Update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error)
+	Delete(id int, version int) error
 }
 
 var (
@@ -27,4 +56,27 @@ var (
 	// ErrRepositoryVehicleNotFound is returned when a vehicle is not found.
 	ErrRepositoryVehicleNotFound = errors.New("repository: vehicle not found")
 	ErrRepositoryIdInUse         = errors.New("repository: identifier already in use")
+
+	// ErrRepositoryVehicleVersionConflict is returned when a vehicle doesn't have the version a write expects.
+	ErrRepositoryVehicleVersionConflict = errors.New("repository: vehicle version conflict")
+
+	// ErrRepositoryVehicleUnpersisted is returned when the content is replaced while it has writes that aren't persisted.
+	ErrRepositoryVehicleUnpersisted = errors.New("repository: writes not persisted")
 )
+
+// RepositoryVehicleReplacer is the interface implemented by repositories whose whole content can be swapped at once.
+type RepositoryVehicleReplacer interface {
+	// Replace swaps the content of the repository for db, returning what changed.
+	// It fails with ErrRepositoryVehicleUnpersisted instead of dropping writes that aren't persisted.
+	Replace(db map[int]*domain.VehicleAttributes) (diff VehiclesDiff, err error)
+}
+
+// VehiclesDiff is an struct that represents the ids that changed between two contents of a repository.
+type VehiclesDiff struct {
+	// Added are the ids that are only in the new content.
+	Added []int `json:"added"`
+	// Removed are the ids that are only in the old content.
+	Removed []int `json:"removed"`
+	// Modified are the ids whose attributes changed.
+	Modified []int `json:"modified"`
+}
@@ -1,22 +1,48 @@
 package repository
 
 import (
+	"fmt"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
-	"strings"
+	"sort"
+	"sync"
+	"time"
 )
 
+// NewRepositoryVehicleInMemory returns a new instance of a vehicle storage in memory.
 func NewRepositoryVehicleInMemory(db map[int]*domain.VehicleAttributes) *RepositoryVehicleInMemory {
-	return &RepositoryVehicleInMemory{db: db}
+	if db == nil {
+		db = make(map[int]*domain.VehicleAttributes)
+	}
+	return &RepositoryVehicleInMemory{db: db, versions: newVersionsVehicle(db), ix: newIndexesVehicle(db)}
 }
 
 // RepositoryVehicleInMemory is an struct that represents a vehicle storage in memory.
+// It is safe for concurrent use: reads share a read lock and always return copies of the
+// stored attributes, so callers see a consistent snapshot while writes are in progress.
+// The lookups by brand, transmission, color and year, and fuel type are served by secondary
+// indexes that every write keeps consistent with db.
+// Replace refuses to drop the writes applied after the last snapshot reported as saved.
 type RepositoryVehicleInMemory struct {
+	// mu guards db, versions, ix, written and saved.
+	mu sync.RWMutex
 	// db is the database of vehicles.
 	db map[int]*domain.VehicleAttributes
+	// versions are the versions of the vehicles of db.
+	versions map[int]versionVehicle
+	// ix are the secondary indexes of db.
+	ix *indexesVehicle
+	// written is the number of writes applied to db.
+	written uint64
+	// saved is the number of writes of the last snapshot that was saved. The content the storage is built
+	// with, or replaced by, is taken as saved.
+	saved uint64
 }
 
 // GetAll returns all vehicles
 func (s *RepositoryVehicleInMemory) GetAll() (v []*domain.Vehicle, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
 	// check if the database is empty
 	if len(s.db) == 0 {
 		err = ErrRepositoryVehicleNotFound
@@ -25,147 +51,412 @@ func (s *RepositoryVehicleInMemory) GetAll() (v []*domain.Vehicle, err error) {
 
 	// get all vehicles from the database
 	v = make([]*domain.Vehicle, 0, len(s.db))
-	for key, value := range s.db {
-		v = append(v, &domain.Vehicle{
-			Id:         key,
-			Attributes: *value,
-		})
+	for key := range s.db {
+		v = append(v, s.vehicle(key))
 	}
+	sortById(v)
 
 	return
 }
 
-func (s *RepositoryVehicleInMemory) GetByDimensions(minHeight float64, maxHeight float64,
-	minWidth float64, maxWidth float64) ([]*domain.Vehicle, error) {
+// GetByFilter returns the vehicles that match the filter
+func (s *RepositoryVehicleInMemory) GetByFilter(f domain.Filter) ([]*domain.Vehicle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getByFilter(f)
+}
+
+// getByFilter returns the vehicles that match the filter, with the lock held.
+func (s *RepositoryVehicleInMemory) getByFilter(f domain.Filter) ([]*domain.Vehicle, error) {
 	if len(s.db) == 0 {
 		err := ErrRepositoryVehicleNotFound
 		return nil, err
 	}
-	v := make([]*domain.Vehicle, 0)
-	for key, value := range s.db {
-		if minWidth < value.Width && value.Width < maxWidth && minHeight < value.Height && value.Height < maxHeight {
-			v = append(v, &domain.Vehicle{
-				Id:         key,
-				Attributes: *value,
-			})
+	vehicles := make([]*domain.Vehicle, 0)
+	match := func(k int) {
+		vehicle := s.vehicle(k)
+		if matchFilter(f, vehicle) {
+			vehicles = append(vehicles, vehicle)
 		}
 	}
-
-	if len(v) == 0 {
-		return nil, ErrRepositoryVehicleNotFound
+	// only the candidates of the indexes are checked when the filter can be narrowed by them
+	if ids, ok := s.ix.candidates(f); ok {
+		for k := range ids {
+			match(k)
+		}
+	} else {
+		for k := range s.db {
+			match(k)
+		}
 	}
-	return v, nil
+	if len(vehicles) != 0 {
+		sortById(vehicles)
+		return vehicles, nil
+	}
+	return nil, ErrRepositoryVehicleNotFound
+}
+
+// GetById returns the vehicle with the id
+func (s *RepositoryVehicleInMemory) GetById(id int) (*domain.Vehicle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
+	return s.getById(id)
 }
-func (s *RepositoryVehicleInMemory) GetByWeight(min float64, max float64) ([]*domain.Vehicle, error) {
-	if len(s.db) == 0 {
-		err := ErrRepositoryVehicleNotFound
-		return nil, err
-	}
-	v := make([]*domain.Vehicle, 0)
-	for key, value := range s.db {
-		if min < value.Weight && value.Weight < max {
-			v = append(v, &domain.Vehicle{
-				Id:         key,
-				Attributes: *value,
-			})
-		}
-	}
 
-	if len(v) == 0 {
+// getById returns the vehicle with the id, with the lock held.
+func (s *RepositoryVehicleInMemory) getById(id int) (*domain.Vehicle, error) {
+	if _, ok := s.db[id]; !ok {
 		return nil, ErrRepositoryVehicleNotFound
 	}
-	return v, nil
+	return s.vehicle(id), nil
+}
+
+func (s *RepositoryVehicleInMemory) GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookupIds(s.ix.dimensions.search(height, width))
+}
+
+func (s *RepositoryVehicleInMemory) GetByWeight(weight domain.Range) ([]*domain.Vehicle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookupIds(s.ix.weight.in(weight))
 }
 
 func (s *RepositoryVehicleInMemory) GetByColorAndYear(color string, year int) ([]*domain.Vehicle, error) {
-	if len(s.db) == 0 {
-		err := ErrRepositoryVehicleNotFound
-		return nil, err
-	}
-	vehicles := make([]*domain.Vehicle, 0)
-	for k, v := range s.db {
-		if v.Year == year && strings.ToLower(v.Color) == strings.ToLower(color) {
-			vehicles = append(vehicles, &domain.Vehicle{
-				Id:         k,
-				Attributes: *v,
-			})
-		}
-	}
-	if len(vehicles) != 0 {
-		return vehicles, nil
-	}
-	return nil, ErrRepositoryVehicleNotFound
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(s.ix.colorYear[colorYearKey(color, year)])
 }
 
 func (s *RepositoryVehicleInMemory) GetByBrand(brand string) ([]*domain.Vehicle, error) {
-	if len(s.db) == 0 {
-		err := ErrRepositoryVehicleNotFound
-		return nil, err
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(s.ix.brand[foldKey(brand)])
+}
+
+// Search returns the vehicles that match a full-text query, sorted by relevance
+func (s *RepositoryVehicleInMemory) Search(query string) ([]domain.SearchResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hits := s.ix.text.Search(query)
+	if len(hits) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
 	}
-	vehicles := make([]*domain.Vehicle, 0)
-	for k, v := range s.db {
-		if strings.ToLower(v.Brand) == strings.ToLower(brand) {
-			vehicles = append(vehicles, &domain.Vehicle{
-				Id:         k,
-				Attributes: *v,
-			})
-		}
+	results := make([]domain.SearchResult, 0, len(hits))
+	for _, h := range hits {
+		results = append(results, domain.SearchResult{Vehicle: s.vehicle(h.Id), Score: h.Score})
 	}
-	if len(vehicles) != 0 {
-		return vehicles, nil
+	return results, nil
+}
+
+// Suggest returns up to limit distinct values of the brand, color or model (of the brand) that start with the prefix
+func (s *RepositoryVehicleInMemory) Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch field {
+	case domain.VehicleFieldBrand:
+		return s.ix.brands.suggest(prefix, limit), nil
+	case domain.VehicleFieldColor:
+		return s.ix.colors.suggest(prefix, limit), nil
+	case domain.VehicleFieldModel:
+		return s.ix.models[foldKey(brand)].suggest(prefix, limit), nil
+	default:
+		return nil, fmt.Errorf("%w. no suggestions for %q", ErrRepositoryVehicleInternal, field)
 	}
-	return nil, ErrRepositoryVehicleNotFound
 }
 
-func (s *RepositoryVehicleInMemory) PatchFuel(id int, fuelType string) error {
-	val, ok := s.db[id]
+func (s *RepositoryVehicleInMemory) PatchFuel(id int, fuelType string, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.db[id]
 	if !ok {
 		return ErrRepositoryVehicleNotFound
 	}
-	val.FuelType = fuelType
+	if err := s.checkVersion(id, version); err != nil {
+		return err
+	}
+	// the stored attributes are replaced instead of changed, as the copies and the undo log of transactions share them
+	attributes := *prev
+	attributes.FuelType = fuelType
+	s.ix.fuelType.remove(foldKey(prev.FuelType), id)
+	s.db[id] = &attributes
+	s.ix.fuelType.add(foldKey(attributes.FuelType), id)
+	s.versions[id] = s.versions[id].next()
+	s.written++
 	return nil
 }
 func (s *RepositoryVehicleInMemory) Put(vehicle *domain.Vehicle) error {
-	_, ok := s.db[vehicle.Id]
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.put(vehicle)
+}
+
+// put replaces the vehicle, with the lock held.
+func (s *RepositoryVehicleInMemory) put(vehicle *domain.Vehicle) error {
+	prev, ok := s.db[vehicle.Id]
 	if !ok {
 		return ErrRepositoryVehicleNotFound
 	}
-	s.db[vehicle.Id] = &vehicle.Attributes
+	if err := s.checkVersion(vehicle.Id, vehicle.Version); err != nil {
+		return err
+	}
+	// store a copy so later changes made by the caller are not shared with the storage
+	attributes := vehicle.Attributes
+	s.ix.remove(vehicle.Id, prev)
+	s.db[vehicle.Id] = &attributes
+	s.ix.add(vehicle.Id, &attributes)
+	s.versions[vehicle.Id] = s.versions[vehicle.Id].next()
+	s.written++
 	return nil
 }
 
-func (s *RepositoryVehicleInMemory) GetByTransmission(transmission string) ([]*domain.Vehicle, error) {
-	if len(s.db) == 0 {
-		err := ErrRepositoryVehicleNotFound
-		return nil, err
+// Update changes the attributes of the vehicle with update when its version is the given one, any if it is 0
+func (s *RepositoryVehicleInMemory) Update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.update(id, version, update)
+}
+
+// update changes the attributes of the vehicle with update, with the lock held.
+func (s *RepositoryVehicleInMemory) update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error) {
+	prev, ok := s.db[id]
+	if !ok {
+		return nil, ErrRepositoryVehicleNotFound
 	}
-	vehicles := make([]*domain.Vehicle, 0)
-	for k, v := range s.db {
-		if strings.ToLower(transmission) == strings.ToLower(v.Transmission) {
-			vehicles = append(vehicles, &domain.Vehicle{
-				Id:         k,
-				Attributes: *v,
-			})
-		}
+	if err := s.checkVersion(id, version); err != nil {
+		return nil, err
 	}
-	if len(vehicles) != 0 {
-		return vehicles, nil
+	// the update works on a copy so a failure leaves the stored vehicle as it was
+	attributes := *prev
+	if err := update(&attributes); err != nil {
+		return nil, err
 	}
-	return nil, ErrRepositoryVehicleNotFound
+	s.ix.remove(id, prev)
+	s.db[id] = &attributes
+	s.ix.add(id, &attributes)
+	s.versions[id] = s.versions[id].next()
+	s.written++
+	return s.vehicle(id), nil
+}
+
+func (s *RepositoryVehicleInMemory) GetByTransmission(transmission string) ([]*domain.Vehicle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.lookup(s.ix.transmission[foldKey(transmission)])
 }
-func (s *RepositoryVehicleInMemory) Delete(id int) error {
-	if _, found := s.db[id]; !found {
+func (s *RepositoryVehicleInMemory) Delete(id int, version int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(id, version)
+}
+
+// delete removes the vehicle, with the lock held.
+func (s *RepositoryVehicleInMemory) delete(id int, version int) error {
+	prev, found := s.db[id]
+	if !found {
 		return ErrRepositoryVehicleNotFound
 	}
+	if err := s.checkVersion(id, version); err != nil {
+		return err
+	}
+	s.ix.remove(id, prev)
 	delete(s.db, id)
+	delete(s.versions, id)
+	s.written++
 	return nil
 }
 
 func (s *RepositoryVehicleInMemory) Post(vehicle *domain.Vehicle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.post(vehicle)
+}
+
+// post inserts the vehicle, with the lock held.
+func (s *RepositoryVehicleInMemory) post(vehicle *domain.Vehicle) error {
 	if _, found := s.db[vehicle.Id]; found {
 		return ErrRepositoryIdInUse
 	}
-	s.db[vehicle.Id] = &vehicle.Attributes
+	attributes := vehicle.Attributes
+	s.db[vehicle.Id] = &attributes
+	s.ix.add(vehicle.Id, &attributes)
+	s.versions[vehicle.Id] = versionVehicle{}.next()
+	s.written++
 	return nil
 }
+
+// Transaction runs fn with the write lock held, undoing the writes of fn when it fails or panics.
+func (s *RepositoryVehicleInMemory) Transaction(fn func(tx RepositoryVehicleTx) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx := &txVehicleInMemory{s: s, written: s.written}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.rollback()
+			panic(p)
+		}
+	}()
+	if err = fn(tx); err != nil {
+		tx.rollback()
+	}
+	return
+}
+
+// Written returns the number of writes applied to the repository, to be reported to Saved once a snapshot
+// taken afterwards is persisted.
+func (s *RepositoryVehicleInMemory) Written() uint64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.written
+}
+
+// Saved records that the content after the given number of writes is persisted.
+func (s *RepositoryVehicleInMemory) Saved(written uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved < written {
+		s.saved = written
+	}
+}
+
+// Replace swaps the database for a copy of db in a single step, so every read sees either the old or the new content.
+// db is taken as persisted, and the swap is refused while there are writes after the last saved snapshot,
+// even when no snapshot was ever saved, as they would be lost.
+func (s *RepositoryVehicleInMemory) Replace(db map[int]*domain.VehicleAttributes) (diff VehiclesDiff, err error) {
+	next := make(map[int]*domain.VehicleAttributes, len(db))
+	for id, a := range db {
+		attributes := *a
+		next[id] = &attributes
+	}
+	ix := newIndexesVehicle(next)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.saved != s.written {
+		err = fmt.Errorf("%w. %d writes after the last save", ErrRepositoryVehicleUnpersisted, s.written-s.saved)
+		return
+	}
+
+	// the vehicles keep their version unless they are modified
+	diff = VehiclesDiff{Added: make([]int, 0), Removed: make([]int, 0), Modified: make([]int, 0)}
+	versions := make(map[int]versionVehicle, len(next))
+	for id, a := range next {
+		prev, ok := s.db[id]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, id)
+			versions[id] = versionVehicle{}.next()
+		case *prev != *a:
+			diff.Modified = append(diff.Modified, id)
+			versions[id] = s.versions[id].next()
+		default:
+			versions[id] = s.versions[id]
+		}
+	}
+	for id := range s.db {
+		if _, ok := next[id]; !ok {
+			diff.Removed = append(diff.Removed, id)
+		}
+	}
+	sort.Ints(diff.Added)
+	sort.Ints(diff.Removed)
+	sort.Ints(diff.Modified)
+
+	s.db = next
+	s.versions = versions
+	s.ix = ix
+	s.saved = s.written
+	return
+}
+
+// versionVehicle is the version of a stored vehicle.
+type versionVehicle struct {
+	version   int
+	updatedAt time.Time
+}
+
+// next returns the version that follows v, changed now.
+func (v versionVehicle) next() versionVehicle {
+	return versionVehicle{version: v.version + 1, updatedAt: time.Now().UTC()}
+}
+
+// newVersionsVehicle returns the first version of the vehicles of db, all changed now.
+func newVersionsVehicle(db map[int]*domain.VehicleAttributes) map[int]versionVehicle {
+	versions := make(map[int]versionVehicle, len(db))
+	first := versionVehicle{}.next()
+	for id := range db {
+		versions[id] = first
+	}
+	return versions
+}
+
+// checkVersion returns ErrRepositoryVehicleVersionConflict when the stored vehicle with the id, that must exist,
+// doesn't have the version, unless it is 0.
+func (s *RepositoryVehicleInMemory) checkVersion(id int, version int) error {
+	if version != 0 && s.versions[id].version != version {
+		return fmt.Errorf("%w. vehicle %d has version %d, not %d", ErrRepositoryVehicleVersionConflict, id, s.versions[id].version, version)
+	}
+	return nil
+}
+
+// vehicle returns a copy of the stored vehicle with the id, that must exist.
+func (s *RepositoryVehicleInMemory) vehicle(id int) *domain.Vehicle {
+	return &domain.Vehicle{
+		Id:         id,
+		Attributes: *s.db[id],
+		Version:    s.versions[id].version,
+		UpdatedAt:  s.versions[id].updatedAt,
+	}
+}
+
+// lookup returns the vehicles with the ids of an index entry.
+func (s *RepositoryVehicleInMemory) lookup(ids map[int]struct{}) ([]*domain.Vehicle, error) {
+	if len(ids) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
+	}
+	vehicles := make([]*domain.Vehicle, 0, len(ids))
+	for k := range ids {
+		vehicles = append(vehicles, s.vehicle(k))
+	}
+	sortById(vehicles)
+	return vehicles, nil
+}
+
+// lookupIds returns the vehicles with the ids found in an ordered index.
+func (s *RepositoryVehicleInMemory) lookupIds(ids []int) ([]*domain.Vehicle, error) {
+	if len(ids) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
+	}
+	vehicles := make([]*domain.Vehicle, 0, len(ids))
+	for _, k := range ids {
+		vehicles = append(vehicles, s.vehicle(k))
+	}
+	sortById(vehicles)
+	return vehicles, nil
+}
+
+// sortById sorts the vehicles by id, as the order of the map is random.
+func sortById(v []*domain.Vehicle) {
+	sort.Slice(v, func(i, j int) bool {
+		return v[i].Id < v[j].Id
+	})
+}
@@ -0,0 +1,296 @@
+package repository
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/search"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// indexVehicle is a secondary index of the in-memory storage: it maps a case-folded key
+// to the set of ids of the vehicles that have it.
+type indexVehicle map[string]map[int]struct{}
+
+// add indexes the id under the key.
+func (ix indexVehicle) add(key string, id int) {
+	ids, ok := ix[key]
+	if !ok {
+		ids = make(map[int]struct{})
+		ix[key] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+// remove drops the id from the key, dropping the key when no id is left.
+func (ix indexVehicle) remove(key string, id int) {
+	ids, ok := ix[key]
+	if !ok {
+		return
+	}
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(ix, key)
+	}
+}
+
+// valuesVehicle counts the vehicles of every distinct value of a field.
+type valuesVehicle map[string]int
+
+// add counts a vehicle with the value.
+func (vs valuesVehicle) add(value string) {
+	vs[value]++
+}
+
+// remove discounts a vehicle with the value, dropping the value when no vehicle is left.
+func (vs valuesVehicle) remove(value string) {
+	if vs[value]--; vs[value] <= 0 {
+		delete(vs, value)
+	}
+}
+
+// suggest returns up to limit values that start with the prefix ignoring case, the most common first.
+func (vs valuesVehicle) suggest(prefix string, limit int) []domain.Suggestion {
+	prefix = foldKey(prefix)
+	suggestions := make([]domain.Suggestion, 0)
+	for value, count := range vs {
+		if strings.HasPrefix(foldKey(value), prefix) {
+			suggestions = append(suggestions, domain.Suggestion{Value: value, Count: count})
+		}
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		a, b := suggestions[i], suggestions[j]
+		return a.Count > b.Count || a.Count == b.Count && a.Value < b.Value
+	})
+	if limit > 0 && len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// indexesVehicle are the secondary indexes of the in-memory storage.
+type indexesVehicle struct {
+	// brand indexes the vehicles by brand.
+	brand indexVehicle
+	// transmission indexes the vehicles by transmission.
+	transmission indexVehicle
+	// colorYear indexes the vehicles by color and year.
+	colorYear indexVehicle
+	// fuelType indexes the vehicles by fuel type.
+	fuelType indexVehicle
+	// weight, height, width, maxSpeed and year order the vehicles by each attribute.
+	weight   *rangeIndexVehicle
+	height   *rangeIndexVehicle
+	width    *rangeIndexVehicle
+	maxSpeed *rangeIndexVehicle
+	year     *rangeIndexVehicle
+	// dimensions indexes the vehicles by height and width.
+	dimensions *dimensionsIndexVehicle
+	// text is the full-text index of the vehicles.
+	text *search.Index
+	// brands, colors and models count the vehicles of every value, the models by case-folded brand.
+	brands valuesVehicle
+	colors valuesVehicle
+	models map[string]valuesVehicle
+}
+
+// newIndexesVehicle returns the secondary indexes of the vehicles of db.
+func newIndexesVehicle(db map[int]*domain.VehicleAttributes) *indexesVehicle {
+	ix := &indexesVehicle{
+		brand:        make(indexVehicle),
+		transmission: make(indexVehicle),
+		colorYear:    make(indexVehicle),
+		fuelType:     make(indexVehicle),
+		text:         search.NewIndex(),
+		brands:       make(valuesVehicle),
+		colors:       make(valuesVehicle),
+		models:       make(map[string]valuesVehicle),
+	}
+	// the ordered indexes are sorted once instead of inserting every vehicle in order
+	weight := make([]rangeEntryVehicle, 0, len(db))
+	height := make([]rangeEntryVehicle, 0, len(db))
+	width := make([]rangeEntryVehicle, 0, len(db))
+	maxSpeed := make([]rangeEntryVehicle, 0, len(db))
+	year := make([]rangeEntryVehicle, 0, len(db))
+	points := make([]pointVehicle, 0, len(db))
+	for id, a := range db {
+		ix.addKeys(id, a)
+		weight = append(weight, rangeEntryVehicle{value: a.Weight, id: id})
+		height = append(height, rangeEntryVehicle{value: a.Height, id: id})
+		width = append(width, rangeEntryVehicle{value: a.Width, id: id})
+		maxSpeed = append(maxSpeed, rangeEntryVehicle{value: float64(a.MaxSpeed), id: id})
+		year = append(year, rangeEntryVehicle{value: float64(a.Year), id: id})
+		points = append(points, pointVehicle{x: a.Height, y: a.Width, id: id})
+	}
+	ix.weight = newRangeIndexVehicle(weight)
+	ix.height = newRangeIndexVehicle(height)
+	ix.width = newRangeIndexVehicle(width)
+	ix.maxSpeed = newRangeIndexVehicle(maxSpeed)
+	ix.year = newRangeIndexVehicle(year)
+	ix.dimensions = newDimensionsIndexVehicle(points)
+	return ix
+}
+
+// add indexes the vehicle.
+func (ix *indexesVehicle) add(id int, a *domain.VehicleAttributes) {
+	ix.addKeys(id, a)
+	ix.weight.add(a.Weight, id)
+	ix.height.add(a.Height, id)
+	ix.width.add(a.Width, id)
+	ix.maxSpeed.add(float64(a.MaxSpeed), id)
+	ix.year.add(float64(a.Year), id)
+	ix.dimensions.add(pointVehicle{x: a.Height, y: a.Width, id: id})
+}
+
+// addKeys indexes the vehicle in the indexes by key.
+func (ix *indexesVehicle) addKeys(id int, a *domain.VehicleAttributes) {
+	ix.brand.add(foldKey(a.Brand), id)
+	ix.transmission.add(foldKey(a.Transmission), id)
+	ix.colorYear.add(colorYearKey(a.Color, a.Year), id)
+	ix.fuelType.add(foldKey(a.FuelType), id)
+	ix.text.Add(id, a)
+	ix.brands.add(a.Brand)
+	ix.colors.add(a.Color)
+	models, ok := ix.models[foldKey(a.Brand)]
+	if !ok {
+		models = make(valuesVehicle)
+		ix.models[foldKey(a.Brand)] = models
+	}
+	models.add(a.Model)
+}
+
+// remove drops the vehicle, that must be indexed with the attributes a.
+func (ix *indexesVehicle) remove(id int, a *domain.VehicleAttributes) {
+	ix.brand.remove(foldKey(a.Brand), id)
+	ix.transmission.remove(foldKey(a.Transmission), id)
+	ix.colorYear.remove(colorYearKey(a.Color, a.Year), id)
+	ix.fuelType.remove(foldKey(a.FuelType), id)
+	ix.text.Remove(id, a)
+	ix.brands.remove(a.Brand)
+	ix.colors.remove(a.Color)
+	if models, ok := ix.models[foldKey(a.Brand)]; ok {
+		if models.remove(a.Model); len(models) == 0 {
+			delete(ix.models, foldKey(a.Brand))
+		}
+	}
+	ix.weight.remove(a.Weight, id)
+	ix.height.remove(a.Height, id)
+	ix.width.remove(a.Width, id)
+	ix.maxSpeed.remove(float64(a.MaxSpeed), id)
+	ix.year.remove(float64(a.Year), id)
+	ix.dimensions.remove(id)
+}
+
+// field returns the index of the field, or nil if the field isn't indexed on its own.
+func (ix *indexesVehicle) field(f domain.VehicleField) indexVehicle {
+	switch f {
+	case domain.VehicleFieldBrand:
+		return ix.brand
+	case domain.VehicleFieldTransmission:
+		return ix.transmission
+	case domain.VehicleFieldFuelType:
+		return ix.fuelType
+	default:
+		return nil
+	}
+}
+
+// ordered returns the ordered index of the field, or nil if the field isn't ordered.
+func (ix *indexesVehicle) ordered(f domain.VehicleField) *rangeIndexVehicle {
+	switch f {
+	case domain.VehicleFieldWeight:
+		return ix.weight
+	case domain.VehicleFieldHeight:
+		return ix.height
+	case domain.VehicleFieldWidth:
+		return ix.width
+	case domain.VehicleFieldMaxSpeed:
+		return ix.maxSpeed
+	case domain.VehicleFieldYear:
+		return ix.year
+	default:
+		return nil
+	}
+}
+
+// candidates returns the ids of the vehicles that may match the filter according to the indexes,
+// or false when the indexes can't narrow the filter and every vehicle has to be checked.
+// The candidates are a superset of the matches, as the indexes ignore case.
+func (ix *indexesVehicle) candidates(f domain.Filter) (map[int]struct{}, bool) {
+	switch f := f.(type) {
+	case domain.FilterAnd:
+		// any operand narrows the conjunction, the smallest one is kept
+		var best map[int]struct{}
+		found := false
+		for _, sub := range f.Filters {
+			ids, ok := ix.candidates(sub)
+			if ok && (!found || len(ids) < len(best)) {
+				best, found = ids, true
+			}
+		}
+		return best, found
+	case domain.FilterCondition:
+		if r := ix.ordered(f.Field); r != nil {
+			return r.candidates(f)
+		}
+		index := ix.field(f.Field)
+		if index == nil {
+			return nil, false
+		}
+		switch f.Operator {
+		case domain.FilterOperatorEq, domain.FilterOperatorIEq:
+			if len(f.Values) != 1 {
+				return nil, false
+			}
+			return index[foldKey(f.Values[0].Text)], true
+		case domain.FilterOperatorIn:
+			ids := make(map[int]struct{})
+			for _, value := range f.Values {
+				for id := range index[foldKey(value.Text)] {
+					ids[id] = struct{}{}
+				}
+			}
+			return ids, true
+		}
+	}
+	return nil, false
+}
+
+// candidates returns the ids of the entries that match the comparison of the condition,
+// or false when the operator has no range.
+func (ix *rangeIndexVehicle) candidates(c domain.FilterCondition) (map[int]struct{}, bool) {
+	if len(c.Values) != 1 {
+		return nil, false
+	}
+	value := c.Values[0].Number
+	i, j := ix.first(), ix.end()
+	switch c.Operator {
+	case domain.FilterOperatorEq:
+		i, j = ix.lower(value, true), ix.upper(value, true)
+	case domain.FilterOperatorLt:
+		j = ix.upper(value, false)
+	case domain.FilterOperatorLe:
+		j = ix.upper(value, true)
+	case domain.FilterOperatorGt:
+		i = ix.lower(value, false)
+	case domain.FilterOperatorGe:
+		i = ix.lower(value, true)
+	default:
+		return nil, false
+	}
+	ids := make(map[int]struct{})
+	for _, id := range ix.ids(i, j) {
+		ids[id] = struct{}{}
+	}
+	return ids, true
+}
+
+// foldKey returns the key of a text attribute in the indexes.
+func foldKey(s string) string {
+	return strings.ToLower(s)
+}
+
+// colorYearKey returns the key of a color and year in the indexes.
+func colorYearKey(color string, year int) string {
+	return foldKey(color) + "|" + strconv.Itoa(year)
+}
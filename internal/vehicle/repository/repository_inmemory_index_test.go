@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// sizeIndexBench is the number of vehicles of the dataset of the benchmarks of the indexes.
+const sizeIndexBench = 100000
+
+// scanBench returns the vehicles of db that satisfy match, sorted by id, the way the lookups were served
+// before the indexes: a scan of every vehicle.
+func scanBench(rp *RepositoryVehicleInMemory, match func(a *domain.VehicleAttributes) bool) ([]*domain.Vehicle, error) {
+	rp.mu.RLock()
+	defer rp.mu.RUnlock()
+
+	vehicles := make([]*domain.Vehicle, 0)
+	for id, a := range rp.db {
+		if match(a) {
+			vehicles = append(vehicles, &domain.Vehicle{Id: id, Attributes: *a})
+		}
+	}
+	if len(vehicles) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
+	}
+	sort.Slice(vehicles, func(i, j int) bool { return vehicles[i].Id < vehicles[j].Id })
+	return vehicles, nil
+}
+
+// benchLookup runs the lookup of the i-th query through the index and through a scan, after checking both
+// return the same vehicles.
+func benchLookup(b *testing.B, index func(i int) ([]*domain.Vehicle, error), scan func(i int) ([]*domain.Vehicle, error)) {
+	indexed, errIndex := index(0)
+	scanned, errScan := scan(0)
+	if errIndex != errScan || !reflect.DeepEqual(idsTest(indexed), idsTest(scanned)) {
+		b.Fatalf("the index returns %d vehicles (%v) and the scan %d (%v)", len(indexed), errIndex, len(scanned), errScan)
+	}
+
+	b.Run("index", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			index(i)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scan(i)
+		}
+	})
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByColorAndYear compares the index of color and year with a scan.
+func BenchmarkRepositoryVehicleInMemory_GetByColorAndYear(b *testing.B) {
+	rp := NewRepositoryVehicleInMemory(newVehiclesTest(sizeIndexBench, 1))
+	query := func(i int) (string, int) {
+		return strings.ToUpper(colorsTest[i%len(colorsTest)]), 1980 + i%45
+	}
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByColorAndYear(query(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		color, year := query(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return a.Year == year && strings.ToLower(a.Color) == strings.ToLower(color)
+		})
+	})
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByTransmission compares the index of transmission with a scan.
+func BenchmarkRepositoryVehicleInMemory_GetByTransmission(b *testing.B) {
+	rp := NewRepositoryVehicleInMemory(newVehiclesTest(sizeIndexBench, 1))
+	query := func(i int) string {
+		return strings.ToUpper(transmissionsTest[i%len(transmissionsTest)])
+	}
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByTransmission(query(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		transmission := query(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return strings.ToLower(transmission) == strings.ToLower(a.Transmission)
+		})
+	})
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByBrand compares the index of brand, which serves the averages by brand,
+// with a scan.
+func BenchmarkRepositoryVehicleInMemory_GetByBrand(b *testing.B) {
+	rp := NewRepositoryVehicleInMemory(newVehiclesTest(sizeIndexBench, 1))
+	query := func(i int) string {
+		return strings.ToLower(brandsTest[i%len(brandsTest)])
+	}
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByBrand(query(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		brand := query(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return strings.ToLower(a.Brand) == strings.ToLower(brand)
+		})
+	})
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByFilterFuelType compares the index of fuel type, which narrows the
+// filters on it, with a scan that checks the filter on every vehicle.
+func BenchmarkRepositoryVehicleInMemory_GetByFilterFuelType(b *testing.B) {
+	rp := NewRepositoryVehicleInMemory(newVehiclesTest(sizeIndexBench, 1))
+	query := func(i int) domain.Filter {
+		return domain.FilterAnd{Filters: []domain.Filter{
+			domain.FilterCondition{Field: domain.VehicleFieldFuelType, Operator: domain.FilterOperatorEq, Values: []domain.FilterValue{{Text: fuelTypesTest[i%len(fuelTypesTest)]}}},
+			domain.FilterCondition{Field: domain.VehicleFieldYear, Operator: domain.FilterOperatorGe, Values: []domain.FilterValue{{Number: float64(2020 - i%10)}}},
+		}}
+	}
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByFilter(query(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		f := query(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return matchFilter(f, &domain.Vehicle{Attributes: *a})
+		})
+	})
+}
@@ -0,0 +1,333 @@
+package repository
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sort"
+)
+
+// rangeEntryVehicle is an entry of a rangeIndexVehicle.
+type rangeEntryVehicle struct {
+	value float64
+	id    int
+}
+
+// less reports whether the entry is ordered before e.
+func (r rangeEntryVehicle) less(e rangeEntryVehicle) bool {
+	return r.value < e.value || r.value == e.value && r.id < e.id
+}
+
+// sizeRangeIndexVehicle is the number of entries of the blocks of a rangeIndexVehicle when it is built.
+// Blocks are split when they double it.
+const sizeRangeIndexVehicle = 512
+
+// rangeIndexVehicle is an ordered index of a numeric attribute of the in-memory storage: its entries
+// are sorted by value and id in a list of blocks, so the vehicles in a range are found with a binary search
+// and a write only moves the entries of a block.
+type rangeIndexVehicle struct {
+	blocks [][]rangeEntryVehicle
+}
+
+// positionRangeIndexVehicle is the position of an entry in a rangeIndexVehicle.
+type positionRangeIndexVehicle struct {
+	block int
+	entry int
+}
+
+// newRangeIndexVehicle returns the index of the entries, reordering them.
+func newRangeIndexVehicle(entries []rangeEntryVehicle) *rangeIndexVehicle {
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].less(entries[j])
+	})
+	ix := &rangeIndexVehicle{blocks: make([][]rangeEntryVehicle, 0, len(entries)/sizeRangeIndexVehicle+1)}
+	for i := 0; i < len(entries); i += sizeRangeIndexVehicle {
+		block := make([]rangeEntryVehicle, 0, 2*sizeRangeIndexVehicle)
+		ix.blocks = append(ix.blocks, append(block, entries[i:min(i+sizeRangeIndexVehicle, len(entries))]...))
+	}
+	return ix
+}
+
+// seek returns the position of the first entry that satisfies pred, which must be false
+// for the entries before it and true for the rest.
+func (ix *rangeIndexVehicle) seek(pred func(e rangeEntryVehicle) bool) positionRangeIndexVehicle {
+	b := sort.Search(len(ix.blocks), func(i int) bool {
+		return pred(ix.blocks[i][len(ix.blocks[i])-1])
+	})
+	if b == len(ix.blocks) {
+		return positionRangeIndexVehicle{block: b}
+	}
+	block := ix.blocks[b]
+	return positionRangeIndexVehicle{block: b, entry: sort.Search(len(block), func(i int) bool {
+		return pred(block[i])
+	})}
+}
+
+// add inserts the entry keeping the order.
+func (ix *rangeIndexVehicle) add(value float64, id int) {
+	e := rangeEntryVehicle{value: value, id: id}
+	if len(ix.blocks) == 0 {
+		ix.blocks = append(ix.blocks, []rangeEntryVehicle{e})
+		return
+	}
+	p := ix.seek(func(r rangeEntryVehicle) bool {
+		return !r.less(e)
+	})
+	if p.block == len(ix.blocks) {
+		// past the last entry
+		p.block--
+		p.entry = len(ix.blocks[p.block])
+	}
+
+	block := append(ix.blocks[p.block], rangeEntryVehicle{})
+	copy(block[p.entry+1:], block[p.entry:])
+	block[p.entry] = e
+	ix.blocks[p.block] = block
+
+	if len(block) >= 2*sizeRangeIndexVehicle {
+		// the second half is moved to a new block next to it
+		half := append(make([]rangeEntryVehicle, 0, 2*sizeRangeIndexVehicle), block[len(block)/2:]...)
+		ix.blocks[p.block] = block[:len(block)/2]
+		ix.blocks = append(ix.blocks, nil)
+		copy(ix.blocks[p.block+2:], ix.blocks[p.block+1:])
+		ix.blocks[p.block+1] = half
+	}
+}
+
+// remove drops the entry.
+func (ix *rangeIndexVehicle) remove(value float64, id int) {
+	e := rangeEntryVehicle{value: value, id: id}
+	p := ix.seek(func(r rangeEntryVehicle) bool {
+		return !r.less(e)
+	})
+	if p.block == len(ix.blocks) || ix.blocks[p.block][p.entry] != e {
+		return
+	}
+
+	block := ix.blocks[p.block]
+	ix.blocks[p.block] = append(block[:p.entry], block[p.entry+1:]...)
+	if len(ix.blocks[p.block]) == 0 {
+		ix.blocks = append(ix.blocks[:p.block], ix.blocks[p.block+1:]...)
+	}
+}
+
+// lower returns the position of the first entry greater than value, or equal to it when inclusive.
+func (ix *rangeIndexVehicle) lower(value float64, inclusive bool) positionRangeIndexVehicle {
+	return ix.seek(func(e rangeEntryVehicle) bool {
+		return e.value > value || inclusive && e.value == value
+	})
+}
+
+// upper returns the position past the last entry less than value, or equal to it when inclusive.
+func (ix *rangeIndexVehicle) upper(value float64, inclusive bool) positionRangeIndexVehicle {
+	return ix.seek(func(e rangeEntryVehicle) bool {
+		return e.value > value || !inclusive && e.value == value
+	})
+}
+
+// first returns the position of the first entry.
+func (ix *rangeIndexVehicle) first() positionRangeIndexVehicle {
+	return positionRangeIndexVehicle{}
+}
+
+// end returns the position past the last entry.
+func (ix *rangeIndexVehicle) end() positionRangeIndexVehicle {
+	return positionRangeIndexVehicle{block: len(ix.blocks)}
+}
+
+// in returns the ids of the entries whose value is in the range.
+func (ix *rangeIndexVehicle) in(r domain.Range) []int {
+	from, to := ix.first(), ix.end()
+	if !r.Min.Unbounded {
+		from = ix.lower(r.Min.Value, r.Min.Inclusive)
+	}
+	if !r.Max.Unbounded {
+		to = ix.upper(r.Max.Value, r.Max.Inclusive)
+	}
+	return ix.ids(from, to)
+}
+
+// ids returns the ids of the entries from position from to position to.
+func (ix *rangeIndexVehicle) ids(from positionRangeIndexVehicle, to positionRangeIndexVehicle) []int {
+	ids := make([]int, 0)
+	for b := from.block; b < len(ix.blocks) && b <= to.block; b++ {
+		block := ix.blocks[b]
+		i, j := 0, len(block)
+		if b == from.block {
+			i = from.entry
+		}
+		if b == to.block {
+			j = to.entry
+		}
+		for ; i < j; i++ {
+			ids = append(ids, block[i].id)
+		}
+	}
+	return ids
+}
+
+// pointVehicle is a vehicle in the plane of its height (x) and width (y).
+type pointVehicle struct {
+	x  float64
+	y  float64
+	id int
+}
+
+// coord returns the coordinate of the point on the axis: 0 for x and 1 for y.
+func (p pointVehicle) coord(axis int) float64 {
+	if axis == 0 {
+		return p.x
+	}
+	return p.y
+}
+
+// kdTreeVehicle is a static 2-d tree stored in a slice: the median of every subslice is its root,
+// splitting the points by x and y on alternate levels.
+type kdTreeVehicle []pointVehicle
+
+// newKdTreeVehicle returns the tree of the points, reordering them.
+func newKdTreeVehicle(points []pointVehicle) kdTreeVehicle {
+	buildKdTreeVehicle(points, 0)
+	return points
+}
+
+// buildKdTreeVehicle places the median of the points on the axis in the middle of the slice
+// and builds both halves on the other axis.
+func buildKdTreeVehicle(points []pointVehicle, axis int) {
+	if len(points) <= 1 {
+		return
+	}
+	m := len(points) / 2
+	selectKdTreeVehicle(points, m, axis)
+	buildKdTreeVehicle(points[:m], 1-axis)
+	buildKdTreeVehicle(points[m+1:], 1-axis)
+}
+
+// selectKdTreeVehicle reorders the points so the k-th one on the axis is at position k,
+// with no greater point before it and no lesser point after it.
+func selectKdTreeVehicle(points []pointVehicle, k int, axis int) {
+	lo, hi := 0, len(points)-1
+	for lo < hi {
+		pivot := points[(lo+hi)/2].coord(axis)
+		i, j := lo, hi
+		for i <= j {
+			for points[i].coord(axis) < pivot {
+				i++
+			}
+			for points[j].coord(axis) > pivot {
+				j--
+			}
+			if i <= j {
+				points[i], points[j] = points[j], points[i]
+				i++
+				j--
+			}
+		}
+		switch {
+		case k <= j:
+			hi = j
+		case k >= i:
+			lo = i
+		default:
+			return
+		}
+	}
+}
+
+// search calls fn with the ids of the points whose x is in rx and y is in ry.
+func (t kdTreeVehicle) search(rx domain.Range, ry domain.Range, fn func(id int)) {
+	t.searchAxis(0, [2]domain.Range{rx, ry}, fn)
+}
+
+func (t kdTreeVehicle) searchAxis(axis int, r [2]domain.Range, fn func(id int)) {
+	if len(t) == 0 {
+		return
+	}
+	m := len(t) / 2
+	p := t[m]
+	if r[0].Contains(p.x) && r[1].Contains(p.y) {
+		fn(p.id)
+	}
+	// the points before the median aren't greater than it and the ones after it aren't lesser
+	c := p.coord(axis)
+	if r[axis].AboveMin(c) {
+		t[:m].searchAxis(1-axis, r, fn)
+	}
+	if r[axis].BelowMax(c) {
+		t[m+1:].searchAxis(1-axis, r, fn)
+	}
+}
+
+// dimensionsIndexVehicle is the index of the vehicles by height and width.
+// The tree is static, so the points written since it was built are kept aside: the added ones are
+// scanned on every search and the stale ones of the tree skipped, until there are enough of them
+// to rebuild the tree.
+type dimensionsIndexVehicle struct {
+	// tree is the tree of the points as of its last build.
+	tree kdTreeVehicle
+	// added are the points added since the tree was built, by id.
+	added map[int]pointVehicle
+	// stale are the ids of the points of the tree removed since it was built.
+	stale map[int]struct{}
+}
+
+// minRebuildDimensionsIndexVehicle is the least number of pending writes that rebuild the tree.
+const minRebuildDimensionsIndexVehicle = 64
+
+// newDimensionsIndexVehicle returns the index of the points.
+func newDimensionsIndexVehicle(points []pointVehicle) *dimensionsIndexVehicle {
+	return &dimensionsIndexVehicle{
+		tree:  newKdTreeVehicle(points),
+		added: make(map[int]pointVehicle),
+		stale: make(map[int]struct{}),
+	}
+}
+
+// add indexes the point.
+func (ix *dimensionsIndexVehicle) add(p pointVehicle) {
+	ix.added[p.id] = p
+	ix.rebuild()
+}
+
+// remove drops the point with the id.
+func (ix *dimensionsIndexVehicle) remove(id int) {
+	if _, ok := ix.added[id]; ok {
+		delete(ix.added, id)
+		return
+	}
+	ix.stale[id] = struct{}{}
+	ix.rebuild()
+}
+
+// rebuild builds the tree again when the pending writes are a sixteenth of it,
+// so the cost of the rebuild is spread over the writes.
+func (ix *dimensionsIndexVehicle) rebuild() {
+	pending := len(ix.added) + len(ix.stale)
+	if pending < minRebuildDimensionsIndexVehicle || pending < len(ix.tree)/16 {
+		return
+	}
+	points := make([]pointVehicle, 0, len(ix.tree)+len(ix.added))
+	for _, p := range ix.tree {
+		if _, ok := ix.stale[p.id]; !ok {
+			points = append(points, p)
+		}
+	}
+	for _, p := range ix.added {
+		points = append(points, p)
+	}
+	*ix = *newDimensionsIndexVehicle(points)
+}
+
+// search returns the ids of the vehicles whose height and width are in the ranges.
+func (ix *dimensionsIndexVehicle) search(height domain.Range, width domain.Range) []int {
+	ids := make([]int, 0)
+	ix.tree.search(height, width, func(id int) {
+		if _, ok := ix.stale[id]; !ok {
+			ids = append(ids, id)
+		}
+	})
+	for _, p := range ix.added {
+		if height.Contains(p.x) && width.Contains(p.y) {
+			ids = append(ids, p.id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sync"
+	"testing"
+)
+
+// sizeRangeBench is the number of vehicles of the dataset of the benchmarks of the range indexes.
+const sizeRangeBench = 1000000
+
+var (
+	rangeBenchOnce sync.Once
+	rangeBenchRp   *RepositoryVehicleInMemory
+)
+
+// rangeBenchRepository returns the repository of the benchmarks of the range indexes, built once as it is large.
+func rangeBenchRepository(b *testing.B) *RepositoryVehicleInMemory {
+	b.Helper()
+	rangeBenchOnce.Do(func() {
+		rangeBenchRp = NewRepositoryVehicleInMemory(newVehiclesTest(sizeRangeBench, 1))
+	})
+	return rangeBenchRp
+}
+
+// weightBench returns the range of weights of the i-th query.
+func weightBench(i int) domain.Range {
+	min := 500 + float64(i%3000)
+	return rangeTest(min, min+2)
+}
+
+// dimensionsBench returns the ranges of height and width of the i-th query.
+func dimensionsBench(i int) (domain.Range, domain.Range) {
+	height := 100 + float64(i%200)
+	width := 150 + float64(i*7%100)
+	return rangeTest(height, height+5), rangeTest(width, width+5)
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByWeight compares the ordered index of weight with a scan.
+func BenchmarkRepositoryVehicleInMemory_GetByWeight(b *testing.B) {
+	rp := rangeBenchRepository(b)
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByWeight(weightBench(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		weight := weightBench(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return weight.Contains(a.Weight)
+		})
+	})
+}
+
+// BenchmarkRepositoryVehicleInMemory_GetByDimensions compares the 2-d tree of height and width with a scan.
+func BenchmarkRepositoryVehicleInMemory_GetByDimensions(b *testing.B) {
+	rp := rangeBenchRepository(b)
+
+	benchLookup(b, func(i int) ([]*domain.Vehicle, error) {
+		return rp.GetByDimensions(dimensionsBench(i))
+	}, func(i int) ([]*domain.Vehicle, error) {
+		height, width := dimensionsBench(i)
+		return scanBench(rp, func(a *domain.VehicleAttributes) bool {
+			return height.Contains(a.Height) && width.Contains(a.Width)
+		})
+	})
+}
+
+// BenchmarkKdTreeVehicle_Search compares the search of the 2-d tree with a scan of its points,
+// without the copies of the vehicles the repository returns.
+func BenchmarkKdTreeVehicle_Search(b *testing.B) {
+	tree := rangeBenchRepository(b).ix.dimensions.tree
+	count := func(i int) (n int) {
+		height, width := dimensionsBench(i)
+		tree.search(height, width, func(id int) { n++ })
+		return
+	}
+	scan := func(i int) (n int) {
+		height, width := dimensionsBench(i)
+		for _, p := range tree {
+			if height.Contains(p.x) && width.Contains(p.y) {
+				n++
+			}
+		}
+		return
+	}
+	if count(0) != scan(0) {
+		b.Fatalf("the tree finds %d points and the scan %d", count(0), scan(0))
+	}
+
+	b.Run("tree", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			count(i)
+		}
+	})
+	b.Run("scan", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			scan(i)
+		}
+	})
+}
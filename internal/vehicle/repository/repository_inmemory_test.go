@@ -0,0 +1,447 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math/rand"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+)
+
+var (
+	brandsTest        = []string{"Toyota", "Ford", "Chevrolet", "Honda", "Volvo", "Fiat", "Kia", "Tesla"}
+	modelsTest        = []string{"A", "B", "C", "D", "E"}
+	colorsTest        = []string{"red", "blue", "green", "black", "white", "Silver"}
+	fuelTypesTest     = []string{"diesel", "biodiesel", "gas", "gasoline"}
+	transmissionsTest = []string{"automatic", "manual", "semi-automatic"}
+)
+
+// newAttributesTest returns random attributes of a vehicle.
+func newAttributesTest(r *rand.Rand, id int) *domain.VehicleAttributes {
+	return &domain.VehicleAttributes{
+		Brand:        brandsTest[r.Intn(len(brandsTest))],
+		Model:        modelsTest[r.Intn(len(modelsTest))],
+		Registration: fmt.Sprintf("REG-%d-%d", id, r.Intn(1000)),
+		Year:         1980 + r.Intn(45),
+		Color:        colorsTest[r.Intn(len(colorsTest))],
+		MaxSpeed:     100 + r.Intn(200),
+		FuelType:     fuelTypesTest[r.Intn(len(fuelTypesTest))],
+		Transmission: transmissionsTest[r.Intn(len(transmissionsTest))],
+		Passengers:   1 + r.Intn(8),
+		Height:       100 + float64(r.Intn(2000))/10,
+		Width:        150 + float64(r.Intn(1000))/10,
+		Weight:       500 + float64(r.Intn(30000))/10,
+	}
+}
+
+// newVehiclesTest returns n random vehicles with the ids 1 to n, the same ones for the same seed.
+func newVehiclesTest(n int, seed int64) map[int]*domain.VehicleAttributes {
+	r := rand.New(rand.NewSource(seed))
+	db := make(map[int]*domain.VehicleAttributes, n)
+	for id := 1; id <= n; id++ {
+		db[id] = newAttributesTest(r, id)
+	}
+	return db
+}
+
+// rangeTest returns the range [min,max].
+func rangeTest(min float64, max float64) domain.Range {
+	return domain.Range{
+		Min: domain.RangeBound{Value: min, Inclusive: true},
+		Max: domain.RangeBound{Value: max, Inclusive: true},
+	}
+}
+
+// idsTest returns the ids of the vehicles, sorted.
+func idsTest(vehicles []*domain.Vehicle) []int {
+	ids := make([]int, 0, len(vehicles))
+	for _, v := range vehicles {
+		ids = append(ids, v.Id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// scanTest returns the ids of the vehicles of GetAll that satisfy match, sorted.
+func scanTest(t *testing.T, rp *RepositoryVehicleInMemory, match func(v *domain.Vehicle) bool) []int {
+	t.Helper()
+	all, err := rp.GetAll()
+	if err != nil && !errors.Is(err, ErrRepositoryVehicleNotFound) {
+		t.Fatalf("GetAll: %v", err)
+	}
+	ids := make([]int, 0)
+	for _, v := range all {
+		if match(v) {
+			ids = append(ids, v.Id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// expectedTest fails the test when the error of a concurrent operation isn't one the operation can expect.
+func expectedTest(t *testing.T, op string, err error) {
+	if err == nil || errors.Is(err, ErrRepositoryVehicleNotFound) || errors.Is(err, ErrRepositoryIdInUse) ||
+		errors.Is(err, ErrRepositoryVehicleVersionConflict) || errors.Is(err, ErrRepositoryVehicleUnpersisted) ||
+		errors.Is(err, errRollbackTest) {
+		return
+	}
+	t.Errorf("%s: unexpected error %v", op, err)
+}
+
+// errRollbackTest makes a transaction roll back.
+var errRollbackTest = errors.New("rollback")
+
+// TestRepositoryVehicleInMemory_Concurrent runs every method of the repository in parallel goroutines, to be run
+// with the race detector, and then checks no change made to the returned vehicles reached the storage and the
+// indexes still agree with the stored vehicles.
+func TestRepositoryVehicleInMemory_Concurrent(t *testing.T) {
+	const (
+		workers = 8
+		ops     = 500
+		ids     = 300
+	)
+	rp := NewRepositoryVehicleInMemory(newVehiclesTest(ids/2, 1))
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			r := rand.New(rand.NewSource(int64(w)))
+			for i := 0; i < ops; i++ {
+				id := 1 + r.Intn(ids)
+				a := newAttributesTest(r, id)
+				var vs []*domain.Vehicle
+				var err error
+				switch op := r.Intn(19); op {
+				case 0:
+					vs, err = rp.GetAll()
+					expectedTest(t, "GetAll", err)
+				case 1:
+					vs, err = rp.GetByDimensions(rangeTest(a.Height-20, a.Height+20), rangeTest(a.Width-10, a.Width+10))
+					expectedTest(t, "GetByDimensions", err)
+				case 2:
+					vs, err = rp.GetByColorAndYear(a.Color, a.Year)
+					expectedTest(t, "GetByColorAndYear", err)
+				case 3:
+					vs, err = rp.GetByWeight(rangeTest(a.Weight-200, a.Weight+200))
+					expectedTest(t, "GetByWeight", err)
+				case 4:
+					vs, err = rp.GetByBrand(a.Brand)
+					expectedTest(t, "GetByBrand", err)
+				case 5:
+					vs, err = rp.GetByTransmission(a.Transmission)
+					expectedTest(t, "GetByTransmission", err)
+				case 6:
+					expectedTest(t, "PatchFuel", rp.PatchFuel(id, a.FuelType, 0))
+				case 7:
+					expectedTest(t, "Put", rp.Put(&domain.Vehicle{Id: id, Attributes: *a}))
+				case 8:
+					expectedTest(t, "Delete", rp.Delete(id, 0))
+				case 9:
+					expectedTest(t, "Post", rp.Post(&domain.Vehicle{Id: id, Attributes: *a}))
+				case 10:
+					rp.Saved(rp.Written())
+					_, err = rp.Replace(newVehiclesTest(ids/2, int64(i)))
+					expectedTest(t, "Replace", err)
+				case 11:
+					f := domain.FilterAnd{Filters: []domain.Filter{
+						domain.FilterCondition{Field: domain.VehicleFieldBrand, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: a.Brand}}},
+						domain.FilterCondition{Field: domain.VehicleFieldYear, Operator: domain.FilterOperatorGe, Values: []domain.FilterValue{{Number: float64(a.Year)}}},
+					}}
+					vs, err = rp.GetByFilter(f)
+					expectedTest(t, "GetByFilter", err)
+				case 12:
+					_, err = rp.Search(a.Brand + " " + a.Color)
+					expectedTest(t, "Search", err)
+				case 13:
+					_, err = rp.Suggest(domain.VehicleFieldModel, a.Brand, "", 5)
+					expectedTest(t, "Suggest", err)
+				case 14:
+					var v *domain.Vehicle
+					v, err = rp.GetById(id)
+					expectedTest(t, "GetById", err)
+					if v != nil {
+						vs = []*domain.Vehicle{v}
+					}
+				case 15:
+					// a version read before is likely stale by now
+					if v, err := rp.GetById(id); err == nil {
+						expectedTest(t, "Put with version", rp.Put(&domain.Vehicle{Id: id, Attributes: *a, Version: v.Version}))
+					}
+				case 16:
+					var v *domain.Vehicle
+					v, err = rp.Update(id, 0, func(u *domain.VehicleAttributes) error {
+						u.Color, u.Weight = a.Color, a.Weight
+						return nil
+					})
+					expectedTest(t, "Update", err)
+					if v != nil {
+						vs = []*domain.Vehicle{v}
+					}
+				case 17, 18:
+					// the writes of the transaction are kept or undone as a whole
+					rollback := op == 18
+					err = rp.Transaction(func(tx RepositoryVehicleTx) error {
+						if err := tx.Post(&domain.Vehicle{Id: id, Attributes: *a}); err != nil {
+							return err
+						}
+						if _, err := tx.Update(id, 0, func(u *domain.VehicleAttributes) error {
+							u.MaxSpeed++
+							return nil
+						}); err != nil {
+							return err
+						}
+						if _, err := tx.GetByFilter(domain.FilterCondition{Field: domain.VehicleFieldId, Operator: domain.FilterOperatorEq, Values: []domain.FilterValue{{Number: float64(id)}}}); err != nil {
+							return err
+						}
+						if err := tx.Delete(1+r.Intn(ids), 0); err != nil && !errors.Is(err, ErrRepositoryVehicleNotFound) {
+							return err
+						}
+						if rollback {
+							return errRollbackTest
+						}
+						return nil
+					})
+					expectedTest(t, "Transaction", err)
+				}
+				// the vehicles are copies the caller can change
+				for _, v := range vs {
+					v.Attributes.Brand = "changed"
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	vs, err := rp.GetAll()
+	if err != nil && !errors.Is(err, ErrRepositoryVehicleNotFound) {
+		t.Fatal(err)
+	}
+	for _, v := range vs {
+		if v.Attributes.Brand == "changed" {
+			t.Fatalf("the change of a returned copy reached vehicle %d", v.Id)
+		}
+	}
+	checkIndexesTest(t, rp)
+}
+
+// TestRepositoryVehicleInMemory_TransactionRollback checks a failed or panicking transaction leaves the
+// repository as it was.
+func TestRepositoryVehicleInMemory_TransactionRollback(t *testing.T) {
+	db := newVehiclesTest(50, 2)
+	rp := NewRepositoryVehicleInMemory(db)
+	before, err := rp.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	written := rp.Written()
+
+	writes := func(tx RepositoryVehicleTx) {
+		r := rand.New(rand.NewSource(3))
+		if err := tx.Post(&domain.Vehicle{Id: 100, Attributes: *newAttributesTest(r, 100)}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Put(&domain.Vehicle{Id: 1, Attributes: *newAttributesTest(r, 1)}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Update(2, 0, func(a *domain.VehicleAttributes) error {
+			a.Brand = "Updated"
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+		if err := tx.Delete(3, 0); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tx.Update(100, 0, func(a *domain.VehicleAttributes) error {
+			a.Color = "Updated"
+			return nil
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = rp.Transaction(func(tx RepositoryVehicleTx) error {
+		writes(tx)
+		return errRollbackTest
+	})
+	if !errors.Is(err, errRollbackTest) {
+		t.Fatalf("Transaction returned %v, want the error of fn", err)
+	}
+	assertUnchangedTest(t, rp, before)
+	if rp.Written() != written {
+		t.Fatalf("the rolled back writes are counted: %d writes, want %d", rp.Written(), written)
+	}
+
+	func() {
+		defer func() {
+			if p := recover(); p == nil {
+				t.Fatal("Transaction didn't panic again")
+			}
+		}()
+		_ = rp.Transaction(func(tx RepositoryVehicleTx) error {
+			writes(tx)
+			panic("fn panics")
+		})
+	}()
+	assertUnchangedTest(t, rp, before)
+	if rp.Written() != written {
+		t.Fatalf("the rolled back writes are counted: %d writes, want %d", rp.Written(), written)
+	}
+
+	// the lock is released after the panic
+	if err := rp.Post(&domain.Vehicle{Id: 101, Attributes: *db[1]}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// assertUnchangedTest fails the test when the vehicles of the repository aren't the ones before.
+func assertUnchangedTest(t *testing.T, rp *RepositoryVehicleInMemory, before []*domain.Vehicle) {
+	t.Helper()
+	after, err := rp.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		t.Fatal("the vehicles changed")
+	}
+	checkIndexesTest(t, rp)
+}
+
+// checkIndexesTest fails the test when a lookup served by the indexes differs from a scan of the vehicles.
+func checkIndexesTest(t *testing.T, rp *RepositoryVehicleInMemory) {
+	t.Helper()
+	rp.mu.RLock()
+	rebuilt := newIndexesVehicle(rp.db)
+	same := reflect.DeepEqual(rebuilt.brand, rp.ix.brand) && reflect.DeepEqual(rebuilt.transmission, rp.ix.transmission) &&
+		reflect.DeepEqual(rebuilt.colorYear, rp.ix.colorYear) && reflect.DeepEqual(rebuilt.fuelType, rp.ix.fuelType) &&
+		reflect.DeepEqual(rebuilt.brands, rp.ix.brands) && reflect.DeepEqual(rebuilt.colors, rp.ix.colors) &&
+		reflect.DeepEqual(rebuilt.models, rp.ix.models)
+	rp.mu.RUnlock()
+	if !same {
+		t.Error("the indexes by key differ from the ones of the stored vehicles")
+	}
+
+	check := func(name string, vehicles []*domain.Vehicle, err error, match func(v *domain.Vehicle) bool) {
+		t.Helper()
+		if err != nil && !errors.Is(err, ErrRepositoryVehicleNotFound) {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got, want := idsTest(vehicles), scanTest(t, rp, match); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s returned %v, want %v", name, got, want)
+		}
+	}
+	for _, brand := range brandsTest {
+		vs, err := rp.GetByBrand(brand)
+		check("GetByBrand "+brand, vs, err, func(v *domain.Vehicle) bool { return v.Attributes.Brand == brand })
+	}
+	for _, transmission := range transmissionsTest {
+		vs, err := rp.GetByTransmission(transmission)
+		check("GetByTransmission "+transmission, vs, err, func(v *domain.Vehicle) bool { return v.Attributes.Transmission == transmission })
+	}
+	for year := 1980; year < 2025; year += 7 {
+		vs, err := rp.GetByColorAndYear("RED", year)
+		check(fmt.Sprint("GetByColorAndYear ", year), vs, err, func(v *domain.Vehicle) bool {
+			return v.Attributes.Color == "red" && v.Attributes.Year == year
+		})
+	}
+	for _, fuelType := range fuelTypesTest {
+		vs, err := rp.GetByFilter(domain.FilterCondition{Field: domain.VehicleFieldFuelType, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: fuelType}}})
+		check("GetByFilter fuel_type "+fuelType, vs, err, func(v *domain.Vehicle) bool { return v.Attributes.FuelType == fuelType })
+	}
+	for w := 500.0; w < 3500; w += 500 {
+		vs, err := rp.GetByWeight(rangeTest(w, w+400))
+		check(fmt.Sprint("GetByWeight ", w), vs, err, func(v *domain.Vehicle) bool {
+			return v.Attributes.Weight >= w && v.Attributes.Weight <= w+400
+		})
+	}
+	for h := 100.0; h < 300; h += 40 {
+		vs, err := rp.GetByDimensions(rangeTest(h, h+50), rangeTest(180, 220))
+		check(fmt.Sprint("GetByDimensions ", h), vs, err, func(v *domain.Vehicle) bool {
+			return v.Attributes.Height >= h && v.Attributes.Height <= h+50 && v.Attributes.Width >= 180 && v.Attributes.Width <= 220
+		})
+	}
+}
+
+// TestRepositoryVehicleInMemory_Replace checks the content is only replaced when every write is saved.
+func TestRepositoryVehicleInMemory_Replace(t *testing.T) {
+	post := func(rp *RepositoryVehicleInMemory) error {
+		return rp.Post(&domain.Vehicle{Id: 100, Attributes: domain.VehicleAttributes{Brand: "Posted"}})
+	}
+	save := func(rp *RepositoryVehicleInMemory) error {
+		rp.Saved(rp.Written())
+		return nil
+	}
+	put := func(rp *RepositoryVehicleInMemory) error {
+		return rp.Put(&domain.Vehicle{Id: 1, Attributes: domain.VehicleAttributes{Brand: "Put"}})
+	}
+	rollback := func(rp *RepositoryVehicleInMemory) error {
+		err := rp.Transaction(func(tx RepositoryVehicleTx) error {
+			if err := tx.Post(&domain.Vehicle{Id: 100, Attributes: domain.VehicleAttributes{Brand: "Posted"}}); err != nil {
+				return err
+			}
+			return errRollbackTest
+		})
+		if !errors.Is(err, errRollbackTest) {
+			return err
+		}
+		return nil
+	}
+	replace := func(rp *RepositoryVehicleInMemory) error {
+		_, err := rp.Replace(newVehiclesTest(5, 2))
+		return err
+	}
+
+	tests := []struct {
+		name   string
+		writes []func(rp *RepositoryVehicleInMemory) error
+		err    error
+	}{
+		{name: "no writes", writes: nil},
+		{name: "writes never saved", writes: []func(rp *RepositoryVehicleInMemory) error{post}, err: ErrRepositoryVehicleUnpersisted},
+		{name: "writes saved", writes: []func(rp *RepositoryVehicleInMemory) error{post, save}},
+		{name: "writes after the save", writes: []func(rp *RepositoryVehicleInMemory) error{post, save, put}, err: ErrRepositoryVehicleUnpersisted},
+		{name: "writes rolled back", writes: []func(rp *RepositoryVehicleInMemory) error{rollback}},
+		{name: "writes after a replace", writes: []func(rp *RepositoryVehicleInMemory) error{replace, put}, err: ErrRepositoryVehicleUnpersisted},
+		{name: "replaced twice", writes: []func(rp *RepositoryVehicleInMemory) error{replace}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := NewRepositoryVehicleInMemory(newVehiclesTest(5, 1))
+			for _, write := range tt.writes {
+				if err := write(rp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			before, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			db := newVehiclesTest(3, 3)
+			diff, err := rp.Replace(db)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Replace returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				assertUnchangedTest(t, rp, before)
+				return
+			}
+			if len(diff.Removed) == 0 || len(diff.Modified) == 0 {
+				t.Errorf("Replace returned the diff %+v", diff)
+			}
+			vs, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vs) != len(db) {
+				t.Errorf("the repository has %d vehicles after the replace, want %d", len(vs), len(db))
+			}
+		})
+	}
+}
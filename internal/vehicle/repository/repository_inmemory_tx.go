@@ -0,0 +1,76 @@
+package repository
+
+import "github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+
+// txVehicleInMemory is a transaction over a vehicle storage in memory, that runs with its write lock held.
+// Every write saves the vehicle as it was before, so that the writes can be undone in reverse order.
+type txVehicleInMemory struct {
+	s *RepositoryVehicleInMemory
+	// undo are the vehicles as they were before every write, in the order of the writes.
+	undo []undoVehicle
+	// written is the number of writes of the storage when the transaction began.
+	written uint64
+}
+
+// undoVehicle is a stored vehicle as it was before a write.
+type undoVehicle struct {
+	id int
+	// attributes are nil when the vehicle didn't exist.
+	attributes *domain.VehicleAttributes
+	version    versionVehicle
+}
+
+func (tx *txVehicleInMemory) GetById(id int) (*domain.Vehicle, error) {
+	return tx.s.getById(id)
+}
+
+func (tx *txVehicleInMemory) GetByFilter(f domain.Filter) ([]*domain.Vehicle, error) {
+	return tx.s.getByFilter(f)
+}
+
+func (tx *txVehicleInMemory) Post(vehicle *domain.Vehicle) error {
+	tx.save(vehicle.Id)
+	return tx.s.post(vehicle)
+}
+
+func (tx *txVehicleInMemory) Put(vehicle *domain.Vehicle) error {
+	tx.save(vehicle.Id)
+	return tx.s.put(vehicle)
+}
+
+func (tx *txVehicleInMemory) Update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error) {
+	tx.save(id)
+	return tx.s.update(id, version, update)
+}
+
+func (tx *txVehicleInMemory) Delete(id int, version int) error {
+	tx.save(id)
+	return tx.s.delete(id, version)
+}
+
+// save adds the vehicle with the id, as it is, to the undo log. The writes replace the stored attributes
+// instead of changing them, so the saved ones are kept as they are.
+func (tx *txVehicleInMemory) save(id int) {
+	tx.undo = append(tx.undo, undoVehicle{id: id, attributes: tx.s.db[id], version: tx.s.versions[id]})
+}
+
+// rollback restores the vehicles of the undo log, the last written first.
+func (tx *txVehicleInMemory) rollback() {
+	s := tx.s
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		u := tx.undo[i]
+		if current, ok := s.db[u.id]; ok {
+			s.ix.remove(u.id, current)
+			delete(s.db, u.id)
+			delete(s.versions, u.id)
+		}
+		if u.attributes != nil {
+			s.db[u.id] = u.attributes
+			s.ix.add(u.id, u.attributes)
+			s.versions[u.id] = u.version
+		}
+	}
+	tx.undo = nil
+	// the undone writes aren't counted, so they aren't taken as unpersisted
+	s.written = tx.written
+}
@@ -0,0 +1,555 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/search"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DSNVehicleSQLite returns the data source name of the database file at path for the sqlite3 driver.
+// Transactions take the write lock when they begin, so the ones that read before writing can't fail to
+// upgrade their lock, and the statements wait for the locks of other processes instead of failing at once.
+func DSNVehicleSQLite(path string) string {
+	return path + "?_busy_timeout=5000&_txlock=immediate"
+}
+
+// NewRepositoryVehicleSQLite returns a new instance of a vehicle storage backed by SQLite, that should be
+// opened with DSNVehicleSQLite. The database is limited to a single connection, which serializes the
+// statements and transactions of the repository.
+func NewRepositoryVehicleSQLite(db *sql.DB) *RepositoryVehicleSQLite {
+	db.SetMaxOpenConns(1)
+	return &RepositoryVehicleSQLite{db: db, search: &searchVehicleSQLite{}}
+}
+
+// RepositoryVehicleSQLite is an struct that represents a vehicle storage in a SQLite database.
+type RepositoryVehicleSQLite struct {
+	// db is the database connection.
+	db *sql.DB
+	// tx is the transaction the statements run in, nil out of transactions.
+	tx *sql.Tx
+	// search is the full-text index of the vehicles, shared with the copies of the repository in transactions.
+	search *searchVehicleSQLite
+}
+
+// searchVehicleSQLite is the full-text index of the vehicles of the database, kept until they change.
+type searchVehicleSQLite struct {
+	// written counts the writes of the repository, that invalidate the index.
+	written atomic.Uint64
+	// mu guards the index and the key it was built at.
+	mu       sync.Mutex
+	ix       *search.Index
+	vehicles map[int]*domain.Vehicle
+	// built reports whether there is an index, built after writes and dataVersion, the data_version of the
+	// connection that changes with the commits of other processes.
+	built       bool
+	writes      uint64
+	dataVersion int64
+}
+
+// schemaVehicleSQLite creates the vehicles table and the indexes used by the queries of the repository.
+const schemaVehicleSQLite = `
+CREATE TABLE IF NOT EXISTS vehicles (
+	id           INTEGER PRIMARY KEY,
+	brand        TEXT    NOT NULL,
+	model        TEXT    NOT NULL,
+	registration TEXT    NOT NULL,
+	year         INTEGER NOT NULL,
+	color        TEXT    NOT NULL,
+	max_speed    INTEGER NOT NULL,
+	fuel_type    TEXT    NOT NULL,
+	transmission TEXT    NOT NULL,
+	passengers   INTEGER NOT NULL,
+	height       REAL    NOT NULL,
+	width        REAL    NOT NULL,
+	weight       REAL    NOT NULL,
+	version      INTEGER NOT NULL DEFAULT 1,
+	updated_at   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS idx_vehicles_brand ON vehicles (brand COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_vehicles_color_year ON vehicles (color COLLATE NOCASE, year);
+CREATE INDEX IF NOT EXISTS idx_vehicles_transmission ON vehicles (transmission COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_vehicles_weight ON vehicles (weight);
+`
+
+// migrationsVehicleSQLite add the columns missing in the tables of previous schemas, by column name.
+var migrationsVehicleSQLite = []struct {
+	column    string
+	statement string
+}{
+	{"version", "ALTER TABLE vehicles ADD COLUMN version INTEGER NOT NULL DEFAULT 1"},
+	{"updated_at", "ALTER TABLE vehicles ADD COLUMN updated_at INTEGER NOT NULL DEFAULT 0"},
+}
+
+const columnsVehicleSQLite = "id, brand, model, registration, year, color, max_speed, fuel_type, transmission, passengers, height, width, weight, version, updated_at"
+
+// CreateSchema creates the tables and indexes of the repository if they don't exist, and migrates the
+// tables of previous schemas. The vehicles with no update time take the current one.
+func (r *RepositoryVehicleSQLite) CreateSchema() error {
+	if _, err := r.db.Exec(schemaVehicleSQLite); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+
+	rows, err := r.db.Query("SELECT name FROM pragma_table_info('vehicles')")
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+		columns[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	for _, m := range migrationsVehicleSQLite {
+		if columns[m.column] {
+			continue
+		}
+		if _, err := r.db.Exec(m.statement); err != nil {
+			return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+	}
+
+	if _, err := r.db.Exec("UPDATE vehicles SET updated_at = ? WHERE updated_at = 0", nowSQLite()); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	return nil
+}
+
+// Seed inserts the given vehicles only when the database is empty, so the seed is applied on first boot.
+// It returns whether the vehicles were inserted.
+func (r *RepositoryVehicleSQLite) Seed(v map[int]*domain.VehicleAttributes) (seeded bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		return
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err = tx.QueryRow("SELECT COUNT(*) FROM vehicles").Scan(&count); err != nil {
+		err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		return
+	}
+	if count > 0 {
+		return
+	}
+
+	stmt, err := tx.Prepare("INSERT INTO vehicles (" + columnsVehicleSQLite + ") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?)")
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		return
+	}
+	defer stmt.Close()
+
+	for id, a := range v {
+		if _, err = stmt.Exec(vehicleSQLiteArgs(id, a)...); err != nil {
+			err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+			return
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		return
+	}
+	r.written()
+	seeded = true
+	return
+}
+
+// GetAll returns all vehicles
+func (r *RepositoryVehicleSQLite) GetAll() (v []*domain.Vehicle, err error) {
+	return r.query("SELECT " + columnsVehicleSQLite + " FROM vehicles ORDER BY id")
+}
+
+// GetById returns the vehicle with the id
+func (r *RepositoryVehicleSQLite) GetById(id int) (*domain.Vehicle, error) {
+	vehicles, err := r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE id = ?", id)
+	if err != nil {
+		return nil, err
+	}
+	return vehicles[0], nil
+}
+
+// GetByFilter returns the vehicles that match the filter
+func (r *RepositoryVehicleSQLite) GetByFilter(f domain.Filter) (v []*domain.Vehicle, err error) {
+	where, args, err := sqlFilter(f)
+	if err != nil {
+		return
+	}
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE "+where+" ORDER BY id", args...)
+}
+
+func (r *RepositoryVehicleSQLite) GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error) {
+	whereHeight, args := sqlRange("height", height)
+	whereWidth, argsWidth := sqlRange("width", width)
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE "+whereHeight+" AND "+whereWidth+" ORDER BY id",
+		append(args, argsWidth...)...)
+}
+
+func (r *RepositoryVehicleSQLite) GetByWeight(weight domain.Range) ([]*domain.Vehicle, error) {
+	where, args := sqlRange("weight", weight)
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE "+where+" ORDER BY id", args...)
+}
+
+func (r *RepositoryVehicleSQLite) GetByColorAndYear(color string, year int) ([]*domain.Vehicle, error) {
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE color = ? COLLATE NOCASE AND year = ? ORDER BY id", color, year)
+}
+
+func (r *RepositoryVehicleSQLite) GetByBrand(brand string) ([]*domain.Vehicle, error) {
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE brand = ? COLLATE NOCASE ORDER BY id", brand)
+}
+
+// Search returns the vehicles that match a full-text query, sorted by relevance.
+// As the database has no fuzzy matching, the vehicles are indexed in memory, and the index is kept
+// until they are written by the repository or by another process.
+func (r *RepositoryVehicleSQLite) Search(query string) ([]domain.SearchResult, error) {
+	c := r.search
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if err := r.searchIndex(); err != nil {
+		return nil, err
+	}
+	hits := c.ix.Search(query)
+	if len(hits) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
+	}
+	results := make([]domain.SearchResult, 0, len(hits))
+	for _, h := range hits {
+		// the stored vehicles are shared by the searches, the results are copies
+		v := *c.vehicles[h.Id]
+		results = append(results, domain.SearchResult{Vehicle: &v, Score: h.Score})
+	}
+	return results, nil
+}
+
+// searchIndex builds the full-text index of the vehicles unless the one built is current, with its lock held.
+func (r *RepositoryVehicleSQLite) searchIndex() error {
+	c := r.search
+	// the writes are counted after they run, so an index built after reading the count is at least as new
+	writes := c.written.Load()
+	var dataVersion int64
+	if err := r.conn().QueryRow("PRAGMA data_version").Scan(&dataVersion); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	if c.built && c.writes == writes && c.dataVersion == dataVersion && r.tx == nil {
+		return nil
+	}
+
+	vehicles, err := r.GetAll()
+	if err != nil && !errors.Is(err, ErrRepositoryVehicleNotFound) {
+		return err
+	}
+	c.ix = search.NewIndex()
+	c.vehicles = make(map[int]*domain.Vehicle, len(vehicles))
+	for _, v := range vehicles {
+		c.vehicles[v.Id] = v
+		c.ix.Add(v.Id, &v.Attributes)
+	}
+	// the index of a transaction may have writes that are rolled back
+	c.built = r.tx == nil
+	c.writes, c.dataVersion = writes, dataVersion
+	return nil
+}
+
+// written invalidates the full-text index, once a write ran.
+func (r *RepositoryVehicleSQLite) written() {
+	r.search.written.Add(1)
+}
+
+// Suggest returns up to limit distinct values of the brand, color or model (of the brand) that start with the prefix
+func (r *RepositoryVehicleSQLite) Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error) {
+	// LIKE ignores the case of ascii letters, its wildcards in the prefix are escaped
+	where := string(field) + " LIKE ? ESCAPE '\\'"
+	args := []any{strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(prefix) + "%"}
+	switch field {
+	case domain.VehicleFieldBrand, domain.VehicleFieldColor:
+	case domain.VehicleFieldModel:
+		where += " AND brand = ? COLLATE NOCASE"
+		args = append(args, brand)
+	default:
+		return nil, fmt.Errorf("%w. no suggestions for %q", ErrRepositoryVehicleInternal, field)
+	}
+	if limit <= 0 {
+		limit = -1
+	}
+	args = append(args, limit)
+
+	rows, err := r.conn().Query("SELECT "+string(field)+", COUNT(*) FROM vehicles WHERE "+where+
+		" GROUP BY "+string(field)+" ORDER BY COUNT(*) DESC, "+string(field)+" LIMIT ?", args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	defer rows.Close()
+
+	suggestions := make([]domain.Suggestion, 0)
+	for rows.Next() {
+		var s domain.Suggestion
+		if err := rows.Scan(&s.Value, &s.Count); err != nil {
+			return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+		suggestions = append(suggestions, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	return suggestions, nil
+}
+
+func (r *RepositoryVehicleSQLite) GetByTransmission(transmission string) ([]*domain.Vehicle, error) {
+	return r.query("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE transmission = ? COLLATE NOCASE ORDER BY id", transmission)
+}
+
+func (r *RepositoryVehicleSQLite) PatchFuel(id int, fuelType string, version int) error {
+	return r.execVersion(id, version, "UPDATE vehicles SET fuel_type = ?, version = version + 1, updated_at = ? WHERE id = ?", fuelType, nowSQLite(), id)
+}
+
+func (r *RepositoryVehicleSQLite) Put(vehicle *domain.Vehicle) error {
+	return r.execVersion(vehicle.Id, vehicle.Version, updateVehicleSQLite, updateVehicleSQLiteArgs(vehicle.Id, &vehicle.Attributes, nowSQLite())...)
+}
+
+// Update changes the attributes of the vehicle with update when its version is the given one, any if it is 0.
+// The vehicle is read and written in a transaction, and only written if its version didn't change in between.
+func (r *RepositoryVehicleSQLite) Update(id int, version int, update func(a *domain.VehicleAttributes) error) (v *domain.Vehicle, err error) {
+	err = r.transaction(func(rt *RepositoryVehicleSQLite) error {
+		v, err = rt.update(id, version, update)
+		return err
+	})
+	return
+}
+
+// update changes the attributes of the vehicle, that must run in a transaction.
+func (r *RepositoryVehicleSQLite) update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error) {
+	v, err := scanVehicleSQLite(r.tx.QueryRow("SELECT "+columnsVehicleSQLite+" FROM vehicles WHERE id = ?", id))
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return nil, ErrRepositoryVehicleNotFound
+	case err != nil:
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	if version != 0 && v.Version != version {
+		return nil, fmt.Errorf("%w. vehicle %d has version %d, not %d", ErrRepositoryVehicleVersionConflict, id, v.Version, version)
+	}
+	if err = update(&v.Attributes); err != nil {
+		return nil, err
+	}
+
+	now := nowSQLite()
+	res, err := r.tx.Exec(updateVehicleSQLite+" AND version = ?", append(updateVehicleSQLiteArgs(id, &v.Attributes, now), v.Version)...)
+	r.written()
+	if err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	if affected == 0 {
+		return nil, fmt.Errorf("%w. vehicle %d changed while it was updated", ErrRepositoryVehicleVersionConflict, id)
+	}
+	v.Version++
+	v.UpdatedAt = time.UnixMicro(now).UTC()
+	return v, nil
+}
+
+// Transaction runs fn with the statements of a sql transaction, committed when fn returns nil.
+// The transaction takes the write lock of the database when it begins, and holds the only connection of
+// the repository, so no other write is applied while fn runs and its reads stay current.
+func (r *RepositoryVehicleSQLite) Transaction(fn func(tx RepositoryVehicleTx) error) error {
+	return r.transaction(func(rt *RepositoryVehicleSQLite) error {
+		return fn(rt)
+	})
+}
+
+// transaction runs fn with a copy of the repository whose statements run in a transaction, committed when fn
+// returns nil and rolled back otherwise. Within a transaction, fn runs with the repository itself.
+func (r *RepositoryVehicleSQLite) transaction(fn func(rt *RepositoryVehicleSQLite) error) error {
+	if r.tx != nil {
+		return fn(r)
+	}
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	defer tx.Rollback()
+
+	if err = fn(&RepositoryVehicleSQLite{db: r.db, tx: tx, search: r.search}); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	return nil
+}
+
+// conn returns the transaction of the repository, or its database when it has none.
+func (r *RepositoryVehicleSQLite) conn() interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+} {
+	if r.tx != nil {
+		return r.tx
+	}
+	return r.db
+}
+
+func (r *RepositoryVehicleSQLite) Delete(id int, version int) error {
+	return r.execVersion(id, version, "DELETE FROM vehicles WHERE id = ?", id)
+}
+
+func (r *RepositoryVehicleSQLite) Post(vehicle *domain.Vehicle) error {
+	// the primary key conflict is resolved as a no-op so it can be told apart from other failures
+	res, err := r.conn().Exec("INSERT INTO vehicles ("+columnsVehicleSQLite+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 1, ?) ON CONFLICT (id) DO NOTHING",
+		vehicleSQLiteArgs(vehicle.Id, &vehicle.Attributes)...)
+	r.written()
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	if affected == 0 {
+		return ErrRepositoryIdInUse
+	}
+	return nil
+}
+
+// query runs a select over the vehicles table, returning ErrRepositoryVehicleNotFound when no row matches.
+func (r *RepositoryVehicleSQLite) query(query string, args ...any) ([]*domain.Vehicle, error) {
+	rows, err := r.conn().Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	defer rows.Close()
+
+	vehicles := make([]*domain.Vehicle, 0)
+	for rows.Next() {
+		v, err := scanVehicleSQLite(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+		vehicles = append(vehicles, v)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+
+	if len(vehicles) == 0 {
+		return nil, ErrRepositoryVehicleNotFound
+	}
+	return vehicles, nil
+}
+
+// exec runs a statement over a single vehicle, returning ErrRepositoryVehicleNotFound when no row is affected.
+func (r *RepositoryVehicleSQLite) exec(query string, args ...any) error {
+	res, err := r.conn().Exec(query, args...)
+	r.written()
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	if affected == 0 {
+		return ErrRepositoryVehicleNotFound
+	}
+	return nil
+}
+
+// execVersion runs a statement over the vehicle with the id, whose where clause ends with the condition on the id,
+// only when the vehicle has the version, unless it is 0. It returns ErrRepositoryVehicleNotFound when there is no
+// vehicle and ErrRepositoryVehicleVersionConflict when it has another version.
+func (r *RepositoryVehicleSQLite) execVersion(id int, version int, query string, args ...any) error {
+	if version != 0 {
+		query += " AND version = ?"
+		args = append(args, version)
+	}
+	err := r.exec(query, args...)
+	if version == 0 || !errors.Is(err, ErrRepositoryVehicleNotFound) {
+		return err
+	}
+
+	// no row was affected, either because there is no vehicle or because it has another version
+	var stored int
+	switch err := r.conn().QueryRow("SELECT version FROM vehicles WHERE id = ?", id).Scan(&stored); {
+	case errors.Is(err, sql.ErrNoRows):
+		return ErrRepositoryVehicleNotFound
+	case err != nil:
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	return fmt.Errorf("%w. vehicle %d has version %d, not %d", ErrRepositoryVehicleVersionConflict, id, stored, version)
+}
+
+// sqlRange returns the sql condition of the column being in the range and its arguments.
+func sqlRange(column string, r domain.Range) (where string, args []any) {
+	conditions := []string{"1 = 1"}
+	if !r.Min.Unbounded {
+		op := ">"
+		if r.Min.Inclusive {
+			op = ">="
+		}
+		conditions = append(conditions, column+" "+op+" ?")
+		args = append(args, r.Min.Value)
+	}
+	if !r.Max.Unbounded {
+		op := "<"
+		if r.Max.Inclusive {
+			op = "<="
+		}
+		conditions = append(conditions, column+" "+op+" ?")
+		args = append(args, r.Max.Value)
+	}
+	where = "(" + strings.Join(conditions, " AND ") + ")"
+	return
+}
+
+// vehicleSQLiteArgs returns the arguments of a new vehicle in the order of columnsVehicleSQLite, without the
+// version, that is always 1.
+func vehicleSQLiteArgs(id int, a *domain.VehicleAttributes) []any {
+	return []any{id, a.Brand, a.Model, a.Registration, a.Year, a.Color, a.MaxSpeed,
+		a.FuelType, a.Transmission, a.Passengers, a.Height, a.Width, a.Weight, nowSQLite()}
+}
+
+// scanVehicleSQLite scans a row with the columnsVehicleSQLite of a vehicle.
+func scanVehicleSQLite(row interface{ Scan(dest ...any) error }) (*domain.Vehicle, error) {
+	var v domain.Vehicle
+	var updatedAt int64
+	a := &v.Attributes
+	err := row.Scan(&v.Id, &a.Brand, &a.Model, &a.Registration, &a.Year, &a.Color, &a.MaxSpeed,
+		&a.FuelType, &a.Transmission, &a.Passengers, &a.Height, &a.Width, &a.Weight, &v.Version, &updatedAt)
+	if err != nil {
+		return nil, err
+	}
+	v.UpdatedAt = time.UnixMicro(updatedAt).UTC()
+	return &v, nil
+}
+
+// updateVehicleSQLite replaces the attributes of a vehicle, increasing its version.
+const updateVehicleSQLite = "UPDATE vehicles SET brand = ?, model = ?, registration = ?, year = ?, color = ?, max_speed = ?, fuel_type = ?, transmission = ?, passengers = ?, height = ?, width = ?, weight = ?, version = version + 1, updated_at = ? WHERE id = ?"
+
+// updateVehicleSQLiteArgs returns the arguments of updateVehicleSQLite.
+func updateVehicleSQLiteArgs(id int, a *domain.VehicleAttributes, updatedAt int64) []any {
+	return []any{a.Brand, a.Model, a.Registration, a.Year, a.Color, a.MaxSpeed, a.FuelType, a.Transmission,
+		a.Passengers, a.Height, a.Width, a.Weight, updatedAt, id}
+}
+
+// nowSQLite returns the current time as stored in the updated_at column, in microseconds since the epoch.
+func nowSQLite() int64 {
+	return time.Now().UnixMicro()
+}
@@ -0,0 +1,461 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/wal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newSQLiteTest returns a repository over a new database file with the schema, seeded with db.
+func newSQLiteTest(t *testing.T, db map[int]*domain.VehicleAttributes) *RepositoryVehicleSQLite {
+	t.Helper()
+	conn, err := sql.Open("sqlite3", DSNVehicleSQLite(filepath.Join(t.TempDir(), "vehicles.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	rp := NewRepositoryVehicleSQLite(conn)
+	if err := rp.CreateSchema(); err != nil {
+		t.Fatal(err)
+	}
+	if db != nil {
+		if _, err := rp.Seed(db); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return rp
+}
+
+// TestRepositoryVehicleSQLite_Queries checks the queries return the same vehicles as the in-memory repository.
+func TestRepositoryVehicleSQLite_Queries(t *testing.T) {
+	db := newVehiclesTest(300, 1)
+	rpSQLite := newSQLiteTest(t, db)
+	rpMem := NewRepositoryVehicleInMemory(db)
+	a := db[7]
+
+	tests := []struct {
+		name  string
+		query func(rp RepositoryVehicle) ([]*domain.Vehicle, error)
+	}{
+		{name: "GetAll", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) { return rp.GetAll() }},
+		{name: "GetByBrand", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByBrand(strings.ToUpper(a.Brand))
+		}},
+		{name: "GetByTransmission", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByTransmission(strings.ToUpper(a.Transmission))
+		}},
+		{name: "GetByColorAndYear", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByColorAndYear(strings.ToLower(a.Color), a.Year)
+		}},
+		{name: "GetByColorAndYear without vehicles", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByColorAndYear("Transparent", a.Year)
+		}},
+		{name: "GetByWeight inclusive", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByWeight(rangeTest(a.Weight-100, a.Weight+100))
+		}},
+		{name: "GetByWeight exclusive", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByWeight(domain.Range{Min: domain.RangeBound{Value: a.Weight}, Max: domain.RangeBound{Unbounded: true}})
+		}},
+		{name: "GetByDimensions", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByDimensions(rangeTest(a.Height-30, a.Height+30), domain.Range{Min: domain.RangeBound{Unbounded: true}, Max: domain.RangeBound{Value: a.Width, Inclusive: true}})
+		}},
+		{name: "GetByFilter", query: func(rp RepositoryVehicle) ([]*domain.Vehicle, error) {
+			return rp.GetByFilter(domain.FilterOr{Filters: []domain.Filter{
+				domain.FilterCondition{Field: domain.VehicleFieldBrand, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: strings.ToLower(a.Brand)}}},
+				domain.FilterNot{Filter: domain.FilterCondition{Field: domain.VehicleFieldYear, Operator: domain.FilterOperatorLt, Values: []domain.FilterValue{{Number: 2015}}}},
+			}})
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want, errWant := tt.query(rpMem)
+			got, err := tt.query(rpSQLite)
+			if !errors.Is(err, errWant) {
+				t.Fatalf("the query returned %v, want %v", err, errWant)
+			}
+			if !reflect.DeepEqual(idsTest(got), idsTest(want)) {
+				t.Fatalf("the query returned %v, want %v", idsTest(got), idsTest(want))
+			}
+			for i := range got {
+				if got[i].Attributes != want[i].Attributes {
+					t.Errorf("vehicle %d is %+v, want %+v", got[i].Id, got[i].Attributes, want[i].Attributes)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryVehicleSQLite_Writes(t *testing.T) {
+	db := newVehiclesTest(10, 2)
+	changed := *db[1]
+	changed.Brand = "Changed"
+
+	tests := []struct {
+		name  string
+		write func(rp *RepositoryVehicleSQLite) error
+		err   error
+		// id is the vehicle checked afterwards, want its attributes, nil when it must not exist.
+		id   int
+		want *domain.VehicleAttributes
+	}{
+		{name: "post", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Post(&domain.Vehicle{Id: 11, Attributes: changed})
+		}, id: 11, want: &changed},
+		{name: "post of an id in use", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Post(&domain.Vehicle{Id: 1, Attributes: changed})
+		}, err: ErrRepositoryIdInUse, id: 1, want: db[1]},
+		{name: "put", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Put(&domain.Vehicle{Id: 1, Attributes: changed})
+		}, id: 1, want: &changed},
+		{name: "put of a missing vehicle", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Put(&domain.Vehicle{Id: 99, Attributes: changed})
+		}, err: ErrRepositoryVehicleNotFound, id: 99},
+		{name: "patch fuel", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.PatchFuel(1, "biodiesel", 0)
+		}, id: 1, want: func() *domain.VehicleAttributes { a := *db[1]; a.FuelType = "biodiesel"; return &a }()},
+		{name: "patch fuel of a missing vehicle", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.PatchFuel(99, "biodiesel", 0)
+		}, err: ErrRepositoryVehicleNotFound, id: 99},
+		{name: "delete", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Delete(1, 0)
+		}, id: 1},
+		{name: "delete of a missing vehicle", write: func(rp *RepositoryVehicleSQLite) error {
+			return rp.Delete(99, 0)
+		}, err: ErrRepositoryVehicleNotFound, id: 99},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp := newSQLiteTest(t, db)
+			if err := tt.write(rp); !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("the write returned %v, want %v", err, tt.err)
+			}
+
+			v, err := rp.GetById(tt.id)
+			if tt.want == nil {
+				if !errors.Is(err, ErrRepositoryVehicleNotFound) {
+					t.Fatalf("GetById returned %v, want %v", err, ErrRepositoryVehicleNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Attributes != *tt.want {
+				t.Errorf("vehicle %d is %+v, want %+v", tt.id, v.Attributes, *tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryVehicleSQLite_Seed(t *testing.T) {
+	rp := newSQLiteTest(t, nil)
+	if _, err := rp.GetAll(); !errors.Is(err, ErrRepositoryVehicleNotFound) {
+		t.Fatalf("GetAll of an empty database returned %v, want %v", err, ErrRepositoryVehicleNotFound)
+	}
+
+	// the seed is only applied to an empty database
+	for i, want := range []bool{true, false} {
+		seeded, err := rp.Seed(newVehiclesTest(5+i, int64(i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seeded != want {
+			t.Errorf("seed %d returned %t, want %t", i, seeded, want)
+		}
+	}
+	vehicles, err := rp.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vehicles) != 5 {
+		t.Errorf("the database has %d vehicles, want the 5 of the first seed", len(vehicles))
+	}
+}
+
+// TestRepositoryVehicle_Suggest checks the suggestions of both repositories, which follow the writes.
+func TestRepositoryVehicle_Suggest(t *testing.T) {
+	db := map[int]*domain.VehicleAttributes{
+		1: {Brand: "Ford", Model: "Fiesta", Color: "Red"},
+		2: {Brand: "Ford", Model: "Focus", Color: "Red"},
+		3: {Brand: "Ford", Model: "Focus", Color: "Blue"},
+		4: {Brand: "Fiat", Model: "Panda", Color: "Red_x"},
+		5: {Brand: "Toyota", Model: "Corolla", Color: "Redx"},
+	}
+	// writes move a vehicle to another brand, delete one and post one of a new brand
+	writes := func(rp RepositoryVehicle) error {
+		if err := rp.Put(&domain.Vehicle{Id: 3, Attributes: domain.VehicleAttributes{Brand: "Toyota", Model: "Corolla", Color: "Blue"}}); err != nil {
+			return err
+		}
+		if err := rp.Delete(1, 0); err != nil {
+			return err
+		}
+		return rp.Post(&domain.Vehicle{Id: 6, Attributes: domain.VehicleAttributes{Brand: "Seat", Model: "Ibiza", Color: "Red"}})
+	}
+
+	tests := []struct {
+		name   string
+		writes bool
+		field  domain.VehicleField
+		brand  string
+		prefix string
+		limit  int
+		want   []domain.Suggestion
+		err    error
+	}{
+		{name: "brands", field: domain.VehicleFieldBrand, prefix: "f", want: []domain.Suggestion{{Value: "Ford", Count: 3}, {Value: "Fiat", Count: 1}}},
+		{name: "limit", field: domain.VehicleFieldBrand, prefix: "F", limit: 1, want: []domain.Suggestion{{Value: "Ford", Count: 3}}},
+		{name: "no prefix", field: domain.VehicleFieldBrand, want: []domain.Suggestion{{Value: "Ford", Count: 3}, {Value: "Fiat", Count: 1}, {Value: "Toyota", Count: 1}}},
+		{name: "colors", field: domain.VehicleFieldColor, prefix: "RED", want: []domain.Suggestion{{Value: "Red", Count: 2}, {Value: "Red_x", Count: 1}, {Value: "Redx", Count: 1}}},
+		// the wildcards of LIKE match themselves
+		{name: "prefix with an underscore", field: domain.VehicleFieldColor, prefix: "red_", want: []domain.Suggestion{{Value: "Red_x", Count: 1}}},
+		{name: "prefix with a percent", field: domain.VehicleFieldColor, prefix: "red%", want: []domain.Suggestion{}},
+		{name: "models", field: domain.VehicleFieldModel, brand: "FORD", prefix: "f", want: []domain.Suggestion{{Value: "Focus", Count: 2}, {Value: "Fiesta", Count: 1}}},
+		{name: "models of a brand with no vehicles", field: domain.VehicleFieldModel, brand: "Seat", want: []domain.Suggestion{}},
+		{name: "field without suggestions", field: domain.VehicleFieldYear, err: ErrRepositoryVehicleInternal},
+		{name: "brands after the writes", writes: true, field: domain.VehicleFieldBrand,
+			want: []domain.Suggestion{{Value: "Toyota", Count: 2}, {Value: "Fiat", Count: 1}, {Value: "Ford", Count: 1}, {Value: "Seat", Count: 1}}},
+		{name: "models after the writes", writes: true, field: domain.VehicleFieldModel, brand: "ford", want: []domain.Suggestion{{Value: "Focus", Count: 1}}},
+		{name: "colors after the writes", writes: true, field: domain.VehicleFieldColor, prefix: "b", want: []domain.Suggestion{{Value: "Blue", Count: 1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repositories := map[string]RepositoryVehicle{
+				"in memory": NewRepositoryVehicleInMemory(copyVehiclesTest(db)),
+				"sqlite":    newSQLiteTest(t, db),
+			}
+			for name, rp := range repositories {
+				if tt.writes {
+					if err := writes(rp); err != nil {
+						t.Fatalf("%s: %v", name, err)
+					}
+				}
+				suggestions, err := rp.Suggest(tt.field, tt.brand, tt.prefix, tt.limit)
+				if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+					t.Fatalf("%s: Suggest returned %v, want %v", name, err, tt.err)
+				}
+				if err == nil && !reflect.DeepEqual(suggestions, tt.want) {
+					t.Errorf("%s: Suggest returned %v, want %v", name, suggestions, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestRepositoryVehicle_Versions checks every repository increases the version of a vehicle on every write and
+// rejects the writes that expect another version.
+func TestRepositoryVehicle_Versions(t *testing.T) {
+	db := newVehiclesTest(3, 3)
+	changed := *db[1]
+	changed.Brand = "Changed"
+
+	// the steps run in order over the same vehicle
+	steps := []struct {
+		name  string
+		write func(rp RepositoryVehicle) error
+		err   error
+		// version is the version of vehicle 1 after the step, 0 when it must not exist.
+		version int
+	}{
+		{name: "patch fuel of the version", write: func(rp RepositoryVehicle) error {
+			return rp.PatchFuel(1, "diesel", 1)
+		}, version: 2},
+		{name: "patch fuel of a stale version", write: func(rp RepositoryVehicle) error {
+			return rp.PatchFuel(1, "gas", 1)
+		}, err: ErrRepositoryVehicleVersionConflict, version: 2},
+		{name: "put of the version", write: func(rp RepositoryVehicle) error {
+			return rp.Put(&domain.Vehicle{Id: 1, Version: 2, Attributes: changed})
+		}, version: 3},
+		{name: "put of a stale version", write: func(rp RepositoryVehicle) error {
+			return rp.Put(&domain.Vehicle{Id: 1, Version: 2, Attributes: *db[1]})
+		}, err: ErrRepositoryVehicleVersionConflict, version: 3},
+		{name: "update of the version", write: func(rp RepositoryVehicle) error {
+			_, err := rp.Update(1, 3, func(a *domain.VehicleAttributes) error {
+				a.Color = "Changed"
+				return nil
+			})
+			return err
+		}, version: 4},
+		{name: "update of a stale version", write: func(rp RepositoryVehicle) error {
+			_, err := rp.Update(1, 3, func(a *domain.VehicleAttributes) error { return nil })
+			return err
+		}, err: ErrRepositoryVehicleVersionConflict, version: 4},
+		{name: "put of any version", write: func(rp RepositoryVehicle) error {
+			return rp.Put(&domain.Vehicle{Id: 1, Attributes: changed})
+		}, version: 5},
+		{name: "write of a missing vehicle", write: func(rp RepositoryVehicle) error {
+			return rp.PatchFuel(99, "gas", 1)
+		}, err: ErrRepositoryVehicleNotFound, version: 5},
+		{name: "delete of a stale version", write: func(rp RepositoryVehicle) error {
+			return rp.Delete(1, 4)
+		}, err: ErrRepositoryVehicleVersionConflict, version: 5},
+		{name: "delete of the version", write: func(rp RepositoryVehicle) error {
+			return rp.Delete(1, 5)
+		}},
+	}
+
+	lg, _, err := wal.Open(filepath.Join(t.TempDir(), "vehicles.wal"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Close()
+	repositories := []struct {
+		name string
+		rp   RepositoryVehicle
+	}{
+		{name: "in memory", rp: NewRepositoryVehicleInMemory(copyVehiclesTest(db))},
+		{name: "sqlite", rp: newSQLiteTest(t, db)},
+		{name: "wal", rp: NewRepositoryVehicleWAL(NewRepositoryVehicleInMemory(copyVehiclesTest(db)), lg)},
+	}
+	for _, r := range repositories {
+		t.Run(r.name, func(t *testing.T) {
+			v, err := r.rp.GetById(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.Version != 1 || v.UpdatedAt.IsZero() {
+				t.Fatalf("the first version is %d updated at %v, want 1 with an update time", v.Version, v.UpdatedAt)
+			}
+			for _, s := range steps {
+				if err := s.write(r.rp); !errors.Is(err, s.err) || (s.err == nil && err != nil) {
+					t.Fatalf("%s: the write returned %v, want %v", s.name, err, s.err)
+				}
+				v, err := r.rp.GetById(1)
+				if s.version == 0 {
+					if !errors.Is(err, ErrRepositoryVehicleNotFound) {
+						t.Fatalf("%s: GetById returned %v, want %v", s.name, err, ErrRepositoryVehicleNotFound)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("%s: %v", s.name, err)
+				}
+				if v.Version != s.version {
+					t.Fatalf("%s: the version is %d, want %d", s.name, v.Version, s.version)
+				}
+			}
+		})
+	}
+}
+
+func TestRepositoryVehicleSQLite_CreateSchema(t *testing.T) {
+	conn, err := sql.Open("sqlite3", DSNVehicleSQLite(filepath.Join(t.TempDir(), "vehicles.db")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// a table of the schema before versions, with a vehicle
+	_, err = conn.Exec(`CREATE TABLE vehicles (id INTEGER PRIMARY KEY, brand TEXT NOT NULL, model TEXT NOT NULL,
+		registration TEXT NOT NULL, year INTEGER NOT NULL, color TEXT NOT NULL, max_speed INTEGER NOT NULL,
+		fuel_type TEXT NOT NULL, transmission TEXT NOT NULL, passengers INTEGER NOT NULL, height REAL NOT NULL,
+		width REAL NOT NULL, weight REAL NOT NULL);
+		INSERT INTO vehicles VALUES (1, 'Ford', 'Fiesta', 'AA100', 2001, 'Red', 180, 'gasoline', 'manual', 5, 150, 170, 1100);`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp := NewRepositoryVehicleSQLite(conn)
+	// the migration is applied once, so creating the schema again changes nothing
+	for i := 0; i < 2; i++ {
+		if err := rp.CreateSchema(); err != nil {
+			t.Fatalf("CreateSchema %d returned %v", i, err)
+		}
+	}
+	v, err := rp.GetById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.Version != 1 || v.UpdatedAt.IsZero() || v.Attributes.Brand != "Ford" {
+		t.Fatalf("the migrated vehicle is %+v, want version 1 with an update time", v)
+	}
+	if err := rp.PatchFuel(1, "diesel", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := rp.PatchFuel(1, "gas", 1); !errors.Is(err, ErrRepositoryVehicleVersionConflict) {
+		t.Errorf("PatchFuel of a stale version returned %v, want %v", err, ErrRepositoryVehicleVersionConflict)
+	}
+}
+
+// TestRepositoryVehicle_Rollback checks the writes of a transaction that fails, as a bulk dry run does,
+// leave every repository as it was, also when the log of the writes is replayed.
+func TestRepositoryVehicle_Rollback(t *testing.T) {
+	db := newVehiclesTest(10, 4)
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	lg, _, err := wal.Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Close()
+
+	repositories := []struct {
+		name string
+		rp   RepositoryVehicle
+	}{
+		{name: "in memory", rp: NewRepositoryVehicleInMemory(copyVehiclesTest(db))},
+		{name: "sqlite", rp: newSQLiteTest(t, db)},
+		{name: "wal", rp: NewRepositoryVehicleWAL(NewRepositoryVehicleInMemory(copyVehiclesTest(db)), lg)},
+	}
+	for _, r := range repositories {
+		t.Run(r.name, func(t *testing.T) {
+			want, err := r.rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = r.rp.Transaction(func(tx RepositoryVehicleTx) error {
+				for _, id := range []int{1, 2} {
+					if err := tx.Delete(id, 0); err != nil {
+						return err
+					}
+				}
+				if _, err := tx.Update(3, 0, func(a *domain.VehicleAttributes) error {
+					a.Brand = "Changed"
+					return nil
+				}); err != nil {
+					return err
+				}
+				if err := tx.Post(&domain.Vehicle{Id: 11, Attributes: *db[4]}); err != nil {
+					return err
+				}
+				// the transaction reads its own writes
+				if _, err := tx.GetById(1); !errors.Is(err, ErrRepositoryVehicleNotFound) {
+					t.Errorf("GetById of a vehicle deleted in the transaction returned %v", err)
+				}
+				return errRollbackTest
+			})
+			if !errors.Is(err, errRollbackTest) {
+				t.Fatalf("Transaction returned %v, want %v", err, errRollbackTest)
+			}
+
+			got, err := r.rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("the rolled back transaction left %d vehicles, want the %d before it", len(got), len(want))
+			}
+		})
+	}
+
+	// the writes of the rolled back transaction are discarded from the log
+	lg.Close()
+	lg, records, err := wal.Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lg.Close()
+	rp := NewRepositoryVehicleWAL(NewRepositoryVehicleInMemory(copyVehiclesTest(db)), lg)
+	if err := rp.Replay(records); err != nil {
+		t.Fatal(err)
+	}
+	if got := contentTest(t, rp); len(got) != len(db) || got[3].Brand != db[3].Brand {
+		t.Errorf("the replay of the log has %d vehicles and vehicle 3 is %+v", len(got), got[3])
+	}
+}
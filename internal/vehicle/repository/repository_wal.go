@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/wal"
+	"sync"
+)
+
+// NewRepositoryVehicleWAL returns a new instance of a vehicle storage that records every mutation
+// of rp in the write-ahead log lg.
+func NewRepositoryVehicleWAL(rp RepositoryVehicle, lg *wal.Log) *RepositoryVehicleWAL {
+	return &RepositoryVehicleWAL{rp: rp, lg: lg}
+}
+
+// RepositoryVehicleWAL is an struct that decorates a vehicle storage with a write-ahead log.
+// Every mutation runs in a transaction of the decorated storage and is written to the log once the storage
+// accepts it, before it is committed, so the log only has the mutations that were acknowledged or were about
+// to be. The log is synced to disk after the commit, before the mutation is acknowledged, so the reads of the
+// storage don't wait for the disk; a mutation whose sync fails is reported as failed, though it is applied.
+// A record whose commit fails is marked as aborted, so replaying the log over the same seed rebuilds the storage.
+// The content can't be replaced while the log has mutations that aren't compacted into a snapshot.
+type RepositoryVehicleWAL struct {
+	// mu serializes the mutations so they are logged in the order they are applied, and guards pending.
+	mu sync.Mutex
+	// rp is the decorated storage.
+	rp RepositoryVehicle
+	// lg is the write-ahead log.
+	lg *wal.Log
+	// pending tells whether the log has committed mutations that aren't in a snapshot.
+	pending bool
+}
+
+// Replay applies the committed records of the log to the decorated storage.
+// Replaying is idempotent, so the records that are already part of the storage, as left by a crash between the
+// snapshot and the reset of Compact, rebuild the same content: posts and puts store the vehicle whether it
+// exists or not, and deletes and fuel patches of a missing vehicle are skipped.
+func (r *RepositoryVehicleWAL) Replay(records []wal.Record) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, rc := range wal.Committed(records) {
+		r.pending = true
+		var err error
+		switch rc.Op {
+		case wal.OperationPatchFuel:
+			if err = r.rp.PatchFuel(rc.Id, rc.FuelType, 0); errors.Is(err, ErrRepositoryVehicleNotFound) {
+				err = nil
+			}
+		case wal.OperationTx:
+			err = r.rp.Transaction(func(tx RepositoryVehicleTx) error {
+				for _, sub := range rc.Records {
+					if err := replayRecord(tx, sub); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		default:
+			err = replayRecord(r.rp, rc)
+		}
+		if err != nil {
+			return fmt.Errorf("%w. replaying record %d: %v", ErrRepositoryVehicleInternal, rc.Seq, err)
+		}
+	}
+	return nil
+}
+
+// replayRecord applies a post, put or delete record with the writes of w, whether the vehicle is stored or not.
+func replayRecord(w RepositoryVehicleTx, rc wal.Record) (err error) {
+	switch rc.Op {
+	case wal.OperationPost, wal.OperationPut:
+		v := &domain.Vehicle{Id: rc.Id, Attributes: *rc.Attributes}
+		if err = w.Put(v); errors.Is(err, ErrRepositoryVehicleNotFound) {
+			err = w.Post(v)
+		}
+	case wal.OperationDelete:
+		if err = w.Delete(rc.Id, 0); errors.Is(err, ErrRepositoryVehicleNotFound) {
+			err = nil
+		}
+	default:
+		err = fmt.Errorf("%w. unknown operation %q", ErrRepositoryVehicleInternal, rc.Op)
+	}
+	return
+}
+
+// Compact saves a snapshot of the decorated storage and empties the log, as its records are part of the snapshot.
+// No mutation is applied while the snapshot is taken.
+func (r *RepositoryVehicleWAL) Compact(snapshot func(rp RepositoryVehicle) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := snapshot(r.rp); err != nil {
+		return err
+	}
+	if err := r.lg.Reset(); err != nil {
+		return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+	}
+	r.pending = false
+	return nil
+}
+
+// Replace swaps the content of the decorated storage, which must be a RepositoryVehicleReplacer,
+// and empties the log, as db is the new base the log is replayed over.
+// It fails with ErrRepositoryVehicleUnpersisted while the log has mutations that aren't compacted,
+// as they are acknowledged and db doesn't have them.
+func (r *RepositoryVehicleWAL) Replace(db map[int]*domain.VehicleAttributes) (diff VehiclesDiff, err error) {
+	rp, ok := r.rp.(RepositoryVehicleReplacer)
+	if !ok {
+		err = fmt.Errorf("%w. the storage can't be replaced", ErrRepositoryVehicleInternal)
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.pending {
+		err = fmt.Errorf("%w. the log has mutations that aren't compacted", ErrRepositoryVehicleUnpersisted)
+		return
+	}
+	if diff, err = rp.Replace(db); err != nil {
+		return
+	}
+	if err = r.lg.Reset(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		return
+	}
+	return
+}
+
+// GetAll returns all vehicles
+func (r *RepositoryVehicleWAL) GetAll() (v []*domain.Vehicle, err error) {
+	return r.rp.GetAll()
+}
+
+func (r *RepositoryVehicleWAL) GetByFilter(f domain.Filter) (v []*domain.Vehicle, err error) {
+	return r.rp.GetByFilter(f)
+}
+
+func (r *RepositoryVehicleWAL) GetById(id int) (*domain.Vehicle, error) {
+	return r.rp.GetById(id)
+}
+
+func (r *RepositoryVehicleWAL) GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error) {
+	return r.rp.GetByDimensions(height, width)
+}
+
+func (r *RepositoryVehicleWAL) GetByColorAndYear(color string, year int) ([]*domain.Vehicle, error) {
+	return r.rp.GetByColorAndYear(color, year)
+}
+
+func (r *RepositoryVehicleWAL) GetByWeight(weight domain.Range) ([]*domain.Vehicle, error) {
+	return r.rp.GetByWeight(weight)
+}
+
+func (r *RepositoryVehicleWAL) GetByBrand(brand string) ([]*domain.Vehicle, error) {
+	return r.rp.GetByBrand(brand)
+}
+
+func (r *RepositoryVehicleWAL) Search(query string) ([]domain.SearchResult, error) {
+	return r.rp.Search(query)
+}
+
+func (r *RepositoryVehicleWAL) Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error) {
+	return r.rp.Suggest(field, brand, prefix, limit)
+}
+
+func (r *RepositoryVehicleWAL) GetByTransmission(transmission string) ([]*domain.Vehicle, error) {
+	return r.rp.GetByTransmission(transmission)
+}
+
+// PatchFuel logs the change of the fuel type, that is replayed with no version, as only the changes the
+// storage accepted are logged.
+func (r *RepositoryVehicleWAL) PatchFuel(id int, fuelType string, version int) error {
+	return r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		if _, err := tx.Update(id, version, func(a *domain.VehicleAttributes) error {
+			a.FuelType = fuelType
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+		return &wal.Record{Op: wal.OperationPatchFuel, Id: id, FuelType: fuelType}, nil
+	})
+}
+
+func (r *RepositoryVehicleWAL) Put(vehicle *domain.Vehicle) error {
+	return r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		if err := tx.Put(vehicle); err != nil {
+			return nil, err
+		}
+		attributes := vehicle.Attributes
+		return &wal.Record{Op: wal.OperationPut, Id: vehicle.Id, Attributes: &attributes}, nil
+	})
+}
+
+// Update logs the update as a put of the resulting attributes.
+func (r *RepositoryVehicleWAL) Update(id int, version int, update func(a *domain.VehicleAttributes) error) (v *domain.Vehicle, err error) {
+	err = r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		if v, err = tx.Update(id, version, update); err != nil {
+			return nil, err
+		}
+		attributes := v.Attributes
+		return &wal.Record{Op: wal.OperationPut, Id: id, Attributes: &attributes}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func (r *RepositoryVehicleWAL) Delete(id int, version int) error {
+	return r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		if err := tx.Delete(id, version); err != nil {
+			return nil, err
+		}
+		return &wal.Record{Op: wal.OperationDelete, Id: id}, nil
+	})
+}
+
+func (r *RepositoryVehicleWAL) Post(vehicle *domain.Vehicle) error {
+	return r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		if err := tx.Post(vehicle); err != nil {
+			return nil, err
+		}
+		attributes := vehicle.Attributes
+		return &wal.Record{Op: wal.OperationPost, Id: vehicle.Id, Attributes: &attributes}, nil
+	})
+}
+
+// Transaction logs the writes of fn as a single tx record, so they are replayed all or none.
+func (r *RepositoryVehicleWAL) Transaction(fn func(tx RepositoryVehicleTx) error) error {
+	return r.apply(func(tx RepositoryVehicleTx) (*wal.Record, error) {
+		txWAL := &txVehicleWAL{tx: tx}
+		if err := fn(txWAL); err != nil {
+			return nil, err
+		}
+		if len(txWAL.records) == 0 {
+			return nil, nil
+		}
+		return &wal.Record{Op: wal.OperationTx, Records: txWAL.records}, nil
+	})
+}
+
+// txVehicleWAL is a transaction that records its writes that succeed, to log them when it commits.
+type txVehicleWAL struct {
+	tx      RepositoryVehicleTx
+	records []wal.Record
+}
+
+func (t *txVehicleWAL) GetById(id int) (*domain.Vehicle, error) {
+	return t.tx.GetById(id)
+}
+
+func (t *txVehicleWAL) GetByFilter(f domain.Filter) ([]*domain.Vehicle, error) {
+	return t.tx.GetByFilter(f)
+}
+
+func (t *txVehicleWAL) Post(vehicle *domain.Vehicle) error {
+	if err := t.tx.Post(vehicle); err != nil {
+		return err
+	}
+	attributes := vehicle.Attributes
+	t.records = append(t.records, wal.Record{Op: wal.OperationPost, Id: vehicle.Id, Attributes: &attributes})
+	return nil
+}
+
+func (t *txVehicleWAL) Put(vehicle *domain.Vehicle) error {
+	if err := t.tx.Put(vehicle); err != nil {
+		return err
+	}
+	attributes := vehicle.Attributes
+	t.records = append(t.records, wal.Record{Op: wal.OperationPut, Id: vehicle.Id, Attributes: &attributes})
+	return nil
+}
+
+// Update is recorded as a put of the resulting attributes.
+func (t *txVehicleWAL) Update(id int, version int, update func(a *domain.VehicleAttributes) error) (*domain.Vehicle, error) {
+	v, err := t.tx.Update(id, version, update)
+	if err != nil {
+		return nil, err
+	}
+	attributes := v.Attributes
+	t.records = append(t.records, wal.Record{Op: wal.OperationPut, Id: id, Attributes: &attributes})
+	return v, nil
+}
+
+func (t *txVehicleWAL) Delete(id int, version int) error {
+	if err := t.tx.Delete(id, version); err != nil {
+		return err
+	}
+	t.records = append(t.records, wal.Record{Op: wal.OperationDelete, Id: id})
+	return nil
+}
+
+// apply runs the write in a transaction of the decorated storage and logs the record it returns, if any,
+// after the storage accepted the write and before it is committed. The record is aborted when the commit fails,
+// and synced to disk once the commit releases the storage otherwise.
+func (r *RepositoryVehicleWAL) apply(write func(tx RepositoryVehicleTx) (*wal.Record, error)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var seq uint64
+	err := r.rp.Transaction(func(tx RepositoryVehicleTx) error {
+		rc, err := write(tx)
+		if err != nil || rc == nil {
+			return err
+		}
+		s, err := r.lg.Write(*rc)
+		if err != nil {
+			return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+		seq = s
+		return nil
+	})
+	if err == nil && seq != 0 {
+		r.pending = true
+		if err = r.lg.Sync(); err != nil {
+			return fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, err)
+		}
+	}
+	if err != nil && seq != 0 {
+		if _, errAbort := r.lg.Append(wal.Record{Op: wal.OperationAbort, Ref: seq}); errAbort != nil {
+			return errors.Join(err, fmt.Errorf("%w. %v", ErrRepositoryVehicleInternal, errAbort))
+		}
+	}
+	return err
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/wal"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// copyVehiclesTest returns a copy of db, as the in-memory storage keeps the map it is built with.
+func copyVehiclesTest(db map[int]*domain.VehicleAttributes) map[int]*domain.VehicleAttributes {
+	c := make(map[int]*domain.VehicleAttributes, len(db))
+	for id, a := range db {
+		attributes := *a
+		c[id] = &attributes
+	}
+	return c
+}
+
+// contentTest returns the attributes of every vehicle of rp by id.
+func contentTest(t *testing.T, rp RepositoryVehicle) map[int]domain.VehicleAttributes {
+	t.Helper()
+	vehicles, err := rp.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := make(map[int]domain.VehicleAttributes, len(vehicles))
+	for _, v := range vehicles {
+		content[v.Id] = v.Attributes
+	}
+	return content
+}
+
+func TestRepositoryVehicleWAL_Replay(t *testing.T) {
+	seed := newVehiclesTest(20, 1)
+
+	// log the writes over the seed
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	lg, _, err := wal.Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rp := NewRepositoryVehicleWAL(NewRepositoryVehicleInMemory(copyVehiclesTest(seed)), lg)
+	writes := []struct {
+		name  string
+		write func() error
+	}{
+		{name: "post", write: func() error { return rp.Post(&domain.Vehicle{Id: 100, Attributes: *seed[1]}) }},
+		{name: "put", write: func() error { return rp.Put(&domain.Vehicle{Id: 1, Attributes: *seed[2]}) }},
+		{name: "patch fuel", write: func() error { return rp.PatchFuel(2, "electric", 0) }},
+		{name: "delete", write: func() error { return rp.Delete(3, 0) }},
+		{name: "update", write: func() error {
+			_, err := rp.Update(4, 0, func(a *domain.VehicleAttributes) error {
+				a.Brand = "updated"
+				return nil
+			})
+			return err
+		}},
+		{name: "post and delete", write: func() error {
+			if err := rp.Post(&domain.Vehicle{Id: 101, Attributes: *seed[5]}); err != nil {
+				return err
+			}
+			return rp.Delete(101, 0)
+		}},
+		{name: "transaction", write: func() error {
+			return rp.Transaction(func(tx RepositoryVehicleTx) error {
+				if err := tx.Post(&domain.Vehicle{Id: 102, Attributes: *seed[6]}); err != nil {
+					return err
+				}
+				if err := tx.Put(&domain.Vehicle{Id: 5, Attributes: *seed[7]}); err != nil {
+					return err
+				}
+				return tx.Delete(6, 0)
+			})
+		}},
+		{name: "aborted transaction", write: func() error {
+			// a transaction whose commit failed, as apply leaves it in the log
+			seq, err := lg.Append(wal.Record{Op: wal.OperationTx, Records: []wal.Record{{Op: wal.OperationDelete, Id: 7}}})
+			if err != nil {
+				return err
+			}
+			_, err = lg.Append(wal.Record{Op: wal.OperationAbort, Ref: seq})
+			return err
+		}},
+	}
+	for _, w := range writes {
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: %v", w.name, err)
+		}
+	}
+	want := contentTest(t, rp)
+	if _, ok := want[7]; !ok {
+		t.Fatal("the aborted transaction was applied")
+	}
+
+	// the snapshot has every write of the log, as Compact leaves it when it crashes before the reset
+	snapshot := make(map[int]*domain.VehicleAttributes, len(want))
+	for id, a := range want {
+		attributes := a
+		snapshot[id] = &attributes
+	}
+	lg.Close()
+
+	tests := []struct {
+		name string
+		base map[int]*domain.VehicleAttributes
+	}{
+		{name: "over the seed", base: seed},
+		{name: "over a snapshot of the writes", base: snapshot},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lg, records, err := wal.Open(path, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer lg.Close()
+
+			rp := NewRepositoryVehicleWAL(NewRepositoryVehicleInMemory(copyVehiclesTest(tt.base)), lg)
+			if err := rp.Replay(records); err != nil {
+				t.Fatalf("Replay returned %v", err)
+			}
+			if got := contentTest(t, rp); !reflect.DeepEqual(got, want) {
+				t.Errorf("Replay rebuilt %d vehicles, want %d: %v", len(got), len(want), got)
+			}
+		})
+	}
+}
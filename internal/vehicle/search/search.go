@@ -0,0 +1,258 @@
+package search
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Fields are the fields of the vehicles in the index, with the boost of their matches in the score.
+var Fields = []struct {
+	Field domain.VehicleField
+	Boost float64
+}{
+	{domain.VehicleFieldBrand, 2},
+	{domain.VehicleFieldModel, 2},
+	{domain.VehicleFieldColor, 1},
+	{domain.VehicleFieldRegistration, 2},
+}
+
+const (
+	// weightExact is the weight of a term equal to the token of the query.
+	weightExact = 1
+	// minPrefix is the least length of a token of the query that matches the terms it is a prefix of.
+	minPrefix = 2
+	// minFuzzy is the least length of a token of the query that matches the terms with typos.
+	minFuzzy = 4
+	// registrationMask is the bit of the registration field in the postings.
+	registrationMask = 1 << 3
+)
+
+// Hit is a vehicle that matches a query, with its relevance.
+type Hit struct {
+	Id    int
+	Score float64
+}
+
+// NewIndex returns a new empty index.
+func NewIndex() *Index {
+	return &Index{
+		postings: make(map[string]map[int]uint8),
+		text:     make(map[string]int),
+		words:    make([]string, 0),
+	}
+}
+
+// Index is an inverted index of the brand, model, color and registration of vehicles.
+// The text fields match the terms of a query exactly, as a prefix or with typos, while registrations only
+// match exactly, as a similar registration is another vehicle.
+// It isn't safe for concurrent use: writes must be serialized with searches.
+type Index struct {
+	// postings are the ids of the vehicles of every term, with the bits of the fields it is in.
+	postings map[string]map[int]uint8
+	// text is the number of vehicles with the term in a text field.
+	text map[string]int
+	// words are the terms of the text fields, sorted to find them by prefix.
+	words []string
+}
+
+// Tokenize returns the terms of the text: its runs of letters and digits, lower cased.
+func Tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Add indexes the vehicle.
+func (ix *Index) Add(id int, a *domain.VehicleAttributes) {
+	for i, f := range Fields {
+		for _, term := range Tokenize(fieldText(a, f.Field)) {
+			ids, ok := ix.postings[term]
+			if !ok {
+				ids = make(map[int]uint8)
+				ix.postings[term] = ids
+			}
+			prev := ids[id]
+			ids[id] = prev | 1<<i
+			if !hasText(prev) && hasText(ids[id]) {
+				ix.addText(term)
+			}
+		}
+	}
+}
+
+// Remove drops the vehicle, that must be indexed with the attributes a.
+func (ix *Index) Remove(id int, a *domain.VehicleAttributes) {
+	for i, f := range Fields {
+		for _, term := range Tokenize(fieldText(a, f.Field)) {
+			ids, ok := ix.postings[term]
+			if !ok {
+				continue
+			}
+			prev := ids[id]
+			ids[id] = prev &^ (1 << i)
+			if hasText(prev) && !hasText(ids[id]) {
+				ix.removeText(term)
+			}
+			if ids[id] == 0 {
+				delete(ids, id)
+			}
+			if len(ids) == 0 {
+				delete(ix.postings, term)
+			}
+		}
+	}
+}
+
+// Search returns the vehicles that match any term of the query, sorted by score and id.
+// The score of a vehicle adds, for every term of the query, the best weight of its matches times the boost
+// of the field: 1 for an equal term, less for a prefix the shorter it is and less for a typo the more edits it needs.
+func (ix *Index) Search(query string) []Hit {
+	scores := make(map[int]float64)
+	seen := make(map[string]bool)
+	for _, token := range Tokenize(query) {
+		if seen[token] {
+			continue
+		}
+		seen[token] = true
+
+		best := make(map[int]float64)
+		for term, weight := range ix.matches(token) {
+			for id, mask := range ix.postings[term] {
+				if term != token && mask&^registrationMask == 0 {
+					// registrations only match exactly
+					continue
+				}
+				if s := weight * boost(mask, term == token); s > best[id] {
+					best[id] = s
+				}
+			}
+		}
+		for id, s := range best {
+			scores[id] += s
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, s := range scores {
+		hits = append(hits, Hit{Id: id, Score: s})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		return hits[i].Score > hits[j].Score || hits[i].Score == hits[j].Score && hits[i].Id < hits[j].Id
+	})
+	return hits
+}
+
+// matches returns the terms that match the token of a query with their weight.
+func (ix *Index) matches(token string) map[string]float64 {
+	terms := make(map[string]float64)
+	if _, ok := ix.postings[token]; ok {
+		terms[token] = weightExact
+	}
+
+	n := len([]rune(token))
+	if n >= minPrefix {
+		for i := sort.SearchStrings(ix.words, token); i < len(ix.words) && strings.HasPrefix(ix.words[i], token); i++ {
+			if term := ix.words[i]; term != token {
+				// the more of the term the prefix covers, the closer to an equal term
+				terms[term] = 0.5 + 0.4*float64(n)/float64(len([]rune(term)))
+			}
+		}
+	}
+
+	if n >= minFuzzy {
+		maxEdits := 1
+		if n >= 8 {
+			maxEdits = 2
+		}
+		for _, term := range ix.words {
+			if _, ok := terms[term]; ok {
+				continue
+			}
+			if d := distance(token, term, maxEdits); d <= maxEdits {
+				terms[term] = 0.8 - 0.3*float64(d-1)
+			}
+		}
+	}
+	return terms
+}
+
+// addText adds the term to the words when it gets its first vehicle with it in a text field.
+func (ix *Index) addText(term string) {
+	ix.text[term]++
+	if ix.text[term] > 1 {
+		return
+	}
+	i := sort.SearchStrings(ix.words, term)
+	ix.words = append(ix.words, "")
+	copy(ix.words[i+1:], ix.words[i:])
+	ix.words[i] = term
+}
+
+// removeText removes the term from the words when its last vehicle with it in a text field is removed.
+func (ix *Index) removeText(term string) {
+	ix.text[term]--
+	if ix.text[term] > 0 {
+		return
+	}
+	delete(ix.text, term)
+	if i := sort.SearchStrings(ix.words, term); i < len(ix.words) && ix.words[i] == term {
+		ix.words = append(ix.words[:i], ix.words[i+1:]...)
+	}
+}
+
+// hasText reports whether the bits of the fields of a posting include a text field.
+func hasText(mask uint8) bool {
+	return mask&^registrationMask != 0
+}
+
+// boost returns the greatest boost of the fields of the bits, only considering registration for equal terms.
+func boost(mask uint8, exact bool) (b float64) {
+	for i, f := range Fields {
+		if mask&(1<<i) == 0 || (!exact && 1<<i == registrationMask) {
+			continue
+		}
+		if f.Boost > b {
+			b = f.Boost
+		}
+	}
+	return
+}
+
+// fieldText returns the text of a field of the attributes.
+func fieldText(a *domain.VehicleAttributes, field domain.VehicleField) string {
+	v := domain.Vehicle{Attributes: *a}
+	return v.Text(field)
+}
+
+// distance returns the edit distance between a and b, or max+1 when it is greater than max.
+func distance(a string, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if d := len(ra) - len(rb); d > max || -d > max {
+		return max + 1
+	}
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		least := curr[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			least = min(least, curr[j])
+		}
+		if least > max {
+			return max + 1
+		}
+		prev, curr = curr, prev
+	}
+	return min(prev[len(rb)], max+1)
+}
@@ -0,0 +1,140 @@
+package search
+
+import (
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// newIndexTest returns an index of a few vehicles.
+func newIndexTest() *Index {
+	ix := NewIndex()
+	ix.Add(1, &domain.VehicleAttributes{Brand: "Toyota", Model: "Land Cruiser", Color: "White", Registration: "AB123"})
+	ix.Add(2, &domain.VehicleAttributes{Brand: "Toyota", Model: "Corolla", Color: "Red", Registration: "CD456"})
+	ix.Add(3, &domain.VehicleAttributes{Brand: "Ford", Model: "Ranger", Color: "Red", Registration: "EF789"})
+	return ix
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{name: "words", text: "Land Cruiser", want: []string{"land", "cruiser"}},
+		{name: "punctuation", text: "Land-Cruiser 4x4, AB 123.", want: []string{"land", "cruiser", "4x4", "ab", "123"}},
+		{name: "accents", text: "Ñandú", want: []string{"ñandú"}},
+		{name: "no words", text: " -- ", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Tokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		max  int
+		want int
+	}{
+		{a: "abc", b: "abc", max: 0, want: 0},
+		{a: "toyta", b: "toyota", max: 1, want: 1},
+		{a: "cruser", b: "cruiser", max: 2, want: 1},
+		{a: "kitten", b: "sitting", max: 3, want: 3},
+		// greater distances return max+1
+		{a: "kitten", b: "sitting", max: 2, want: 3},
+		{a: "abcdef", b: "ab", max: 1, want: 2},
+		{a: "", b: "abc", max: 5, want: 3},
+		{a: "café", b: "cafe", max: 1, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := distance(tt.a, tt.b, tt.max); got != tt.want {
+				t.Errorf("distance returned %d, want %d", got, tt.want)
+			}
+			if got := distance(tt.b, tt.a, tt.max); got != tt.want {
+				t.Errorf("distance swapped returned %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []Hit
+	}{
+		{
+			// a typo weighs 0.8 and an equal term 1, times the boost 2 of brand and model
+			name: "typos", query: "toyta land cruser",
+			want: []Hit{{Id: 1, Score: 5.2}, {Id: 2, Score: 1.6}},
+		},
+		{name: "equal color", query: "RED", want: []Hit{{Id: 2, Score: 1}, {Id: 3, Score: 1}}},
+		{name: "repeated word", query: "toyota Toyota", want: []Hit{{Id: 1, Score: 2}, {Id: 2, Score: 2}}},
+		{
+			// the prefix covers 3 of the 7 letters of corolla
+			name: "prefix", query: "cor", want: []Hit{{Id: 2, Score: 2 * (0.5 + 0.4*3/7)}},
+		},
+		{name: "prefix too short", query: "c", want: []Hit{}},
+		{name: "typo in a short word", query: "rad", want: []Hit{}},
+		{name: "equal registration", query: "ab123", want: []Hit{{Id: 1, Score: 2}}},
+		{name: "registration prefix", query: "ab12", want: []Hit{}},
+		{name: "registration typo", query: "ab124", want: []Hit{}},
+		{name: "no words", query: "--", want: []Hit{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits := newIndexTest().Search(tt.query)
+			for i := range hits {
+				hits[i].Score = math.Round(hits[i].Score*1e9) / 1e9
+			}
+			for i := range tt.want {
+				tt.want[i].Score = math.Round(tt.want[i].Score*1e9) / 1e9
+			}
+			if !reflect.DeepEqual(hits, tt.want) {
+				t.Errorf("Search returned %v, want %v", hits, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndex_Remove(t *testing.T) {
+	ix := newIndexTest()
+	ix.Remove(2, &domain.VehicleAttributes{Brand: "Toyota", Model: "Corolla", Color: "Red", Registration: "CD456"})
+	// a vehicle re-added with other attributes only matches the new ones
+	ix.Remove(3, &domain.VehicleAttributes{Brand: "Ford", Model: "Ranger", Color: "Red", Registration: "EF789"})
+	ix.Add(3, &domain.VehicleAttributes{Brand: "Ford", Model: "Focus", Color: "Blue", Registration: "EF789"})
+
+	tests := []struct {
+		query string
+		want  []int
+	}{
+		{query: "toyota", want: []int{1}},
+		{query: "cor", want: []int{}},
+		{query: "corolla", want: []int{}},
+		{query: "red", want: []int{}},
+		{query: "ranger", want: []int{}},
+		{query: "foc", want: []int{3}},
+		{query: "ef789", want: []int{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			ids := make([]int, 0)
+			for _, h := range ix.Search(tt.query) {
+				ids = append(ids, h.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Search returned %v, want %v", ids, tt.want)
+			}
+		})
+	}
+	if want := []string{"blue", "cruiser", "focus", "ford", "land", "toyota", "white"}; !reflect.DeepEqual(ix.words, want) {
+		t.Errorf("the index has the words %q, want %q", ix.words, want)
+	}
+}
@@ -0,0 +1,134 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"sort"
+	"strings"
+)
+
+// errBulkDryRun rolls back the transaction of a dry run once its writes succeed.
+var errBulkDryRun = errors.New("service: bulk dry run")
+
+// UpdateByFilter sets the fields of the changes, a json object of field names and values, in every vehicle
+// that matches the filter expression, and returns their ids. The vehicles are updated in a transaction, so
+// either all of them are or none. A dry run checks the changes apply to every vehicle and then rolls back.
+func (s *ServiceVehicleDefault) UpdateByFilter(filter string, changes map[string]any, dryRun bool) (ids []int, err error) {
+	f, err := parseBulkFilter(filter)
+	if err != nil {
+		return
+	}
+	if err = validateBulkChanges(changes); err != nil {
+		return
+	}
+
+	return s.bulk(f, dryRun, func(tx repository.RepositoryVehicleTx, id int) error {
+		// the errors of the changes are kept apart from the ones of the storage, which are adapted
+		var errChanges error
+		_, err := tx.Update(id, 0, func(a *domain.VehicleAttributes) error {
+			current := &domain.Vehicle{Id: id, Attributes: *a}
+			doc, _ := mergePatch(patchDocument(current), changes).(map[string]any)
+			errChanges = patchVehicle(current, doc)
+			if errChanges == nil {
+				errChanges = validateAttributes(current.Attributes)
+			}
+			if errChanges != nil {
+				errChanges = fmt.Errorf("vehicle %d: %w", id, errChanges)
+				return errChanges
+			}
+			*a = current.Attributes
+			return nil
+		})
+		if err != nil {
+			if errChanges != nil {
+				return errChanges
+			}
+			return s.errAdapter(err)
+		}
+		return nil
+	})
+}
+
+// DeleteByFilter removes every vehicle that matches the filter expression and returns their ids, all in a
+// transaction. A dry run runs the deletes too and then rolls the transaction back, so no vehicle is removed.
+func (s *ServiceVehicleDefault) DeleteByFilter(filter string, dryRun bool) (ids []int, err error) {
+	f, err := parseBulkFilter(filter)
+	if err != nil {
+		return
+	}
+
+	return s.bulk(f, dryRun, func(tx repository.RepositoryVehicleTx, id int) error {
+		if err := tx.Delete(id, 0); err != nil {
+			return s.errAdapter(err)
+		}
+		return nil
+	})
+}
+
+// bulk runs the write on every vehicle that matches the filter, in a transaction that is rolled back when
+// a write fails or on a dry run, and returns the ids of the vehicles sorted, empty if none matches.
+func (s *ServiceVehicleDefault) bulk(f domain.Filter, dryRun bool, write func(tx repository.RepositoryVehicleTx, id int) error) (ids []int, err error) {
+	ids = make([]int, 0)
+	var errWrite error
+	err = s.rp.Transaction(func(tx repository.RepositoryVehicleTx) error {
+		vehicles, err := tx.GetByFilter(f)
+		if err != nil && !errors.Is(err, repository.ErrRepositoryVehicleNotFound) {
+			return err
+		}
+		for _, v := range vehicles {
+			ids = append(ids, v.Id)
+		}
+		sort.Ints(ids)
+
+		for _, id := range ids {
+			if errWrite = write(tx, id); errWrite != nil {
+				return errWrite
+			}
+		}
+		if dryRun {
+			return errBulkDryRun
+		}
+		return nil
+	})
+	switch {
+	case errWrite != nil:
+		return nil, errWrite
+	case errors.Is(err, errBulkDryRun):
+		return ids, nil
+	case err != nil:
+		return nil, s.errAdapter(err)
+	}
+	return
+}
+
+// parseBulkFilter returns the filter of a bulk operation, which is required so that no operation affects
+// every vehicle by mistake.
+func parseBulkFilter(filter string) (domain.Filter, error) {
+	if strings.TrimSpace(filter) == "" {
+		return nil, fmt.Errorf("%w. a filter is required", ErrServiceVehicleInvalidBulk)
+	}
+	return ParseFilter(filter)
+}
+
+// validateBulkChanges checks the changes name fields other than the id and set them to a value.
+// The type of the values is checked when they are applied.
+func validateBulkChanges(changes map[string]any) error {
+	if len(changes) == 0 {
+		return fmt.Errorf("%w. no field to change", ErrServiceVehicleInvalidBulk)
+	}
+	for name, value := range changes {
+		field, ok := domain.ParseVehicleField(name)
+		if !ok || name != strings.ToLower(name) {
+			return fmt.Errorf("%w. unknown field %q", ErrServiceVehicleInvalidBulk, name)
+		}
+		if field == domain.VehicleFieldId {
+			return fmt.Errorf("%w. id can't be changed", ErrServiceVehicleInvalidBulk)
+		}
+		if value == nil {
+			return fmt.Errorf("%w. %s can't be removed", ErrServiceVehicleInvalidBulk, field)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestServiceVehicleDefault_UpdateByFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  string
+		changes map[string]any
+		dryRun  bool
+		// ids are the vehicles returned, changed whether they are updated.
+		ids     []int
+		changed bool
+		err     error
+	}{
+		{name: "update", filter: "brand = Ford", changes: map[string]any{"color": "Green"}, ids: []int{1, 2, 3}, changed: true},
+		{name: "dry run", filter: "brand = Ford", changes: map[string]any{"color": "Green"}, dryRun: true, ids: []int{1, 2, 3}},
+		{name: "no vehicle matches", filter: "brand = Seat", changes: map[string]any{"color": "Green"}, ids: []int{}},
+		{name: "no filter", filter: " ", changes: map[string]any{"color": "Green"}, err: ErrServiceVehicleInvalidBulk},
+		{name: "wrong filter", filter: "brand = ", changes: map[string]any{"color": "Green"}, err: ErrServiceVehicleInvalidFilter},
+		{name: "no changes", filter: "brand = Ford", changes: map[string]any{}, err: ErrServiceVehicleInvalidBulk},
+		{name: "id changed", filter: "brand = Ford", changes: map[string]any{"id": 9.0}, err: ErrServiceVehicleInvalidBulk},
+		{name: "unknown field", filter: "brand = Ford", changes: map[string]any{"wheels": 4.0}, err: ErrServiceVehicleInvalidBulk},
+		{name: "field removed", filter: "brand = Ford", changes: map[string]any{"color": nil}, err: ErrServiceVehicleInvalidBulk},
+		{name: "value of a wrong type", filter: "brand = Ford", changes: map[string]any{"year": "new"}, err: ErrServiceVehicleInvalidPatch},
+		{name: "invalid attributes", filter: "brand = Ford", changes: map[string]any{"fuel_type": "steam"}, err: ErrServiceVehicleInvalidAttributes},
+		{name: "invalid on some vehicles", filter: "brand = Ford", changes: map[string]any{"year": 1800.0}, err: ErrServiceVehicleInvalidAttributes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			written := rp.Written()
+
+			ids, err := sv.UpdateByFilter(tt.filter, tt.changes, tt.dryRun)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("UpdateByFilter returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("UpdateByFilter returned %v, want %v", ids, tt.ids)
+			}
+
+			// the vehicles are either all updated or none
+			for id := range vehiclesTest {
+				v, err := rp.GetById(id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				green := v.Attributes.Color == "Green"
+				if want := tt.changed && v.Attributes.Brand == "Ford"; green != want {
+					t.Errorf("vehicle %d has color %s", id, v.Attributes.Color)
+				}
+			}
+			if !tt.changed && rp.Written() != written {
+				t.Errorf("UpdateByFilter counted %d writes, want none", rp.Written()-written)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_DeleteByFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter string
+		dryRun bool
+		// ids are the vehicles returned, left the vehicles left afterwards.
+		ids  []int
+		left int
+		err  error
+	}{
+		{name: "delete", filter: "brand = Toyota", ids: []int{4, 5}, left: 4},
+		{name: "dry run", filter: "brand = Toyota", dryRun: true, ids: []int{4, 5}, left: 6},
+		{name: "no vehicle matches", filter: "brand = Seat", ids: []int{}, left: 6},
+		{name: "no filter", filter: "", err: ErrServiceVehicleInvalidBulk, left: 6},
+		{name: "wrong filter", filter: "year >", err: ErrServiceVehicleInvalidFilter, left: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			written := rp.Written()
+
+			ids, err := sv.DeleteByFilter(tt.filter, tt.dryRun)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("DeleteByFilter returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("DeleteByFilter returned %v, want %v", ids, tt.ids)
+			}
+			vehicles, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(vehicles) != tt.left {
+				t.Errorf("%d vehicles are left, want %d", len(vehicles), tt.left)
+			}
+			// the deletes of a dry run are rolled back, so they aren't writes to persist
+			if tt.left == len(vehiclesTest) && rp.Written() != written {
+				t.Errorf("DeleteByFilter counted %d writes, want none", rp.Written()-written)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DuplicateTolerance is the default greatest relative difference of the dimensions of near-identical vehicles.
+const DuplicateTolerance = 0.01
+
+// RejectDuplicates makes Post and Batch reject the vehicles that duplicate a stored one with
+// ErrServiceVehicleDuplicate, comparing the dimensions with the tolerance.
+func (s *ServiceVehicleDefault) RejectDuplicates(tolerance float64) {
+	s.duplicates = &tolerance
+}
+
+// ParseDuplicateTolerance returns the tolerance of a query param, DuplicateTolerance when it is empty.
+func ParseDuplicateTolerance(param string) (tolerance float64, err error) {
+	if strings.TrimSpace(param) == "" {
+		return DuplicateTolerance, nil
+	}
+	tolerance, err = strconv.ParseFloat(strings.TrimSpace(param), 64)
+	if err != nil || !(tolerance >= 0 && tolerance <= 1) {
+		err = fmt.Errorf("%w. tolerance must be a number from 0 to 1, got %q", ErrServiceVehicleInvalidDuplicates, param)
+	}
+	return
+}
+
+// GetDuplicates returns the groups of vehicles that share a registration, and the groups of vehicles with the
+// same brand, model, year and color whose height and width differ less than the tolerance, relative to the
+// greatest of them. A vehicle near-identical to two others groups them all, even if they aren't near-identical.
+func (s *ServiceVehicleDefault) GetDuplicates(tolerance float64) (groups []domain.DuplicateGroup, err error) {
+	if tolerance < 0 || tolerance > 1 || math.IsNaN(tolerance) {
+		err = fmt.Errorf("%w. tolerance must be from 0 to 1", ErrServiceVehicleInvalidDuplicates)
+		return
+	}
+	vehicles, err := s.GetAll()
+	if err != nil {
+		if errors.Is(err, ErrServiceVehicleNotFound) {
+			err = nil
+		}
+		return
+	}
+	sort.Slice(vehicles, func(i, j int) bool {
+		return vehicles[i].Id < vehicles[j].Id
+	})
+
+	groups = make([]domain.DuplicateGroup, 0)
+	registrations := make(map[string][]*domain.Vehicle)
+	for _, v := range vehicles {
+		if k := registrationKey(v); k != "" {
+			registrations[k] = append(registrations[k], v)
+		}
+	}
+	for _, vs := range registrations {
+		if len(vs) > 1 {
+			groups = append(groups, domain.DuplicateGroup{Reason: domain.DuplicateReasonRegistration, Vehicles: vs})
+		}
+	}
+
+	attributes := make(map[string][]*domain.Vehicle)
+	for _, v := range vehicles {
+		k := attributesKey(v)
+		attributes[k] = append(attributes[k], v)
+	}
+	for _, vs := range attributes {
+		// the near-identical vehicles are joined in the same group
+		parent := make([]int, len(vs))
+		for i := range parent {
+			parent[i] = i
+		}
+		var root func(i int) int
+		root = func(i int) int {
+			for parent[i] != i {
+				parent[i] = parent[parent[i]]
+				i = parent[i]
+			}
+			return i
+		}
+		for i := range vs {
+			for j := i + 1; j < len(vs); j++ {
+				if nearDimensions(vs[i], vs[j], tolerance) {
+					parent[root(j)] = root(i)
+				}
+			}
+		}
+		components := make(map[int][]*domain.Vehicle)
+		for i, v := range vs {
+			components[root(i)] = append(components[root(i)], v)
+		}
+		for _, c := range components {
+			if len(c) > 1 {
+				groups = append(groups, domain.DuplicateGroup{Reason: domain.DuplicateReasonAttributes, Vehicles: c})
+			}
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		a, b := groups[i], groups[j]
+		return a.Vehicles[0].Id < b.Vehicles[0].Id || a.Vehicles[0].Id == b.Vehicles[0].Id && a.Reason > b.Reason
+	})
+	return
+}
+
+// findDuplicate returns a vehicle of rp that the vehicle duplicates, nil if there is none.
+func (s *ServiceVehicleDefault) findDuplicate(rp repository.RepositoryVehicleTx, vehicle *domain.Vehicle, tolerance float64) (*domain.Vehicle, error) {
+	a := vehicle.Attributes
+	attributes := domain.FilterAnd{Filters: []domain.Filter{
+		domain.FilterCondition{Field: domain.VehicleFieldBrand, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: a.Brand}}},
+		domain.FilterCondition{Field: domain.VehicleFieldModel, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: a.Model}}},
+		domain.FilterCondition{Field: domain.VehicleFieldColor, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: a.Color}}},
+		domain.FilterCondition{Field: domain.VehicleFieldYear, Operator: domain.FilterOperatorEq, Values: []domain.FilterValue{{Text: strconv.Itoa(a.Year), Number: float64(a.Year)}}},
+	}}
+	var f domain.Filter = attributes
+	if k := registrationKey(vehicle); k != "" {
+		f = domain.FilterOr{Filters: []domain.Filter{attributes, domain.FilterCondition{
+			Field: domain.VehicleFieldRegistration, Operator: domain.FilterOperatorIEq, Values: []domain.FilterValue{{Text: strings.TrimSpace(a.Registration)}},
+		}}}
+	}
+
+	candidates, err := rp.GetByFilter(f)
+	if err != nil {
+		if err = s.errAdapter(err); errors.Is(err, ErrServiceVehicleNotFound) {
+			err = nil
+		}
+		return nil, err
+	}
+	for _, c := range candidates {
+		if k := registrationKey(vehicle); k != "" && registrationKey(c) == k {
+			return c, nil
+		}
+		if attributesKey(c) == attributesKey(vehicle) && nearDimensions(c, vehicle, tolerance) {
+			return c, nil
+		}
+	}
+	return nil, nil
+}
+
+// registrationKey returns the registration of the vehicle compared ignoring case and spaces around it.
+func registrationKey(v *domain.Vehicle) string {
+	return strings.ToLower(strings.TrimSpace(v.Attributes.Registration))
+}
+
+// attributesKey returns the brand, model, year and color of the vehicle, compared ignoring case.
+func attributesKey(v *domain.Vehicle) string {
+	a := v.Attributes
+	return fmt.Sprintf("%q %q %d %q", strings.ToLower(a.Brand), strings.ToLower(a.Model), a.Year, strings.ToLower(a.Color))
+}
+
+// nearDimensions reports whether the height and width of the vehicles differ at most the tolerance,
+// relative to the greatest of them.
+func nearDimensions(a *domain.Vehicle, b *domain.Vehicle, tolerance float64) bool {
+	near := func(x, y float64) bool {
+		return math.Abs(x-y) <= tolerance*math.Max(math.Abs(x), math.Abs(y))
+	}
+	return near(a.Attributes.Height, b.Attributes.Height) && near(a.Attributes.Width, b.Attributes.Width)
+}
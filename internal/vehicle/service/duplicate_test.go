@@ -0,0 +1,173 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// vehicleTest returns the vehicle of vehiclesTest with the id from, changed, with another id.
+func vehicleTest(id int, from int, change func(a *domain.VehicleAttributes)) *domain.Vehicle {
+	a := vehiclesTest[from]
+	change(&a)
+	return &domain.Vehicle{Id: id, Attributes: a}
+}
+
+func TestParseDuplicateTolerance(t *testing.T) {
+	tests := []struct {
+		param string
+		want  float64
+		err   error
+	}{
+		{param: "", want: DuplicateTolerance},
+		{param: " 0.05 ", want: 0.05},
+		{param: "0", want: 0},
+		{param: "1", want: 1},
+		{param: "-0.1", err: ErrServiceVehicleInvalidDuplicates},
+		{param: "1.5", err: ErrServiceVehicleInvalidDuplicates},
+		{param: "NaN", err: ErrServiceVehicleInvalidDuplicates},
+		{param: "tiny", err: ErrServiceVehicleInvalidDuplicates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.param, func(t *testing.T) {
+			tolerance, err := ParseDuplicateTolerance(tt.param)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseDuplicateTolerance returned %v, want %v", err, tt.err)
+			}
+			if err == nil && tolerance != tt.want {
+				t.Errorf("ParseDuplicateTolerance returned %v, want %v", tolerance, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetDuplicates(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance float64
+		// posted are the vehicles added to vehiclesTest.
+		posted []*domain.Vehicle
+		// want are the groups as reason:ids.
+		want []string
+		err  error
+	}{
+		{name: "no duplicates", tolerance: 0.01, want: []string{}},
+		{
+			name: "registration ignoring case and spaces", tolerance: 0.01,
+			posted: []*domain.Vehicle{vehicleTest(7, 2, func(a *domain.VehicleAttributes) { a.Registration, a.Brand = " aa100 ", "Seat" })},
+			want:   []string{"registration:[1 7]"},
+		},
+		{
+			name: "near-identical dimensions", tolerance: 0.01,
+			posted: []*domain.Vehicle{vehicleTest(7, 1, func(a *domain.VehicleAttributes) {
+				a.Registration, a.Brand, a.Height = "ZZ100", "FORD", 151
+			})},
+			want: []string{"attributes:[1 7]"},
+		},
+		{
+			name: "dimensions beyond the tolerance", tolerance: 0.01,
+			posted: []*domain.Vehicle{vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Width = "ZZ100", 175 })},
+			want:   []string{},
+		},
+		{
+			name: "other year", tolerance: 0.01,
+			posted: []*domain.Vehicle{vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Year = "ZZ100", 2002 })},
+			want:   []string{},
+		},
+		{
+			// 8 is near 7 and 7 is near 1, but 8 isn't near 1
+			name: "chain of near-identical vehicles", tolerance: 0.01,
+			posted: []*domain.Vehicle{
+				vehicleTest(8, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Height = "ZZ200", 152.8 }),
+				vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Height = "ZZ100", 151.4 }),
+			},
+			want: []string{"attributes:[1 7 8]"},
+		},
+		{
+			name: "both reasons", tolerance: 0,
+			posted: []*domain.Vehicle{
+				vehicleTest(7, 1, func(a *domain.VehicleAttributes) {}),
+				vehicleTest(8, 4, func(a *domain.VehicleAttributes) { a.Registration = "ZZ100" }),
+			},
+			want: []string{"registration:[1 7]", "attributes:[1 7]", "attributes:[4 8]"},
+		},
+		{name: "negative tolerance", tolerance: -0.1, err: ErrServiceVehicleInvalidDuplicates},
+		{name: "tolerance over 1", tolerance: 1.1, err: ErrServiceVehicleInvalidDuplicates},
+		{name: "tolerance not a number", tolerance: math.NaN(), err: ErrServiceVehicleInvalidDuplicates},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			for _, v := range tt.posted {
+				if err := rp.Post(v); err != nil {
+					t.Fatal(err)
+				}
+			}
+			groups, err := sv.GetDuplicates(tt.tolerance)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetDuplicates returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			got := make([]string, 0, len(groups))
+			for _, g := range groups {
+				ids := make([]int, 0, len(g.Vehicles))
+				for _, v := range g.Vehicles {
+					ids = append(ids, v.Id)
+				}
+				got = append(got, fmt.Sprintf("%s:%v", g.Reason, ids))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GetDuplicates returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_RejectDuplicates(t *testing.T) {
+	tests := []struct {
+		name    string
+		vehicle *domain.Vehicle
+		err     error
+	}{
+		{name: "copy", vehicle: vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration = "ZZ100" }), err: ErrServiceVehicleDuplicate},
+		{name: "registration", vehicle: vehicleTest(7, 2, func(a *domain.VehicleAttributes) { a.Registration = "aa100 " }), err: ErrServiceVehicleDuplicate},
+		{name: "other color", vehicle: vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Color = "ZZ100", "Green" })},
+		{name: "other dimensions", vehicle: vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Height = "ZZ100", 160 })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			sv.RejectDuplicates(0.01)
+			if err := sv.Post(tt.vehicle); !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Post returned %v, want %v", err, tt.err)
+			}
+			_, err := rp.GetById(tt.vehicle.Id)
+			if posted := err == nil; posted != (tt.err == nil) {
+				t.Errorf("the vehicle was posted: %t, want %t", posted, tt.err == nil)
+			}
+		})
+	}
+
+	// the vehicles of a batch are compared to the ones posted before them
+	for _, atomic := range []bool{false, true} {
+		t.Run(fmt.Sprintf("batch atomic %t", atomic), func(t *testing.T) {
+			sv, _ := newServiceTest(t)
+			sv.RejectDuplicates(0.01)
+			failures, err := sv.Batch([]*domain.Vehicle{
+				vehicleTest(7, 1, func(a *domain.VehicleAttributes) { a.Registration, a.Color = "ZZ100", "Green" }),
+				vehicleTest(8, 2, func(a *domain.VehicleAttributes) { a.Registration = "zz100" }),
+			}, atomic)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(failures) != 1 || failures[0].Index != 1 || !errors.Is(failures[0].Err, ErrServiceVehicleDuplicate) {
+				t.Errorf("Batch returned %+v, want the second vehicle to duplicate the first", failures)
+			}
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package service
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseFilter returns the filter of a query expression, or ErrServiceVehicleInvalidFilter describing what is wrong.
+//
+// An expression combines conditions with and, or, not and parentheses, and is evaluated with the usual precedence:
+//
+//	brand ~ toyota and (year >= 1990 or color in (red, "dark blue")) and not transmission = manual
+//
+// A condition compares a field, by its json name, with =, !=, <, <=, >, >=, ~ (equality ignoring case)
+// or in (any of a list of values). Text values may be quoted with single or double quotes.
+// A numeric field can also be compared with within and a range in interval notation, see ParseRange:
+//
+//	weight within [100,200) and max_speed within (150,]
+func ParseFilter(expr string) (f domain.Filter, err error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return
+	}
+
+	p := &filterParser{tokens: tokens}
+	f, err = p.parseOr()
+	if err != nil {
+		return
+	}
+	if t := p.peek(); t.kind != tokenFilterEOF {
+		err = p.errorf(t, "unexpected %q", t.text)
+	}
+	return
+}
+
+// tokenFilterKind is the kind of a token of a filter expression.
+type tokenFilterKind int
+
+const (
+	tokenFilterEOF tokenFilterKind = iota
+	tokenFilterWord
+	tokenFilterString
+	tokenFilterOperator
+	tokenFilterLParen
+	tokenFilterRParen
+	tokenFilterComma
+	tokenFilterLBracket
+	tokenFilterRBracket
+)
+
+// tokenFilter is a token of a filter expression.
+type tokenFilter struct {
+	kind tokenFilterKind
+	text string
+	// pos is the position of the token in the expression.
+	pos int
+}
+
+// tokenizeFilter splits a filter expression into tokens.
+func tokenizeFilter(expr string) (tokens []tokenFilter, err error) {
+	rs := []rune(expr)
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, tokenFilter{kind: tokenFilterLParen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, tokenFilter{kind: tokenFilterRParen, text: ")", pos: i})
+			i++
+		case r == '[':
+			tokens = append(tokens, tokenFilter{kind: tokenFilterLBracket, text: "[", pos: i})
+			i++
+		case r == ']':
+			tokens = append(tokens, tokenFilter{kind: tokenFilterRBracket, text: "]", pos: i})
+			i++
+		case r == ',':
+			tokens = append(tokens, tokenFilter{kind: tokenFilterComma, text: ",", pos: i})
+			i++
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(rs) && rs[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+			if op == "!" {
+				err = fmt.Errorf("%w. position %d: unexpected %q", ErrServiceVehicleInvalidFilter, i, op)
+				return
+			}
+			tokens = append(tokens, tokenFilter{kind: tokenFilterOperator, text: op, pos: i})
+			i += len(op)
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(rs) && rs[j] != r {
+				j++
+			}
+			if j == len(rs) {
+				err = fmt.Errorf("%w. position %d: unterminated string", ErrServiceVehicleInvalidFilter, i)
+				return
+			}
+			tokens = append(tokens, tokenFilter{kind: tokenFilterString, text: string(rs[i+1 : j]), pos: i})
+			i = j + 1
+		case isFilterWordRune(r):
+			j := i
+			for j < len(rs) && isFilterWordRune(rs[j]) {
+				j++
+			}
+			tokens = append(tokens, tokenFilter{kind: tokenFilterWord, text: string(rs[i:j]), pos: i})
+			i = j
+		default:
+			err = fmt.Errorf("%w. position %d: unexpected %q", ErrServiceVehicleInvalidFilter, i, r)
+			return
+		}
+	}
+	tokens = append(tokens, tokenFilter{kind: tokenFilterEOF, text: "end of filter", pos: len(rs)})
+	return
+}
+
+// isFilterWordRune reports whether r can be part of a field name or an unquoted value.
+func isFilterWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.' || r == '+'
+}
+
+// filterParser is a recursive descent parser of filter expressions.
+type filterParser struct {
+	tokens []tokenFilter
+	i      int
+}
+
+func (p *filterParser) peek() tokenFilter {
+	return p.tokens[p.i]
+}
+
+func (p *filterParser) next() tokenFilter {
+	t := p.tokens[p.i]
+	if t.kind != tokenFilterEOF {
+		p.i++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given keyword, consuming it if so.
+func (p *filterParser) keyword(k string) bool {
+	if t := p.peek(); t.kind == tokenFilterWord && strings.EqualFold(t.text, k) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) errorf(t tokenFilter, format string, args ...any) error {
+	return fmt.Errorf("%w. position %d: %s", ErrServiceVehicleInvalidFilter, t.pos, fmt.Sprintf(format, args...))
+}
+
+// parseOr parses: and { "or" and }
+func (p *filterParser) parseOr() (domain.Filter, error) {
+	f, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	filters := []domain.Filter{f}
+	for p.keyword("or") {
+		if f, err = p.parseAnd(); err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return domain.FilterOr{Filters: filters}, nil
+}
+
+// parseAnd parses: unary { "and" unary }
+func (p *filterParser) parseAnd() (domain.Filter, error) {
+	f, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	filters := []domain.Filter{f}
+	for p.keyword("and") {
+		if f, err = p.parseUnary(); err != nil {
+			return nil, err
+		}
+		filters = append(filters, f)
+	}
+	if len(filters) == 1 {
+		return filters[0], nil
+	}
+	return domain.FilterAnd{Filters: filters}, nil
+}
+
+// parseUnary parses: "not" unary | "(" or ")" | condition
+func (p *filterParser) parseUnary() (domain.Filter, error) {
+	if p.keyword("not") {
+		f, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return domain.FilterNot{Filter: f}, nil
+	}
+
+	if p.peek().kind == tokenFilterLParen {
+		p.next()
+		f, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenFilterRParen {
+			return nil, p.errorf(t, "expected \")\", got %q", t.text)
+		}
+		return f, nil
+	}
+
+	return p.parseCondition()
+}
+
+// parseCondition parses: field operator value | field "in" "(" value { "," value } ")" | field "within" range
+func (p *filterParser) parseCondition() (domain.Filter, error) {
+	t := p.next()
+	if t.kind != tokenFilterWord {
+		return nil, p.errorf(t, "expected a field, got %q", t.text)
+	}
+	field, ok := domain.ParseVehicleField(t.text)
+	if !ok {
+		return nil, p.errorf(t, "unknown field %q", t.text)
+	}
+	c := domain.FilterCondition{Field: field}
+
+	// operator
+	t = p.next()
+	switch {
+	case t.kind == tokenFilterOperator:
+		c.Operator = domain.FilterOperator(t.text)
+	case t.kind == tokenFilterWord && strings.EqualFold(t.text, "in"):
+		c.Operator = domain.FilterOperatorIn
+	case t.kind == tokenFilterWord && strings.EqualFold(t.text, "within"):
+		if !field.IsNumeric() {
+			return nil, p.errorf(t, "operator within can't be used with the text field %s", field)
+		}
+		return p.parseRange(field)
+	default:
+		return nil, p.errorf(t, "expected an operator after %s, got %q", field, t.text)
+	}
+	if c.Operator == domain.FilterOperatorIEq && field.IsNumeric() {
+		return nil, p.errorf(t, "operator ~ can't be used with the numeric field %s", field)
+	}
+
+	// values
+	if c.Operator != domain.FilterOperatorIn {
+		v, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		c.Values = []domain.FilterValue{v}
+		return c, nil
+	}
+
+	if t = p.next(); t.kind != tokenFilterLParen {
+		return nil, p.errorf(t, "expected \"(\" after in, got %q", t.text)
+	}
+	for {
+		v, err := p.parseValue(field)
+		if err != nil {
+			return nil, err
+		}
+		c.Values = append(c.Values, v)
+
+		t = p.next()
+		if t.kind == tokenFilterRParen {
+			return c, nil
+		}
+		if t.kind != tokenFilterComma {
+			return nil, p.errorf(t, "expected \",\" or \")\", got %q", t.text)
+		}
+	}
+}
+
+// parseValue parses a value of the field.
+func (p *filterParser) parseValue(field domain.VehicleField) (v domain.FilterValue, err error) {
+	t := p.next()
+	if t.kind != tokenFilterWord && t.kind != tokenFilterString {
+		err = p.errorf(t, "expected a value for %s, got %q", field, t.text)
+		return
+	}
+
+	v.Text = t.text
+	if field.IsNumeric() {
+		if v.Number, err = strconv.ParseFloat(t.text, 64); err != nil {
+			err = p.errorf(t, "%s must be a number, got %q", field, t.text)
+			return
+		}
+	}
+	return
+}
+
+// parseRange parses a range in interval notation, returning the conditions of the field being in it.
+func (p *filterParser) parseRange(field domain.VehicleField) (domain.Filter, error) {
+	start := p.next()
+	if start.kind != tokenFilterLBracket && start.kind != tokenFilterLParen {
+		return nil, p.errorf(start, "expected \"[\" or \"(\" after within, got %q", start.text)
+	}
+	// the tokens up to the closing bracket are joined back and parsed as a range
+	text := start.text
+	for {
+		t := p.next()
+		switch t.kind {
+		case tokenFilterWord, tokenFilterComma:
+			text += t.text
+			continue
+		case tokenFilterRBracket, tokenFilterRParen:
+			text += t.text
+		default:
+			return nil, p.errorf(t, "expected \"]\" or \")\" closing the range of %s, got %q", field, t.text)
+		}
+		break
+	}
+
+	r, err := ParseRange(text)
+	if err != nil {
+		return nil, p.errorf(start, "%s: %v", field, err)
+	}
+	return r.Filter(field), nil
+}
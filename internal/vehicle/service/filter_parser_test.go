@@ -0,0 +1,112 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+// conditionTest returns the condition of a field with the given values, as parsed.
+func conditionTest(field domain.VehicleField, op domain.FilterOperator, values ...domain.FilterValue) domain.FilterCondition {
+	return domain.FilterCondition{Field: field, Operator: op, Values: values}
+}
+
+func TestParseFilter(t *testing.T) {
+	ford := domain.FilterValue{Text: "Ford"}
+	year := func(n float64, text string) domain.FilterValue { return domain.FilterValue{Text: text, Number: n} }
+
+	tests := []struct {
+		name string
+		expr string
+		want domain.Filter
+		err  error
+	}{
+		{name: "condition", expr: "brand = Ford", want: conditionTest(domain.VehicleFieldBrand, domain.FilterOperatorEq, ford)},
+		{name: "numeric condition", expr: "year>=1990", want: conditionTest(domain.VehicleFieldYear, domain.FilterOperatorGe, year(1990, "1990"))},
+		{name: "every operator", expr: "year != 1 and year < 2 and year <= 3 and year > 4 and brand ~ ford", want: domain.FilterAnd{Filters: []domain.Filter{
+			conditionTest(domain.VehicleFieldYear, domain.FilterOperatorNe, year(1, "1")),
+			conditionTest(domain.VehicleFieldYear, domain.FilterOperatorLt, year(2, "2")),
+			conditionTest(domain.VehicleFieldYear, domain.FilterOperatorLe, year(3, "3")),
+			conditionTest(domain.VehicleFieldYear, domain.FilterOperatorGt, year(4, "4")),
+			conditionTest(domain.VehicleFieldBrand, domain.FilterOperatorIEq, domain.FilterValue{Text: "ford"}),
+		}}},
+		{name: "quoted values", expr: `color in (red, "dark blue", 'light green')`, want: conditionTest(domain.VehicleFieldColor, domain.FilterOperatorIn,
+			domain.FilterValue{Text: "red"}, domain.FilterValue{Text: "dark blue"}, domain.FilterValue{Text: "light green"})},
+		{name: "and binds tighter than or", expr: "brand = Ford or brand = Fiat and year > 2000", want: domain.FilterOr{Filters: []domain.Filter{
+			conditionTest(domain.VehicleFieldBrand, domain.FilterOperatorEq, ford),
+			domain.FilterAnd{Filters: []domain.Filter{
+				conditionTest(domain.VehicleFieldBrand, domain.FilterOperatorEq, domain.FilterValue{Text: "Fiat"}),
+				conditionTest(domain.VehicleFieldYear, domain.FilterOperatorGt, year(2000, "2000")),
+			}},
+		}}},
+		{name: "parentheses and not", expr: "NOT (brand = Ford OR year > 2000)", want: domain.FilterNot{Filter: domain.FilterOr{Filters: []domain.Filter{
+			conditionTest(domain.VehicleFieldBrand, domain.FilterOperatorEq, ford),
+			conditionTest(domain.VehicleFieldYear, domain.FilterOperatorGt, year(2000, "2000")),
+		}}}},
+		{name: "within", expr: "weight within [100,200)", want: domain.FilterAnd{Filters: []domain.Filter{
+			conditionTest(domain.VehicleFieldWeight, domain.FilterOperatorGe, year(100, "100")),
+			conditionTest(domain.VehicleFieldWeight, domain.FilterOperatorLt, year(200, "200")),
+		}}},
+		{name: "within open range", expr: "max_speed within (150,]", want: conditionTest(domain.VehicleFieldMaxSpeed, domain.FilterOperatorGt, year(150, "150"))},
+		{name: "empty", expr: "", err: ErrServiceVehicleInvalidFilter},
+		{name: "unknown field", expr: "wheels = 4", err: ErrServiceVehicleInvalidFilter},
+		{name: "missing operator", expr: "brand Ford", err: ErrServiceVehicleInvalidFilter},
+		{name: "missing value", expr: "brand =", err: ErrServiceVehicleInvalidFilter},
+		{name: "number expected", expr: "year = new", err: ErrServiceVehicleInvalidFilter},
+		{name: "~ on a number", expr: "year ~ 2000", err: ErrServiceVehicleInvalidFilter},
+		{name: "within on a text", expr: "brand within [a,b]", err: ErrServiceVehicleInvalidFilter},
+		{name: "empty range", expr: "weight within [200,100]", err: ErrServiceVehicleInvalidFilter},
+		{name: "unclosed range", expr: "weight within [100,200", err: ErrServiceVehicleInvalidFilter},
+		{name: "unclosed parenthesis", expr: "(brand = Ford", err: ErrServiceVehicleInvalidFilter},
+		{name: "unclosed in", expr: "color in (red, blue", err: ErrServiceVehicleInvalidFilter},
+		{name: "unterminated string", expr: `brand = "Ford`, err: ErrServiceVehicleInvalidFilter},
+		{name: "lone bang", expr: "brand ! Ford", err: ErrServiceVehicleInvalidFilter},
+		{name: "unexpected character", expr: "brand = Ford;", err: ErrServiceVehicleInvalidFilter},
+		{name: "trailing tokens", expr: "brand = Ford Fiat", err: ErrServiceVehicleInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := ParseFilter(tt.expr)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseFilter(%q) returned %v, want %v", tt.expr, err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(f, tt.want) {
+				t.Errorf("ParseFilter(%q) returned %#v, want %#v", tt.expr, f, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetByFilter(t *testing.T) {
+	sv, _ := newServiceTest(t)
+	tests := []struct {
+		expr string
+		ids  []int
+		err  error
+	}{
+		{expr: "brand = Ford", ids: []int{1, 2, 3}},
+		{expr: "brand = ford", err: ErrServiceVehicleNotFound},
+		{expr: "brand ~ FORD and color = Red", ids: []int{1, 3}},
+		{expr: "not brand = Ford and year >= 2010", ids: []int{4, 5}},
+		{expr: "color in (Red, Black) or fuel_type = diesel", ids: []int{1, 2, 3, 5, 6}},
+		{expr: "weight within (1100, 1700]", ids: []int{2, 3, 4}},
+		{expr: "year > 2100", err: ErrServiceVehicleNotFound},
+		{expr: "year >", err: ErrServiceVehicleInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			vehicles, err := sv.GetByFilter(tt.expr)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetByFilter returned %v, want %v", err, tt.err)
+			}
+			ids := make([]int, 0, len(vehicles))
+			for _, v := range vehicles {
+				ids = append(ids, v.Id)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("GetByFilter returned %v, want %v", ids, tt.ids)
+			}
+		})
+	}
+}
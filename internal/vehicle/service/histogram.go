@@ -0,0 +1,176 @@
+package service
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"sort"
+	"strings"
+)
+
+var (
+	// HistogramFields are the fields a histogram can count.
+	HistogramFields = []domain.VehicleField{
+		domain.VehicleFieldYear, domain.VehicleFieldWeight, domain.VehicleFieldMaxSpeed, domain.VehicleFieldPassengers,
+		domain.VehicleFieldHeight, domain.VehicleFieldWidth,
+	}
+	// HistogramSplitByFields are the fields that can split the bins of a histogram.
+	HistogramSplitByFields = []domain.VehicleField{
+		domain.VehicleFieldBrand, domain.VehicleFieldModel, domain.VehicleFieldColor,
+		domain.VehicleFieldFuelType, domain.VehicleFieldTransmission,
+	}
+)
+
+const (
+	// HistogramYearWidth is the default width of the bins of year, a decade.
+	HistogramYearWidth = 10
+	// HistogramBins is the default number of bins of the fields other than year.
+	HistogramBins = 10
+	// HistogramMaxBins is the greatest number of bins of a histogram.
+	HistogramMaxBins = 1000
+)
+
+// GetHistogram returns the histogram of the vehicles that match a filter expression, all of them if it is empty.
+// When the options set no bins, year is counted by decade and the other fields in HistogramBins bins.
+func (s *ServiceVehicleDefault) GetHistogram(filter string, opts domain.HistogramOptions) (h domain.Histogram, err error) {
+	if opts.Width == 0 && opts.Bins == 0 && len(opts.Edges) == 0 {
+		if opts.Field == domain.VehicleFieldYear {
+			opts.Width = HistogramYearWidth
+		} else {
+			opts.Bins = HistogramBins
+		}
+	}
+	if err = validateHistogramOptions(opts); err != nil {
+		return
+	}
+
+	var vehicles []*domain.Vehicle
+	if strings.TrimSpace(filter) == "" {
+		vehicles, err = s.GetAll()
+	} else {
+		vehicles, err = s.GetByFilter(filter)
+	}
+	if err != nil {
+		return
+	}
+	return computeHistogram(vehicles, opts)
+}
+
+// validateHistogramOptions checks the fields of the options and that they set the bins exactly once.
+func validateHistogramOptions(opts domain.HistogramOptions) error {
+	if !containsField(HistogramFields, opts.Field) {
+		return fmt.Errorf("%w. unknown histogram field %q, expected one of %s", ErrServiceVehicleInvalidHistogram, opts.Field, joinFields(HistogramFields))
+	}
+	if opts.SplitBy != "" && !containsField(HistogramSplitByFields, opts.SplitBy) {
+		return fmt.Errorf("%w. unknown split by field %q, expected one of %s", ErrServiceVehicleInvalidHistogram, opts.SplitBy, joinFields(HistogramSplitByFields))
+	}
+
+	set := 0
+	if opts.Width != 0 {
+		set++
+	}
+	if opts.Bins != 0 {
+		set++
+	}
+	if len(opts.Edges) != 0 {
+		set++
+	}
+	switch {
+	case set > 1:
+		return fmt.Errorf("%w. only one of width, bins or edges can be set", ErrServiceVehicleInvalidHistogram)
+	case opts.Width < 0 || math.IsNaN(opts.Width) || math.IsInf(opts.Width, 0):
+		return fmt.Errorf("%w. width must be a positive number", ErrServiceVehicleInvalidHistogram)
+	case math.IsNaN(opts.Origin) || math.IsInf(opts.Origin, 0):
+		return fmt.Errorf("%w. origin must be a finite number", ErrServiceVehicleInvalidHistogram)
+	case opts.Bins < 0 || opts.Bins > HistogramMaxBins:
+		return fmt.Errorf("%w. bins must be from 1 to %d", ErrServiceVehicleInvalidHistogram, HistogramMaxBins)
+	case len(opts.Edges) == 1 || len(opts.Edges) > HistogramMaxBins+1:
+		return fmt.Errorf("%w. edges must have from 2 to %d values", ErrServiceVehicleInvalidHistogram, HistogramMaxBins+1)
+	}
+	for i := 1; i < len(opts.Edges); i++ {
+		if !(opts.Edges[i-1] < opts.Edges[i]) {
+			return fmt.Errorf("%w. edges must be increasing", ErrServiceVehicleInvalidHistogram)
+		}
+	}
+	return nil
+}
+
+// computeHistogram counts the values of the field of the vehicles in the bins of the options.
+func computeHistogram(vehicles []*domain.Vehicle, opts domain.HistogramOptions) (h domain.Histogram, err error) {
+	h = domain.Histogram{Options: opts, Count: len(vehicles)}
+	if len(vehicles) == 0 {
+		h.Bins = make([]domain.HistogramBin, 0)
+		return
+	}
+
+	least, greatest := math.Inf(1), math.Inf(-1)
+	for _, v := range vehicles {
+		least = math.Min(least, v.Number(opts.Field))
+		greatest = math.Max(greatest, v.Number(opts.Field))
+	}
+
+	// bin returns the index of the bin of a value, or -1 when it is out of the bins
+	var bin func(value float64) int
+	var edges []float64
+	switch {
+	case opts.Width != 0:
+		first := math.Floor((least - opts.Origin) / opts.Width)
+		n := math.Floor((greatest-opts.Origin)/opts.Width) - first + 1
+		// a width too small for the values overflows the number of bins, which is then infinite or NaN
+		if math.IsInf(first, 0) || math.IsNaN(first) || !(n >= 1 && n <= HistogramMaxBins) {
+			err = fmt.Errorf("%w. width %v makes %v bins, more than %d", ErrServiceVehicleInvalidHistogram, opts.Width, n, HistogramMaxBins)
+			return
+		}
+		for i := 0; i <= int(n); i++ {
+			edges = append(edges, opts.Origin+(first+float64(i))*opts.Width)
+		}
+		bin = func(value float64) int {
+			return int(math.Floor((value-opts.Origin)/opts.Width) - first)
+		}
+	case opts.Bins != 0:
+		width := (greatest - least) / float64(opts.Bins)
+		for i := 0; i < opts.Bins; i++ {
+			edges = append(edges, least+float64(i)*width)
+		}
+		edges = append(edges, greatest)
+		bin = func(value float64) int {
+			if width == 0 {
+				return 0
+			}
+			return int(math.Min((value-least)/width, float64(opts.Bins-1)))
+		}
+	default:
+		edges = opts.Edges
+		bin = func(value float64) int {
+			last := len(edges) - 1
+			if value < edges[0] || value > edges[last] {
+				return -1
+			}
+			// the last bin includes its max
+			i := sort.Search(last, func(i int) bool {
+				return value < edges[i+1]
+			})
+			return min(i, last-1)
+		}
+	}
+
+	h.Bins = make([]domain.HistogramBin, len(edges)-1)
+	for i := range h.Bins {
+		h.Bins[i] = domain.HistogramBin{Min: edges[i], Max: edges[i+1]}
+		if opts.SplitBy != "" {
+			h.Bins[i].Split = make(map[string]int)
+		}
+	}
+	for _, v := range vehicles {
+		i := bin(v.Number(opts.Field))
+		if i < 0 {
+			h.Outside++
+			continue
+		}
+		h.Bins[i].Count++
+		if opts.SplitBy != "" {
+			h.Bins[i].Split[v.Text(opts.SplitBy)]++
+		}
+	}
+	return
+}
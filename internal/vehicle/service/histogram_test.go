@@ -0,0 +1,144 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestComputeHistogram(t *testing.T) {
+	all := fleetTest(1, 2, 3, 4, 5, 6)
+	tests := []struct {
+		name     string
+		vehicles []*domain.Vehicle
+		opts     domain.HistogramOptions
+		// edges are the limits of the bins, counts their counts.
+		edges   []float64
+		counts  []int
+		outside int
+		err     error
+	}{
+		{
+			name: "width", vehicles: all,
+			opts:   domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 10},
+			edges:  []float64{1990, 2000, 2010, 2020, 2030},
+			counts: []int{1, 2, 2, 1},
+		},
+		{
+			name: "width with origin", vehicles: all,
+			opts:   domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 10, Origin: 5},
+			edges:  []float64{1995, 2005, 2015, 2025},
+			counts: []int{2, 2, 2},
+		},
+		{
+			name: "bins", vehicles: all,
+			opts:   domain.HistogramOptions{Field: domain.VehicleFieldWeight, Bins: 2},
+			edges:  []float64{900, 1500, 2100},
+			counts: []int{4, 2},
+		},
+		{
+			name: "bins of a single value", vehicles: fleetTest(1, 2),
+			opts:   domain.HistogramOptions{Field: domain.VehicleFieldPassengers, Bins: 3},
+			edges:  []float64{5, 5, 5, 5},
+			counts: []int{2, 0, 0},
+		},
+		{
+			name: "edges", vehicles: all,
+			opts:    domain.HistogramOptions{Field: domain.VehicleFieldMaxSpeed, Edges: []float64{160, 200, 250}},
+			edges:   []float64{160, 200, 250},
+			counts:  []int{3, 2},
+			outside: 1,
+		},
+		{
+			name: "no vehicles", vehicles: nil,
+			opts:   domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 10},
+			edges:  nil,
+			counts: []int{},
+		},
+		{
+			name: "too many bins", vehicles: all,
+			opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 0.01},
+			err:  ErrServiceVehicleInvalidHistogram,
+		},
+		{
+			// the bins overflow to infinity, they used to make a slice of a NaN length and panic
+			name: "subnormal width", vehicles: all,
+			opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 1e-320},
+			err:  ErrServiceVehicleInvalidHistogram,
+		},
+		{
+			name: "subnormal width of a single value", vehicles: fleetTest(1),
+			opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 5e-324},
+			err:  ErrServiceVehicleInvalidHistogram,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := computeHistogram(tt.vehicles, tt.opts)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("computeHistogram returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			var edges []float64
+			counts := make([]int, 0, len(h.Bins))
+			for i, b := range h.Bins {
+				if i == 0 {
+					edges = append(edges, b.Min)
+				}
+				edges = append(edges, b.Max)
+				counts = append(counts, b.Count)
+			}
+			if !reflect.DeepEqual(edges, tt.edges) || !reflect.DeepEqual(counts, tt.counts) {
+				t.Errorf("computeHistogram returned bins %v with counts %v, want %v with %v", edges, counts, tt.edges, tt.counts)
+			}
+			if h.Outside != tt.outside || h.Count != len(tt.vehicles) {
+				t.Errorf("computeHistogram returned %d vehicles with %d outside, want %d with %d", h.Count, h.Outside, len(tt.vehicles), tt.outside)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetHistogram(t *testing.T) {
+	sv, _ := newServiceTest(t)
+	tests := []struct {
+		name   string
+		filter string
+		opts   domain.HistogramOptions
+		// bins is the number of bins, split the split of the first bin.
+		bins  int
+		split map[string]int
+		err   error
+	}{
+		{name: "default decades of year", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear}, bins: 4},
+		{name: "default bins", opts: domain.HistogramOptions{Field: domain.VehicleFieldWeight}, bins: HistogramBins},
+		{name: "filter and split", filter: "brand = Ford", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 10, SplitBy: domain.VehicleFieldColor}, bins: 2, split: map[string]int{"Red": 1, "Blue": 1}},
+		{name: "unknown field", opts: domain.HistogramOptions{Field: domain.VehicleFieldBrand}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "unknown split by", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, SplitBy: domain.VehicleFieldYear}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "width and bins", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: 10, Bins: 3}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "negative width", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Width: -1}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "too many bins", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Bins: HistogramMaxBins + 1}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "single edge", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Edges: []float64{2000}}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "edges not increasing", opts: domain.HistogramOptions{Field: domain.VehicleFieldYear, Edges: []float64{2000, 2000}}, err: ErrServiceVehicleInvalidHistogram},
+		{name: "subnormal width", opts: domain.HistogramOptions{Field: domain.VehicleFieldWeight, Width: 1e-320}, err: ErrServiceVehicleInvalidHistogram},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, err := sv.GetHistogram(tt.filter, tt.opts)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetHistogram returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(h.Bins) != tt.bins {
+				t.Errorf("GetHistogram returned %d bins, want %d", len(h.Bins), tt.bins)
+			}
+			if tt.split != nil && !reflect.DeepEqual(h.Bins[0].Split, tt.split) {
+				t.Errorf("GetHistogram split the first bin as %v, want %v", h.Bins[0].Split, tt.split)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"sort"
+	"strings"
+)
+
+// ParseSort returns the sort keys of a sort expression: a comma separated list of fields,
+// each optionally followed by :asc or :desc, e.g. "year:desc,brand".
+func ParseSort(expr string) (keys []domain.SortKey, err error) {
+	if strings.TrimSpace(expr) == "" {
+		return
+	}
+
+	for _, part := range strings.Split(expr, ",") {
+		name, order, _ := strings.Cut(strings.TrimSpace(part), ":")
+		field, ok := domain.ParseVehicleField(name)
+		if !ok {
+			err = fmt.Errorf("%w. unknown sort field %q", ErrServiceVehicleInvalidPage, name)
+			return
+		}
+
+		key := domain.SortKey{Field: field}
+		switch strings.ToLower(order) {
+		case "", "asc":
+		case "desc":
+			key.Desc = true
+		default:
+			err = fmt.Errorf("%w. unknown sort order %q of %s", ErrServiceVehicleInvalidPage, order, field)
+			return
+		}
+		keys = append(keys, key)
+	}
+	return
+}
+
+// Paginate sorts the vehicles and returns the page selected by the options.
+// The order is deterministic: vehicles with the same sort values are sorted by id.
+func (s *ServiceVehicleDefault) Paginate(vehicles []*domain.Vehicle, opts domain.PageOptions) (p domain.Page, err error) {
+	if opts.Offset < 0 || opts.Limit < 0 {
+		err = fmt.Errorf("%w. offset and limit can't be negative", ErrServiceVehicleInvalidPage)
+		return
+	}
+	if opts.Cursor != "" && opts.Offset > 0 {
+		err = fmt.Errorf("%w. offset can't be used with a cursor", ErrServiceVehicleInvalidPage)
+		return
+	}
+
+	// sort
+	sorted := make([]*domain.Vehicle, len(vehicles))
+	copy(sorted, vehicles)
+	sort.Slice(sorted, func(i, j int) bool {
+		return compareSortValues(sortValues(sorted[i], opts.Sort), sortValues(sorted[j], opts.Sort), opts.Sort) < 0
+	})
+
+	// select
+	n := len(sorted)
+	start, end := opts.Offset, n
+	if opts.Cursor != "" {
+		var c pageCursor
+		if c, err = decodePageCursor(opts.Cursor, opts.Sort); err != nil {
+			return
+		}
+		// the boundary is the first vehicle after the cursor, or not before it for the previous page
+		boundary := sort.Search(n, func(i int) bool {
+			cmp := compareSortValues(sortValues(sorted[i], opts.Sort), c.Values, opts.Sort)
+			return cmp > 0 || (cmp == 0 && c.Prev)
+		})
+		start = boundary
+		if c.Prev {
+			start, end = max(0, boundary-opts.Limit), boundary
+			if opts.Limit == 0 {
+				start = 0
+			}
+		}
+	}
+	start = min(start, n)
+	if opts.Limit > 0 {
+		end = min(end, start+opts.Limit)
+	}
+
+	p = domain.Page{Vehicles: sorted[start:end], Total: n, Offset: start, Limit: opts.Limit}
+	if p.HasNext() && len(p.Vehicles) > 0 {
+		p.NextCursor = encodePageCursor(pageCursor{Values: sortValues(p.Vehicles[len(p.Vehicles)-1], opts.Sort)}, opts.Sort)
+	}
+	if p.HasPrev() && n > 0 {
+		var values []any
+		if len(p.Vehicles) > 0 {
+			values = sortValues(p.Vehicles[0], opts.Sort)
+		} else {
+			// past the end of the list the previous page ends right after the last vehicle
+			values = sortValues(sorted[n-1], opts.Sort)
+			values[len(values)-1] = values[len(values)-1].(float64) + 1
+		}
+		p.PrevCursor = encodePageCursor(pageCursor{Values: values, Prev: true}, opts.Sort)
+	}
+	return
+}
+
+// pageCursor is the position a page starts after, or ends before when Prev is set.
+type pageCursor struct {
+	// Sort is the sort expression of the list, so a cursor is not used with another order.
+	Sort string `json:"s"`
+	// Values are the values of the sort fields and the id of the vehicle at the position.
+	Values []any `json:"v"`
+	// Prev is whether the cursor points to the previous page.
+	Prev bool `json:"p,omitempty"`
+}
+
+// encodePageCursor returns the opaque text of a cursor.
+func encodePageCursor(c pageCursor, keys []domain.SortKey) string {
+	c.Sort = sortString(keys)
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodePageCursor returns the cursor of an opaque text, checking it belongs to a list with the same sort.
+func decodePageCursor(text string, keys []domain.SortKey) (c pageCursor, err error) {
+	b, err := base64.RawURLEncoding.DecodeString(text)
+	if err == nil {
+		err = json.Unmarshal(b, &c)
+	}
+	if err != nil || len(c.Values) != len(keys)+1 {
+		err = fmt.Errorf("%w. invalid cursor", ErrServiceVehicleInvalidPage)
+		return
+	}
+	if c.Sort != sortString(keys) {
+		err = fmt.Errorf("%w. the cursor belongs to a list sorted by %q", ErrServiceVehicleInvalidPage, c.Sort)
+		return
+	}
+	for i, key := range keys {
+		if _, isNumber := c.Values[i].(float64); isNumber != key.Field.IsNumeric() {
+			err = fmt.Errorf("%w. invalid cursor", ErrServiceVehicleInvalidPage)
+			return
+		}
+	}
+	if _, ok := c.Values[len(keys)].(float64); !ok {
+		err = fmt.Errorf("%w. invalid cursor", ErrServiceVehicleInvalidPage)
+		return
+	}
+	return
+}
+
+// sortString returns the sort expression of the keys.
+func sortString(keys []domain.SortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, key := range keys {
+		order := "asc"
+		if key.Desc {
+			order = "desc"
+		}
+		parts = append(parts, string(key.Field)+":"+order)
+	}
+	return strings.Join(parts, ",")
+}
+
+// sortValues returns the values of the sort fields of the vehicle followed by its id.
+func sortValues(v *domain.Vehicle, keys []domain.SortKey) []any {
+	values := make([]any, 0, len(keys)+1)
+	for _, key := range keys {
+		if key.Field.IsNumeric() {
+			values = append(values, v.Number(key.Field))
+		} else {
+			values = append(values, v.Text(key.Field))
+		}
+	}
+	return append(values, float64(v.Id))
+}
+
+// compareSortValues compares the values of two vehicles returned by sortValues, returning -1, 0 or +1.
+// Text is compared ignoring case first, so the order doesn't depend on capitalization.
+func compareSortValues(a, b []any, keys []domain.SortKey) int {
+	for i := range a {
+		var cmp int
+		switch va := a[i].(type) {
+		case float64:
+			vb := b[i].(float64)
+			switch {
+			case va < vb:
+				cmp = -1
+			case va > vb:
+				cmp = 1
+			}
+		case string:
+			vb := b[i].(string)
+			if cmp = strings.Compare(strings.ToLower(va), strings.ToLower(vb)); cmp == 0 {
+				cmp = strings.Compare(va, vb)
+			}
+		}
+		if i < len(keys) && keys[i].Desc {
+			cmp = -cmp
+		}
+		if cmp != 0 {
+			return cmp
+		}
+	}
+	return 0
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+// pageIdsTest returns the ids of the vehicles of a page.
+func pageIdsTest(p domain.Page) []int {
+	ids := make([]int, 0, len(p.Vehicles))
+	for _, v := range p.Vehicles {
+		ids = append(ids, v.Id)
+	}
+	return ids
+}
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []domain.SortKey
+		err  error
+	}{
+		{expr: "", want: nil},
+		{expr: "year", want: []domain.SortKey{{Field: domain.VehicleFieldYear}}},
+		{expr: "year:DESC, brand:asc", want: []domain.SortKey{{Field: domain.VehicleFieldYear, Desc: true}, {Field: domain.VehicleFieldBrand}}},
+		{expr: "wheels", err: ErrServiceVehicleInvalidPage},
+		{expr: "year:up", err: ErrServiceVehicleInvalidPage},
+		{expr: "year,", err: ErrServiceVehicleInvalidPage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			keys, err := ParseSort(tt.expr)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseSort returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(keys, tt.want) {
+				t.Errorf("ParseSort returned %v, want %v", keys, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_Paginate(t *testing.T) {
+	sv, _ := newServiceTest(t)
+	vehicles := fleetTest(6, 5, 4, 3, 2, 1)
+	byYear := []domain.SortKey{{Field: domain.VehicleFieldYear, Desc: true}}
+	byBrand := []domain.SortKey{{Field: domain.VehicleFieldBrand}}
+
+	tests := []struct {
+		name string
+		opts domain.PageOptions
+		ids  []int
+		// next and prev are whether the page has them.
+		next, prev bool
+		err        error
+	}{
+		{name: "by id", opts: domain.PageOptions{}, ids: []int{1, 2, 3, 4, 5, 6}},
+		{name: "by year descending", opts: domain.PageOptions{Sort: byYear}, ids: []int{5, 3, 4, 2, 1, 6}},
+		{name: "ties by id", opts: domain.PageOptions{Sort: byBrand}, ids: []int{6, 1, 2, 3, 4, 5}},
+		{name: "limit", opts: domain.PageOptions{Sort: byYear, Limit: 2}, ids: []int{5, 3}, next: true},
+		{name: "offset", opts: domain.PageOptions{Sort: byYear, Offset: 2, Limit: 2}, ids: []int{4, 2}, next: true, prev: true},
+		{name: "last page", opts: domain.PageOptions{Offset: 4, Limit: 4}, ids: []int{5, 6}, prev: true},
+		{name: "past the end", opts: domain.PageOptions{Offset: 10, Limit: 2}, ids: []int{}, prev: true},
+		{name: "negative offset", opts: domain.PageOptions{Offset: -1}, err: ErrServiceVehicleInvalidPage},
+		{name: "negative limit", opts: domain.PageOptions{Limit: -1}, err: ErrServiceVehicleInvalidPage},
+		{name: "offset and cursor", opts: domain.PageOptions{Offset: 1, Cursor: "x"}, err: ErrServiceVehicleInvalidPage},
+		{name: "malformed cursor", opts: domain.PageOptions{Cursor: "not a cursor"}, err: ErrServiceVehicleInvalidPage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := sv.Paginate(vehicles, tt.opts)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Paginate returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if ids := pageIdsTest(p); !reflect.DeepEqual(ids, tt.ids) {
+				t.Errorf("Paginate returned %v, want %v", ids, tt.ids)
+			}
+			if (p.NextCursor != "") != tt.next || (p.PrevCursor != "") != tt.prev || p.Total != len(vehicles) {
+				t.Errorf("Paginate returned a page of %d with next %q and prev %q", p.Total, p.NextCursor, p.PrevCursor)
+			}
+		})
+	}
+}
+
+// TestServiceVehicleDefault_PaginateCursor walks the pages forwards and backwards with their cursors.
+func TestServiceVehicleDefault_PaginateCursor(t *testing.T) {
+	sv, _ := newServiceTest(t)
+	vehicles := fleetTest(1, 2, 3, 4, 5, 6)
+	sortKeys := []domain.SortKey{{Field: domain.VehicleFieldBrand, Desc: true}, {Field: domain.VehicleFieldMaxSpeed}}
+	want := [][]int{{5, 4}, {1, 2}, {3, 6}}
+
+	p, err := sv.Paginate(vehicles, domain.PageOptions{Sort: sortKeys, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		if ids := pageIdsTest(p); !reflect.DeepEqual(ids, want[i]) {
+			t.Fatalf("page %d has %v, want %v", i, ids, want[i])
+		}
+		if p.NextCursor == "" {
+			if i != len(want)-1 {
+				t.Fatalf("page %d has no next cursor", i)
+			}
+			break
+		}
+		if p, err = sv.Paginate(vehicles, domain.PageOptions{Sort: sortKeys, Limit: 2, Cursor: p.NextCursor}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := len(want) - 2; i >= 0; i-- {
+		if p.PrevCursor == "" {
+			t.Fatalf("page %d has no previous cursor", i+1)
+		}
+		if p, err = sv.Paginate(vehicles, domain.PageOptions{Sort: sortKeys, Limit: 2, Cursor: p.PrevCursor}); err != nil {
+			t.Fatal(err)
+		}
+		if ids := pageIdsTest(p); !reflect.DeepEqual(ids, want[i]) {
+			t.Fatalf("page %d has %v, want %v", i, ids, want[i])
+		}
+	}
+	if p.PrevCursor != "" {
+		t.Errorf("the first page has a previous cursor")
+	}
+
+	// a cursor only works with the sort it was returned with
+	next, err := sv.Paginate(vehicles, domain.PageOptions{Sort: sortKeys, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = sv.Paginate(vehicles, domain.PageOptions{Limit: 2, Cursor: next.NextCursor})
+	if !errors.Is(err, ErrServiceVehicleInvalidPage) {
+		t.Errorf("Paginate with a cursor of another sort returned %v, want %v", err, ErrServiceVehicleInvalidPage)
+	}
+}
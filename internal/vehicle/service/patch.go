@@ -0,0 +1,242 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// Update applies the patch to the vehicle with the id when its version is the given one, any if it is 0,
+// and returns the updated vehicle. The patched attributes must follow the domain rules of the vehicles,
+// otherwise ErrServiceVehicleInvalidAttributes is returned and nothing changes.
+func (s *ServiceVehicleDefault) Update(id int, version int, patch domain.Patch) (*domain.Vehicle, error) {
+	var apply func(doc map[string]any) (map[string]any, error)
+	switch patch.Format {
+	case domain.PatchFormatMerge:
+		var p any
+		if err := json.Unmarshal(patch.Document, &p); err != nil {
+			return nil, fmt.Errorf("%w. %v", ErrServiceVehicleInvalidPatch, err)
+		}
+		apply = func(doc map[string]any) (map[string]any, error) {
+			patched, ok := mergePatch(doc, p).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%w. the merge patch must be an object", ErrServiceVehicleInvalidPatch)
+			}
+			return patched, nil
+		}
+	case domain.PatchFormatJSON:
+		var ops []operationPatch
+		if err := json.Unmarshal(patch.Document, &ops); err != nil {
+			return nil, fmt.Errorf("%w. %v", ErrServiceVehicleInvalidPatch, err)
+		}
+		apply = func(doc map[string]any) (map[string]any, error) {
+			return jsonPatch(doc, ops)
+		}
+	default:
+		return nil, fmt.Errorf("%w. unknown patch format %q", ErrServiceVehicleInvalidPatch, patch.Format)
+	}
+
+	// the errors of the patch are kept apart from the ones of the storage, which are adapted
+	var errPatch error
+	v, err := s.rp.Update(id, version, func(a *domain.VehicleAttributes) error {
+		current := &domain.Vehicle{Id: id, Attributes: *a}
+		doc, err := apply(patchDocument(current))
+		if err == nil {
+			err = patchVehicle(current, doc)
+		}
+		if err == nil {
+			err = validateAttributes(current.Attributes)
+		}
+		if err != nil {
+			errPatch = err
+			return err
+		}
+		*a = current.Attributes
+		return nil
+	})
+	if err != nil {
+		if errPatch != nil {
+			return nil, errPatch
+		}
+		return nil, s.errAdapter(err)
+	}
+	return v, nil
+}
+
+// validateAttributes returns ErrServiceVehicleInvalidAttributes with the rules the attributes break, if any.
+func validateAttributes(a domain.VehicleAttributes) error {
+	errs := a.Validate()
+	if len(errs) == 0 {
+		return nil
+	}
+	messages := make([]string, 0, len(errs))
+	for _, e := range errs {
+		messages = append(messages, e.Error())
+	}
+	return fmt.Errorf("%w. %s", ErrServiceVehicleInvalidAttributes, strings.Join(messages, "; "))
+}
+
+// patchDocument returns the vehicle as the json object the patches apply to.
+func patchDocument(v *domain.Vehicle) map[string]any {
+	doc := make(map[string]any, len(domain.VehicleFields))
+	for _, f := range domain.VehicleFields {
+		if f.IsNumeric() {
+			doc[string(f)] = v.Number(f)
+		} else {
+			doc[string(f)] = v.Text(f)
+		}
+	}
+	return doc
+}
+
+// patchVehicle sets the fields of the vehicle from a patched document, which must have all of them with
+// values of their type, and the same id.
+func patchVehicle(v *domain.Vehicle, doc map[string]any) error {
+	for name := range doc {
+		if _, ok := domain.ParseVehicleField(name); !ok || name != strings.ToLower(name) {
+			return fmt.Errorf("%w. unknown field %q", ErrServiceVehicleInvalidPatch, name)
+		}
+	}
+	for _, f := range domain.VehicleFields {
+		value, ok := doc[string(f)]
+		if !ok || value == nil {
+			return fmt.Errorf("%w. %s can't be removed", ErrServiceVehicleInvalidPatch, f)
+		}
+		if !f.IsNumeric() {
+			text, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%w. %s must be a string", ErrServiceVehicleInvalidPatch, f)
+			}
+			v.SetText(f, text)
+			continue
+		}
+
+		n, ok := value.(float64)
+		if !ok {
+			return fmt.Errorf("%w. %s must be a number", ErrServiceVehicleInvalidPatch, f)
+		}
+		integer := f != domain.VehicleFieldHeight && f != domain.VehicleFieldWidth && f != domain.VehicleFieldWeight
+		if integer && (n != math.Trunc(n) || math.Abs(n) > math.MaxInt32) {
+			return fmt.Errorf("%w. %s must be an integer", ErrServiceVehicleInvalidPatch, f)
+		}
+		if f == domain.VehicleFieldId {
+			if int(n) != v.Id {
+				return fmt.Errorf("%w. id can't be changed", ErrServiceVehicleInvalidPatch)
+			}
+			continue
+		}
+		v.SetNumber(f, n)
+	}
+	return nil
+}
+
+// mergePatch returns the target with the merge patch applied, as RFC 7396 sets.
+func mergePatch(target any, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for name, value := range p {
+		if value == nil {
+			delete(t, name)
+			continue
+		}
+		t[name] = mergePatch(t[name], value)
+	}
+	return t
+}
+
+// operationPatch is an operation of a JSON Patch.
+type operationPatch struct {
+	Op   string `json:"op"`
+	Path string `json:"path"`
+	From string `json:"from"`
+	// Value is nil when the operation has no value, and the json null when it is null.
+	Value json.RawMessage `json:"value"`
+}
+
+// jsonPatch returns a copy of the document with the operations applied in order, as RFC 6902 sets.
+// As the values of the document are scalars, paths can only point to its members.
+func jsonPatch(doc map[string]any, ops []operationPatch) (map[string]any, error) {
+	patched := make(map[string]any, len(doc))
+	for name, value := range doc {
+		patched[name] = value
+	}
+
+	for i, op := range ops {
+		path, err := memberPatch(op.Path)
+		if err != nil {
+			return nil, fmt.Errorf("%w. operation %d: path %v", ErrServiceVehicleInvalidPatch, i, err)
+		}
+		var value any
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return nil, fmt.Errorf("%w. operation %d: %s needs a value", ErrServiceVehicleInvalidPatch, i, op.Op)
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return nil, fmt.Errorf("%w. operation %d: %v", ErrServiceVehicleInvalidPatch, i, err)
+			}
+		case "move", "copy":
+			from, err := memberPatch(op.From)
+			if err != nil {
+				return nil, fmt.Errorf("%w. operation %d: from %v", ErrServiceVehicleInvalidPatch, i, err)
+			}
+			var ok bool
+			if value, ok = patched[from]; !ok {
+				return nil, fmt.Errorf("%w. operation %d: from %q doesn't exist", ErrServiceVehicleInvalidPatch, i, op.From)
+			}
+			if op.Op == "move" {
+				delete(patched, from)
+			}
+		case "remove":
+		default:
+			return nil, fmt.Errorf("%w. operation %d: unknown op %q", ErrServiceVehicleInvalidPatch, i, op.Op)
+		}
+
+		current, exists := patched[path]
+		switch op.Op {
+		case "add", "move", "copy":
+			patched[path] = value
+		case "replace", "remove":
+			if !exists {
+				return nil, fmt.Errorf("%w. operation %d: path %q doesn't exist", ErrServiceVehicleInvalidPatch, i, op.Path)
+			}
+			if op.Op == "remove" {
+				delete(patched, path)
+			} else {
+				patched[path] = value
+			}
+		case "test":
+			if !exists || !reflect.DeepEqual(current, value) {
+				return nil, fmt.Errorf("%w. operation %d: %s is %s", ErrServiceVehiclePatchTestFailed, i, op.Path, jsonValue(current))
+			}
+		}
+	}
+	return patched, nil
+}
+
+// memberPatch returns the member of the document a json pointer points to, which must be a single reference token.
+func memberPatch(pointer string) (string, error) {
+	if !strings.HasPrefix(pointer, "/") || strings.Count(pointer, "/") != 1 {
+		return "", fmt.Errorf("%q must point to a field of the vehicle, e.g. /color", pointer)
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(pointer[1:]), nil
+}
+
+// jsonValue returns the json of a value of a document, for error messages.
+func jsonValue(value any) string {
+	var b bytes.Buffer
+	if err := json.NewEncoder(&b).Encode(value); err != nil {
+		return fmt.Sprint(value)
+	}
+	return strings.TrimSpace(b.String())
+}
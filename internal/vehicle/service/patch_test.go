@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"reflect"
+	"testing"
+)
+
+func TestServiceVehicleDefault_Update(t *testing.T) {
+	merge := func(doc string) domain.Patch {
+		return domain.Patch{Format: domain.PatchFormatMerge, Document: []byte(doc)}
+	}
+	jsonPatch := func(doc string) domain.Patch {
+		return domain.Patch{Format: domain.PatchFormatJSON, Document: []byte(doc)}
+	}
+
+	tests := []struct {
+		name    string
+		id      int
+		version int
+		patch   domain.Patch
+		// change changes the attributes of vehiclesTest as the patch does.
+		change func(a *domain.VehicleAttributes)
+		err    error
+	}{
+		// RFC 7396 merge patches
+		{name: "merge", id: 1, patch: merge(`{"color":"Blue","max_speed":190}`),
+			change: func(a *domain.VehicleAttributes) { a.Color, a.MaxSpeed = "Blue", 190 }},
+		{name: "empty merge", id: 1, patch: merge(`{}`), change: func(a *domain.VehicleAttributes) {}},
+		{name: "merge of the same id", id: 1, patch: merge(`{"id":1,"weight":1150.5}`),
+			change: func(a *domain.VehicleAttributes) { a.Weight = 1150.5 }},
+		{name: "merge of the version", id: 1, version: 1, patch: merge(`{"color":"Blue"}`),
+			change: func(a *domain.VehicleAttributes) { a.Color = "Blue" }},
+		{name: "merge removing a field", id: 1, patch: merge(`{"color":null}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of an unknown field", id: 1, patch: merge(`{"colour":"Blue"}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of a field in upper case", id: 1, patch: merge(`{"Color":"Blue"}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of a string for a number", id: 1, patch: merge(`{"year":"2002"}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of a number for a string", id: 1, patch: merge(`{"color":1}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of a fraction for an integer", id: 1, patch: merge(`{"passengers":4.5}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of another id", id: 1, patch: merge(`{"id":2}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge of an array", id: 1, patch: merge(`["color"]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge not json", id: 1, patch: merge(`color=Blue`), err: ErrServiceVehicleInvalidPatch},
+		{name: "merge breaking a domain rule", id: 1, patch: merge(`{"fuel_type":"steam"}`), err: ErrServiceVehicleInvalidAttributes},
+		{name: "merge of a year too old", id: 1, patch: merge(`{"year":1800}`), err: ErrServiceVehicleInvalidAttributes},
+
+		// RFC 6902 JSON patches
+		{name: "replace", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/color","value":"Blue"}]`),
+			change: func(a *domain.VehicleAttributes) { a.Color = "Blue" }},
+		{name: "test before replace", id: 1, patch: jsonPatch(`[{"op":"test","path":"/year","value":2001},{"op":"replace","path":"/year","value":2002}]`),
+			change: func(a *domain.VehicleAttributes) { a.Year = 2002 }},
+		{name: "add of an existing field", id: 1, patch: jsonPatch(`[{"op":"add","path":"/color","value":"Blue"}]`),
+			change: func(a *domain.VehicleAttributes) { a.Color = "Blue" }},
+		{name: "remove and add", id: 1, patch: jsonPatch(`[{"op":"remove","path":"/color"},{"op":"add","path":"/color","value":"Blue"}]`),
+			change: func(a *domain.VehicleAttributes) { a.Color = "Blue" }},
+		{name: "copy", id: 1, patch: jsonPatch(`[{"op":"copy","from":"/brand","path":"/model"}]`),
+			change: func(a *domain.VehicleAttributes) { a.Model = "Ford" }},
+		{name: "no operations", id: 1, patch: jsonPatch(`[]`), change: func(a *domain.VehicleAttributes) {}},
+		{name: "failed test", id: 1, patch: jsonPatch(`[{"op":"test","path":"/year","value":2002}]`), err: ErrServiceVehiclePatchTestFailed},
+		{name: "test of a string for a number", id: 1, patch: jsonPatch(`[{"op":"test","path":"/year","value":"2001"}]`), err: ErrServiceVehiclePatchTestFailed},
+		// the operations are applied all or none
+		{name: "failed test after replace", id: 1,
+			patch: jsonPatch(`[{"op":"replace","path":"/color","value":"Blue"},{"op":"test","path":"/color","value":"Red"}]`),
+			err:   ErrServiceVehiclePatchTestFailed},
+		{name: "remove", id: 1, patch: jsonPatch(`[{"op":"remove","path":"/color"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "move", id: 1, patch: jsonPatch(`[{"op":"move","from":"/brand","path":"/model"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "replace of a null", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/color","value":null}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "replace without a value", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/color"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "replace of an unknown field", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/wheels","value":4}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "add of an unknown field", id: 1, patch: jsonPatch(`[{"op":"add","path":"/wheels","value":4}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "nested path", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/color/name","value":"Blue"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "copy from a missing field", id: 1, patch: jsonPatch(`[{"op":"copy","from":"/wheels","path":"/color"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "unknown op", id: 1, patch: jsonPatch(`[{"op":"append","path":"/color","value":"Blue"}]`), err: ErrServiceVehicleInvalidPatch},
+		{name: "json patch of an object", id: 1, patch: jsonPatch(`{"op":"replace","path":"/color","value":"Blue"}`), err: ErrServiceVehicleInvalidPatch},
+		{name: "json patch breaking a domain rule", id: 1, patch: jsonPatch(`[{"op":"replace","path":"/transmission","value":"cvt"}]`),
+			err: ErrServiceVehicleInvalidAttributes},
+
+		{name: "stale version", id: 1, version: 2, patch: merge(`{"color":"Blue"}`), err: ErrServiceVehicleVersionConflict},
+		{name: "missing vehicle", id: 99, patch: merge(`{"color":"Blue"}`), err: ErrServiceVehicleNotFound},
+		{name: "unknown format", id: 1, patch: domain.Patch{Format: "xml", Document: []byte(`<color>Blue</color>`)}, err: ErrServiceVehicleInvalidPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			v, err := sv.Update(tt.id, tt.version, tt.patch)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Update returned %v, want %v", err, tt.err)
+			}
+
+			stored, errGet := rp.GetById(tt.id)
+			if err != nil {
+				if errGet == nil && (stored.Version != 1 || stored.Attributes != vehiclesTest[tt.id]) {
+					t.Errorf("Update failed but changed the vehicle to %+v", stored)
+				}
+				return
+			}
+			want := vehiclesTest[tt.id]
+			tt.change(&want)
+			if v.Attributes != want || v.Version != 2 {
+				t.Errorf("Update returned %+v version %d, want %+v version 2", v.Attributes, v.Version, want)
+			}
+			if errGet != nil || stored.Attributes != want {
+				t.Errorf("the stored vehicle is %+v, want %+v", stored, want)
+			}
+		})
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	// the examples of RFC 7396 beyond the flat documents of vehicles
+	tests := []struct {
+		name   string
+		target any
+		patch  any
+		want   any
+	}{
+		{name: "replace", target: map[string]any{"a": "b"}, patch: map[string]any{"a": "c"}, want: map[string]any{"a": "c"}},
+		{name: "add", target: map[string]any{"a": "b"}, patch: map[string]any{"b": "c"}, want: map[string]any{"a": "b", "b": "c"}},
+		{name: "remove", target: map[string]any{"a": "b", "b": "c"}, patch: map[string]any{"a": nil}, want: map[string]any{"b": "c"}},
+		{name: "nested", target: map[string]any{"a": map[string]any{"b": "c", "d": "e"}}, patch: map[string]any{"a": map[string]any{"d": nil}},
+			want: map[string]any{"a": map[string]any{"b": "c"}}},
+		{name: "array", target: map[string]any{"a": []any{"b"}}, patch: map[string]any{"a": "c"}, want: map[string]any{"a": "c"}},
+		{name: "object over a string", target: map[string]any{"a": "foo"}, patch: map[string]any{"a": map[string]any{"b": "c"}},
+			want: map[string]any{"a": map[string]any{"b": "c"}}},
+		{name: "not an object", target: map[string]any{"a": "b"}, patch: []any{"c"}, want: []any{"c"}},
+		{name: "null", target: map[string]any{"a": "b"}, patch: nil, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergePatch(tt.target, tt.patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergePatch returned %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemberPatch(t *testing.T) {
+	tests := []struct {
+		pointer string
+		want    string
+		bad     bool
+	}{
+		{pointer: "/color", want: "color"},
+		{pointer: "/a~1b", want: "a/b"},
+		{pointer: "/a~0b", want: "a~b"},
+		{pointer: "/", want: ""},
+		{pointer: "color", bad: true},
+		{pointer: "", bad: true},
+		{pointer: "/color/name", bad: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pointer, func(t *testing.T) {
+			got, err := memberPatch(tt.pointer)
+			if (err != nil) != tt.bad {
+				t.Fatalf("memberPatch returned %v, want an error %t", err, tt.bad)
+			}
+			if got != tt.want {
+				t.Errorf("memberPatch returned %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
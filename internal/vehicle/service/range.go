@@ -0,0 +1,116 @@
+package service
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseRange returns the range of a numeric field written in any of the forms:
+//
+//	[100,200]  (100,200)  [100,200)  (100,200]  interval notation, brackets are inclusive and parentheses exclusive
+//	(200,]  [,300)                              an empty end has no limit
+//	>200  >=200  <300  <=300                    a single bound
+//	100-200                                     both ends inclusive
+//	150                                         only that value
+//
+// It returns ErrServiceVehicleInvalidRange describing what is wrong when the range can't be parsed,
+// has no bound or is empty.
+func ParseRange(s string) (r domain.Range, err error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == "":
+		err = fmt.Errorf("%w. the range is empty", ErrServiceVehicleInvalidRange)
+		return
+	case strings.HasPrefix(s, "[") || strings.HasPrefix(s, "("):
+		r, err = parseRangeInterval(s)
+	case strings.HasPrefix(s, ">=") || strings.HasPrefix(s, "<=") || strings.HasPrefix(s, ">") || strings.HasPrefix(s, "<"):
+		r, err = parseRangeComparison(s)
+	case strings.Contains(strings.TrimPrefix(s, "-"), "-"):
+		// the dash of a negative min isn't the separator
+		i := strings.Index(s[1:], "-") + 1
+		if r.Min.Value, err = parseRangeValue(s[:i], s); err != nil {
+			return
+		}
+		if r.Max.Value, err = parseRangeValue(s[i+1:], s); err != nil {
+			return
+		}
+		r.Min.Inclusive, r.Max.Inclusive = true, true
+	default:
+		if r.Min.Value, err = parseRangeValue(s, s); err != nil {
+			return
+		}
+		r.Max.Value = r.Min.Value
+		r.Min.Inclusive, r.Max.Inclusive = true, true
+	}
+	if err != nil {
+		return
+	}
+
+	switch {
+	case r.IsUnbounded():
+		err = fmt.Errorf("%w. %q has no bound", ErrServiceVehicleInvalidRange, s)
+	case r.IsEmpty():
+		err = fmt.Errorf("%w. %q is empty, the min must be less than the max", ErrServiceVehicleInvalidRange, s)
+	}
+	return
+}
+
+// parseRangeInterval parses a range in interval notation.
+func parseRangeInterval(s string) (r domain.Range, err error) {
+	last := s[len(s)-1]
+	if len(s) < 3 || (last != ']' && last != ')') {
+		err = fmt.Errorf("%w. %q must end with ] or )", ErrServiceVehicleInvalidRange, s)
+		return
+	}
+	min, max, ok := strings.Cut(s[1:len(s)-1], ",")
+	if !ok {
+		err = fmt.Errorf("%w. %q must have the min and the max separated by a comma", ErrServiceVehicleInvalidRange, s)
+		return
+	}
+
+	r.Min.Inclusive = s[0] == '['
+	r.Max.Inclusive = last == ']'
+	if r.Min.Unbounded = strings.TrimSpace(min) == ""; !r.Min.Unbounded {
+		if r.Min.Value, err = parseRangeValue(min, s); err != nil {
+			return
+		}
+	}
+	if r.Max.Unbounded = strings.TrimSpace(max) == ""; !r.Max.Unbounded {
+		if r.Max.Value, err = parseRangeValue(max, s); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// parseRangeComparison parses a range with a single bound.
+func parseRangeComparison(s string) (r domain.Range, err error) {
+	op := s[:1]
+	if strings.HasPrefix(s[1:], "=") {
+		op = s[:2]
+	}
+	value, err := parseRangeValue(s[len(op):], s)
+	if err != nil {
+		return
+	}
+
+	bound := domain.RangeBound{Value: value, Inclusive: strings.HasSuffix(op, "=")}
+	if op[0] == '>' {
+		r.Min, r.Max = bound, domain.RangeBound{Unbounded: true}
+	} else {
+		r.Min, r.Max = domain.RangeBound{Unbounded: true}, bound
+	}
+	return
+}
+
+// parseRangeValue parses a finite bound of the range s.
+func parseRangeValue(v string, s string) (f float64, err error) {
+	f, err = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		err = fmt.Errorf("%w. %q in %q is not a number", ErrServiceVehicleInvalidRange, strings.TrimSpace(v), s)
+	}
+	return
+}
@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseRange(t *testing.T) {
+	tests := []struct {
+		name string
+		s    string
+		// want is the range in interval notation.
+		want string
+		err  error
+	}{
+		{name: "inclusive interval", s: "[100,200]", want: "[100,200]"},
+		{name: "exclusive interval", s: "(100,200)", want: "(100,200)"},
+		{name: "half open interval", s: "[100,200)", want: "[100,200)"},
+		{name: "open max", s: "(200,]", want: "(200,)"},
+		{name: "open min", s: "[,300)", want: "(,300)"},
+		{name: "spaces", s: " [ 100 , 200 ] ", want: "[100,200]"},
+		{name: "greater than", s: ">200", want: "(200,)"},
+		{name: "greater or equal", s: ">=200", want: "[200,)"},
+		{name: "less than", s: "<300", want: "(,300)"},
+		{name: "less or equal", s: "<=300", want: "(,300]"},
+		{name: "dash", s: "100-200", want: "[100,200]"},
+		{name: "dash with a negative min", s: "-100-200", want: "[-100,200]"},
+		{name: "single value", s: "150.5", want: "[150.5,150.5]"},
+		{name: "empty", s: "", err: ErrServiceVehicleInvalidRange},
+		{name: "not a number", s: "heavy", err: ErrServiceVehicleInvalidRange},
+		{name: "not a number bound", s: "[100,abc]", err: ErrServiceVehicleInvalidRange},
+		{name: "NaN", s: "NaN", err: ErrServiceVehicleInvalidRange},
+		{name: "infinite bound", s: ">=Inf", err: ErrServiceVehicleInvalidRange},
+		{name: "infinite interval bound", s: "[-Inf,100]", err: ErrServiceVehicleInvalidRange},
+		{name: "unterminated interval", s: "[100,200", err: ErrServiceVehicleInvalidRange},
+		{name: "interval without comma", s: "[100]", err: ErrServiceVehicleInvalidRange},
+		{name: "no bound", s: "(,)", err: ErrServiceVehicleInvalidRange},
+		{name: "min greater than max", s: "200-100", err: ErrServiceVehicleInvalidRange},
+		{name: "empty exclusive interval", s: "[100,100)", err: ErrServiceVehicleInvalidRange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRange(tt.s)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseRange(%q) returned %v, want %v", tt.s, err, tt.err)
+			}
+			if err == nil && r.String() != tt.want {
+				t.Errorf("ParseRange(%q) returned %s, want %s", tt.s, r, tt.want)
+			}
+		})
+	}
+}
@@ -11,15 +11,50 @@ import (
 type ServiceVehicle interface {
 	// GetAll returns all vehicles
 	GetAll() (v []*domain.Vehicle, err error)
-	GetByDimensions(minLength float64, maxLength float64, minWidth float64, maxWidth float64) ([]*domain.Vehicle, error)
+	// GetByFilter returns the vehicles that match a filter expression, see ParseFilter
+	GetByFilter(filter string) (v []*domain.Vehicle, err error)
+	// GetById returns the vehicle with the id
+	GetById(id int) (*domain.Vehicle, error)
+	// Paginate sorts the vehicles and returns the page selected by the options
+	Paginate(vehicles []*domain.Vehicle, opts domain.PageOptions) (p domain.Page, err error)
+	// GetByDimensions returns the vehicles whose height and width are in the ranges
+	GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error)
 	SearchByColorAndYear(color string, year int) (v []*domain.Vehicle, err error)
-	GetByWeight(weight float64, weight2 float64) ([]*domain.Vehicle, error)
-	GetAverageCapacityByBrand(brand string) (float64, error)
-	PatchFuel(id int, fuelType string) error
+	// GetByWeight returns the vehicles whose weight is in the range
+	GetByWeight(weight domain.Range) ([]*domain.Vehicle, error)
+	// GetAverageCapacityByBrand returns the average number of passengers of the vehicles of the brand
+	GetAverageCapacityByBrand(brand string) (domain.Average, error)
+	// GetAverageByBrands returns the average of a numeric field of the vehicles of every brand
+	GetAverageByBrands(field domain.VehicleField, brands []string) ([]domain.Average, error)
+	// GetStatistics returns the statistics of the vehicles that match a filter expression, all of them if it is empty
+	GetStatistics(filter string, opts domain.StatisticsOptions) (domain.Statistics, error)
+	// GetHistogram returns the histogram of the vehicles that match a filter expression, all of them if it is empty
+	GetHistogram(filter string, opts domain.HistogramOptions) (domain.Histogram, error)
+	// Search returns the vehicles that match a full-text query, sorted by relevance
+	Search(query string) ([]domain.SearchResult, error)
+	// Suggest returns the distinct values of a field that start with a prefix, with their number of vehicles
+	Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error)
+	// GetSimilar returns the vehicles nearest to the one with the id, the nearest first
+	GetSimilar(id int, opts domain.SimilarOptions) ([]domain.SimilarResult, error)
+	// GetDuplicates returns the groups of vehicles that are duplicates of each other
+	GetDuplicates(tolerance float64) ([]domain.DuplicateGroup, error)
+	// MatchETag returns the version of the vehicle when its entity tag is among the tags, 0 for the tag *
+	MatchETag(id int, tags []string) (int, error)
+	// PatchFuel changes the fuel type of the vehicle when its version is the given one, any if it is 0
+	PatchFuel(id int, fuelType string, version int) error
+	// Put replaces the vehicle when its stored version is the one of vehicle, any if it is 0
 	Put(vehicle *domain.Vehicle) error
 	GetByTransmission(transmission string) ([]*domain.Vehicle, error)
-	Delete(id int) error
-	Batch([]*domain.Vehicle) error
+	// Update applies the patch to the vehicle when its version is the given one, any if it is 0
+	Update(id int, version int, patch domain.Patch) (*domain.Vehicle, error)
+	// Delete removes the vehicle when its version is the given one, any if it is 0
+	Delete(id int, version int) error
+	// UpdateByFilter changes the fields of the vehicles that match a filter expression, all or none, returning their ids
+	UpdateByFilter(filter string, changes map[string]any, dryRun bool) ([]int, error)
+	// DeleteByFilter removes the vehicles that match a filter expression, all or none, returning their ids
+	DeleteByFilter(filter string, dryRun bool) ([]int, error)
+	// Batch inserts the vehicles, all or none when atomic, returning the ones that fail with their error
+	Batch(vehicles []*domain.Vehicle, atomic bool) ([]domain.BatchFailure, error)
 	Post(*domain.Vehicle) error
 }
 
@@ -30,4 +65,49 @@ var (
 	// ErrServiceVehicleNotFound is returned when no vehicle is found.
 	ErrServiceVehicleNotFound = errors.New("service: vehicle not found")
 	ErrServiceVIdInUse        = errors.New("service: identifier already in use")
+
+	// ErrServiceVehicleInvalidFilter is returned when a filter expression can't be parsed.
+	ErrServiceVehicleInvalidFilter = errors.New("service: invalid filter")
+
+	// ErrServiceVehicleInvalidPage is returned when the sort or pagination options are wrong.
+	ErrServiceVehicleInvalidPage = errors.New("service: invalid page")
+
+	// ErrServiceVehicleInvalidRange is returned when a range can't be parsed or is empty.
+	ErrServiceVehicleInvalidRange = errors.New("service: invalid range")
+
+	// ErrServiceVehicleInvalidStatistics is returned when the statistics options are wrong.
+	ErrServiceVehicleInvalidStatistics = errors.New("service: invalid statistics")
+
+	// ErrServiceVehicleInvalidHistogram is returned when the histogram options are wrong.
+	ErrServiceVehicleInvalidHistogram = errors.New("service: invalid histogram")
+
+	// ErrServiceVehicleInvalidSearch is returned when a search query has nothing to search.
+	ErrServiceVehicleInvalidSearch = errors.New("service: invalid search")
+
+	// ErrServiceVehicleInvalidSuggest is returned when the field of the suggestions or its brand are wrong.
+	ErrServiceVehicleInvalidSuggest = errors.New("service: invalid suggest")
+
+	// ErrServiceVehicleInvalidSimilar is returned when the options to find similar vehicles are wrong.
+	ErrServiceVehicleInvalidSimilar = errors.New("service: invalid similar")
+
+	// ErrServiceVehicleInvalidDuplicates is returned when the options to find duplicated vehicles are wrong.
+	ErrServiceVehicleInvalidDuplicates = errors.New("service: invalid duplicates")
+
+	// ErrServiceVehicleVersionConflict is returned when a vehicle changed since the version a write expects.
+	ErrServiceVehicleVersionConflict = errors.New("service: vehicle version conflict")
+
+	// ErrServiceVehicleInvalidPatch is returned when a patch document is malformed or can't be applied.
+	ErrServiceVehicleInvalidPatch = errors.New("service: invalid patch")
+
+	// ErrServiceVehiclePatchTestFailed is returned when a test operation of a JSON Patch fails.
+	ErrServiceVehiclePatchTestFailed = errors.New("service: patch test failed")
+
+	// ErrServiceVehicleInvalidAttributes is returned when the attributes of a vehicle break the domain rules.
+	ErrServiceVehicleInvalidAttributes = errors.New("service: invalid vehicle attributes")
+
+	// ErrServiceVehicleDuplicate is returned when a vehicle duplicates a stored one.
+	ErrServiceVehicleDuplicate = errors.New("service: duplicated vehicle")
+
+	// ErrServiceVehicleInvalidBulk is returned when the filter or the changes of a bulk operation are wrong.
+	ErrServiceVehicleInvalidBulk = errors.New("service: invalid bulk operation")
 )
@@ -1,14 +1,19 @@
 package service
 
 import (
+	"fmt"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
 	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/search"
+	"strings"
 )
 
 // ServiceVehicleDefault is an struct that represents a vehicle service.
 type ServiceVehicleDefault struct {
 	rp         repository.RepositoryVehicle
 	errAdapter ServiceErrorAdapter
+	// duplicates is the tolerance of the dimensions to reject duplicated vehicles, nil not to reject them.
+	duplicates *float64
 }
 
 type ServiceErrorAdapter func(error) error
@@ -31,8 +36,32 @@ func (s *ServiceVehicleDefault) GetAll() ([]*domain.Vehicle, error) {
 	return v, err
 }
 
-func (s *ServiceVehicleDefault) GetByDimensions(minHeight float64, maxHeight float64, minWidth float64, maxWidth float64) ([]*domain.Vehicle, error) {
-	v, err := s.rp.GetByDimensions(minHeight, maxHeight, minWidth, maxWidth)
+// GetById returns the vehicle with the id.
+func (s *ServiceVehicleDefault) GetById(id int) (*domain.Vehicle, error) {
+	v, err := s.rp.GetById(id)
+	if err != nil {
+		return nil, s.errAdapter(err)
+	}
+	return v, nil
+}
+
+// GetByFilter returns the vehicles that match a filter expression.
+func (s *ServiceVehicleDefault) GetByFilter(filter string) ([]*domain.Vehicle, error) {
+	f, err := ParseFilter(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := s.rp.GetByFilter(f)
+	if err != nil {
+		return nil, s.errAdapter(err)
+	}
+
+	return v, err
+}
+
+func (s *ServiceVehicleDefault) GetByDimensions(height domain.Range, width domain.Range) ([]*domain.Vehicle, error) {
+	v, err := s.rp.GetByDimensions(height, width)
 	if err != nil {
 		return nil, s.errAdapter(err)
 	}
@@ -48,8 +77,8 @@ func (s *ServiceVehicleDefault) SearchByColorAndYear(color string, year int) (v
 
 	return
 }
-func (s *ServiceVehicleDefault) GetByWeight(min float64, max float64) ([]*domain.Vehicle, error) {
-	v, err := s.rp.GetByWeight(min, max)
+func (s *ServiceVehicleDefault) GetByWeight(weight domain.Range) ([]*domain.Vehicle, error) {
+	v, err := s.rp.GetByWeight(weight)
 	if err != nil {
 		return nil, s.errAdapter(err)
 	}
@@ -57,24 +86,69 @@ func (s *ServiceVehicleDefault) GetByWeight(min float64, max float64) ([]*domain
 	return v, err
 }
 
-func (s *ServiceVehicleDefault) GetAverageCapacityByBrand(brand string) (float64, error) {
-	vehicles, err := s.rp.GetByBrand(brand)
+// GetAverageCapacityByBrand returns the average number of passengers of the vehicles of the brand.
+func (s *ServiceVehicleDefault) GetAverageCapacityByBrand(brand string) (domain.Average, error) {
+	averages, err := s.GetAverageByBrands(domain.VehicleFieldPassengers, []string{brand})
 	if err != nil {
-		return 0, s.errAdapter(err)
+		return domain.Average{}, err
 	}
-	if len(vehicles) == 0 {
-		return 0, ErrServiceVehicleNotFound
+	return averages[0], nil
+}
+
+// Search returns the vehicles that match a full-text query over brand, model, color and registration,
+// sorted by relevance. Words of the query may be misspelled or incomplete.
+func (s *ServiceVehicleDefault) Search(query string) ([]domain.SearchResult, error) {
+	if len(search.Tokenize(query)) == 0 {
+		return nil, fmt.Errorf("%w. the query has no words", ErrServiceVehicleInvalidSearch)
 	}
+	results, err := s.rp.Search(query)
+	if err != nil {
+		return nil, s.errAdapter(err)
+	}
+	return results, nil
+}
+
+// SuggestFields are the fields with suggestions.
+var SuggestFields = []domain.VehicleField{domain.VehicleFieldBrand, domain.VehicleFieldModel, domain.VehicleFieldColor}
 
-	count := 0
-	for _, v := range vehicles {
-		count += v.Attributes.Passengers
+// Suggest returns up to limit distinct values of the brand, the color or the model of a brand that start with
+// the prefix ignoring case, with their number of vehicles and the most common first.
+func (s *ServiceVehicleDefault) Suggest(field domain.VehicleField, brand string, prefix string, limit int) ([]domain.Suggestion, error) {
+	if !containsField(SuggestFields, field) {
+		return nil, fmt.Errorf("%w. no suggestions for %q, expected one of %s", ErrServiceVehicleInvalidSuggest, field, joinFields(SuggestFields))
 	}
-	return float64(count) / float64(len(vehicles)), nil
+	if field == domain.VehicleFieldModel && strings.TrimSpace(brand) == "" {
+		return nil, fmt.Errorf("%w. the suggestions of models need a brand", ErrServiceVehicleInvalidSuggest)
+	}
+	suggestions, err := s.rp.Suggest(field, brand, prefix, limit)
+	if err != nil {
+		return nil, s.errAdapter(err)
+	}
+	return suggestions, nil
 }
 
-func (s *ServiceVehicleDefault) PatchFuel(id int, fuelType string) error {
-	err := s.rp.PatchFuel(id, fuelType)
+// MatchETag returns the version of the vehicle with the id when its entity tag is among the tags of an If-Match
+// header, or 0 for the tag *, that matches any version. Otherwise, it returns ErrServiceVehicleVersionConflict.
+// The version is then checked again by the write, as the vehicle may change in between.
+func (s *ServiceVehicleDefault) MatchETag(id int, tags []string) (int, error) {
+	v, err := s.GetById(id)
+	if err != nil {
+		return 0, err
+	}
+	etag := v.ETag()
+	for _, tag := range tags {
+		switch tag {
+		case "*":
+			return 0, nil
+		case etag:
+			return v.Version, nil
+		}
+	}
+	return 0, fmt.Errorf("%w. vehicle %d has the entity tag %s", ErrServiceVehicleVersionConflict, id, etag)
+}
+
+func (s *ServiceVehicleDefault) PatchFuel(id int, fuelType string, version int) error {
+	err := s.rp.PatchFuel(id, fuelType, version)
 	if err != nil {
 		return s.errAdapter(err)
 	}
@@ -98,23 +172,85 @@ func (s *ServiceVehicleDefault) GetByTransmission(transmission string) ([]*domai
 	return v, err
 }
 
-func (s *ServiceVehicleDefault) Delete(id int) error {
-	err := s.rp.Delete(id)
+func (s *ServiceVehicleDefault) Delete(id int, version int) error {
+	err := s.rp.Delete(id, version)
 	if err != nil {
 		return s.errAdapter(err)
 	}
 	return nil
 }
 
-func (s *ServiceVehicleDefault) Batch(vehicles []*domain.Vehicle) error {
-	for _, v := range vehicles {
-		err := s.Post(v)
-		if err != nil {
-			return s.errAdapter(err)
+// Batch inserts the vehicles, returning the ones that fail with their error. When atomic, the vehicles are
+// inserted in a transaction that the first failure rolls back, and it is the only one returned; otherwise
+// every vehicle is tried on its own.
+func (s *ServiceVehicleDefault) Batch(vehicles []*domain.Vehicle, atomic bool) (failures []domain.BatchFailure, err error) {
+	failures = make([]domain.BatchFailure, 0)
+	if !atomic {
+		for i, v := range vehicles {
+			if errPost := s.postIsolated(v); errPost != nil {
+				failures = append(failures, domain.BatchFailure{Index: i, Id: v.Id, Err: errPost})
+			}
 		}
+		return
 	}
-	return nil
+
+	err = s.rp.Transaction(func(tx repository.RepositoryVehicleTx) error {
+		for i, v := range vehicles {
+			if errPost := s.post(tx, v); errPost != nil {
+				failures = append(failures, domain.BatchFailure{Index: i, Id: v.Id, Err: errPost})
+				return errPost
+			}
+		}
+		return nil
+	})
+	if len(failures) > 0 {
+		// the failure of the vehicle is reported instead of the rollback it caused
+		return failures, nil
+	}
+	if err != nil {
+		err = s.errAdapter(err)
+	}
+	return
 }
+
 func (s *ServiceVehicleDefault) Post(vehicle *domain.Vehicle) error {
-	return s.rp.Post(vehicle)
+	return s.postIsolated(vehicle)
+}
+
+// postIsolated inserts the vehicle on its own. When duplicates are rejected, the check runs in a transaction
+// with the insert, so a duplicate inserted in between can't be missed.
+func (s *ServiceVehicleDefault) postIsolated(vehicle *domain.Vehicle) error {
+	if s.duplicates == nil {
+		return s.post(s.rp, vehicle)
+	}
+
+	var errPost error
+	err := s.rp.Transaction(func(tx repository.RepositoryVehicleTx) error {
+		errPost = s.post(tx, vehicle)
+		return errPost
+	})
+	switch {
+	case errPost != nil:
+		return errPost
+	case err != nil:
+		return s.errAdapter(err)
+	}
+	return nil
+}
+
+// post inserts the vehicle with the writes of rp, rejecting it when it duplicates a vehicle and it is configured so.
+func (s *ServiceVehicleDefault) post(rp repository.RepositoryVehicleTx, vehicle *domain.Vehicle) error {
+	if s.duplicates != nil {
+		duplicate, err := s.findDuplicate(rp, vehicle, *s.duplicates)
+		if err != nil {
+			return err
+		}
+		if duplicate != nil {
+			return fmt.Errorf("%w. vehicle %d duplicates vehicle %d", ErrServiceVehicleDuplicate, vehicle.Id, duplicate.Id)
+		}
+	}
+	if err := rp.Post(vehicle); err != nil {
+		return s.errAdapter(err)
+	}
+	return nil
 }
@@ -0,0 +1,225 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// vehiclesTest is a small fleet with known values, by id.
+var vehiclesTest = map[int]domain.VehicleAttributes{
+	1: {Brand: "Ford", Model: "Fiesta", Registration: "AA100", Year: 2001, Color: "Red", MaxSpeed: 180, FuelType: "gasoline", Transmission: "manual", Passengers: 5, Height: 150, Width: 170, Weight: 1100},
+	2: {Brand: "Ford", Model: "Focus", Registration: "AA200", Year: 2005, Color: "Blue", MaxSpeed: 200, FuelType: "diesel", Transmission: "manual", Passengers: 5, Height: 155, Width: 180, Weight: 1300},
+	3: {Brand: "Ford", Model: "Mustang", Registration: "AA300", Year: 2015, Color: "Red", MaxSpeed: 250, FuelType: "gasoline", Transmission: "automatic", Passengers: 4, Height: 140, Width: 190, Weight: 1700},
+	4: {Brand: "Toyota", Model: "Corolla", Registration: "BB100", Year: 2010, Color: "White", MaxSpeed: 190, FuelType: "gas", Transmission: "automatic", Passengers: 5, Height: 145, Width: 175, Weight: 1250},
+	5: {Brand: "Toyota", Model: "Hilux", Registration: "BB200", Year: 2020, Color: "Black", MaxSpeed: 170, FuelType: "diesel", Transmission: "manual", Passengers: 5, Height: 180, Width: 185, Weight: 2100},
+	6: {Brand: "Fiat", Model: "Panda", Registration: "CC100", Year: 1999, Color: "Red", MaxSpeed: 150, FuelType: "gasoline", Transmission: "manual", Passengers: 4, Height: 150, Width: 160, Weight: 900},
+}
+
+// newServiceTest returns a service over an in-memory repository with the vehicles of vehiclesTest.
+func newServiceTest(t *testing.T) (*ServiceVehicleDefault, *repository.RepositoryVehicleInMemory) {
+	t.Helper()
+	db := make(map[int]*domain.VehicleAttributes, len(vehiclesTest))
+	for id, a := range vehiclesTest {
+		attributes := a
+		db[id] = &attributes
+	}
+	rp := repository.NewRepositoryVehicleInMemory(db)
+	return NewServiceVehicleDefault(rp, ErrorAdapter), rp
+}
+
+// fleetTest returns the vehicles of vehiclesTest with the given ids.
+func fleetTest(ids ...int) []*domain.Vehicle {
+	vehicles := make([]*domain.Vehicle, 0, len(ids))
+	for _, id := range ids {
+		vehicles = append(vehicles, &domain.Vehicle{Id: id, Attributes: vehiclesTest[id]})
+	}
+	return vehicles
+}
+
+func TestServiceVehicleDefault_GetAverageCapacityByBrand(t *testing.T) {
+	sv, _ := newServiceTest(t)
+
+	average, err := sv.GetAverageCapacityByBrand("toyota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := domain.Average{Brand: "Toyota", Field: domain.VehicleFieldPassengers, Average: 5, Count: 2, Models: []string{"Corolla", "Hilux"}}
+	if !reflect.DeepEqual(average, want) {
+		t.Errorf("GetAverageCapacityByBrand returned %+v, want %+v", average, want)
+	}
+	if _, err := sv.GetAverageCapacityByBrand("Seat"); !errors.Is(err, ErrServiceVehicleNotFound) {
+		t.Errorf("GetAverageCapacityByBrand of a brand with no vehicles returned %v, want %v", err, ErrServiceVehicleNotFound)
+	}
+}
+
+func TestServiceVehicleDefault_Search(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		// write changes the vehicles before the search.
+		write func(rp *repository.RepositoryVehicleInMemory) error
+		want  []int
+		err   error
+	}{
+		{name: "ranked", query: "ford red", want: []int{1, 3, 2, 6}},
+		{name: "typo", query: "corola", want: []int{4}},
+		{name: "registration", query: "bb200", want: []int{5}},
+		{
+			name: "posted vehicle", query: "panda",
+			write: func(rp *repository.RepositoryVehicleInMemory) error {
+				return rp.Post(&domain.Vehicle{Id: 7, Attributes: vehiclesTest[6]})
+			},
+			want: []int{6, 7},
+		},
+		{
+			name: "deleted vehicle", query: "panda",
+			write: func(rp *repository.RepositoryVehicleInMemory) error { return rp.Delete(6, 0) },
+			err:   ErrServiceVehicleNotFound,
+		},
+		{name: "no match", query: "seat", err: ErrServiceVehicleNotFound},
+		{name: "no words", query: " - ", err: ErrServiceVehicleInvalidSearch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			if tt.write != nil {
+				if err := tt.write(rp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			results, err := sv.Search(tt.query)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Search returned %v, want %v", err, tt.err)
+			}
+			ids := make([]int, 0, len(results))
+			for _, r := range results {
+				ids = append(ids, r.Vehicle.Id)
+			}
+			if err == nil && !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Search returned %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_Suggest(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  domain.VehicleField
+		brand  string
+		prefix string
+		want   []domain.Suggestion
+		err    error
+	}{
+		{name: "brands", field: domain.VehicleFieldBrand, prefix: "t", want: []domain.Suggestion{{Value: "Toyota", Count: 2}}},
+		{name: "colors", field: domain.VehicleFieldColor, prefix: "b", want: []domain.Suggestion{{Value: "Black", Count: 1}, {Value: "Blue", Count: 1}}},
+		{name: "models", field: domain.VehicleFieldModel, brand: "toyota", want: []domain.Suggestion{{Value: "Corolla", Count: 1}, {Value: "Hilux", Count: 1}}},
+		{name: "models without a brand", field: domain.VehicleFieldModel, brand: " ", err: ErrServiceVehicleInvalidSuggest},
+		{name: "field without suggestions", field: domain.VehicleFieldFuelType, err: ErrServiceVehicleInvalidSuggest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, _ := newServiceTest(t)
+			suggestions, err := sv.Suggest(tt.field, tt.brand, tt.prefix, 0)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("Suggest returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(suggestions, tt.want) {
+				t.Errorf("Suggest returned %v, want %v", suggestions, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_MatchETag(t *testing.T) {
+	sv, _ := newServiceTest(t)
+	v, err := sv.GetById(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      int
+		tags    []string
+		version int
+		err     error
+	}{
+		{name: "current tag", id: 1, tags: []string{v.ETag()}, version: 1},
+		{name: "list with the current tag", id: 1, tags: []string{`"0-0"`, v.ETag()}, version: 1},
+		{name: "any", id: 1, tags: []string{"*"}, version: 0},
+		{name: "stale tag", id: 1, tags: []string{`"0-0"`}, err: ErrServiceVehicleVersionConflict},
+		{name: "weak current tag", id: 1, tags: []string{"W/" + v.ETag()}, err: ErrServiceVehicleVersionConflict},
+		{name: "tag of another vehicle", id: 2, tags: []string{v.ETag()}, err: ErrServiceVehicleVersionConflict},
+		{name: "missing vehicle", id: 99, tags: []string{"*"}, err: ErrServiceVehicleNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			version, err := sv.MatchETag(tt.id, tt.tags)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("MatchETag returned %v, want %v", err, tt.err)
+			}
+			if version != tt.version {
+				t.Errorf("MatchETag returned version %d, want %d", version, tt.version)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_Batch(t *testing.T) {
+	fresh := func(id int) *domain.Vehicle { return &domain.Vehicle{Id: id, Attributes: vehiclesTest[1]} }
+	tests := []struct {
+		name     string
+		vehicles []*domain.Vehicle
+		atomic   bool
+		// failures are the indexes of the failed vehicles, stored the ids of the batch stored afterwards.
+		failures []int
+		stored   []int
+	}{
+		{name: "atomic", vehicles: []*domain.Vehicle{fresh(7), fresh(8)}, atomic: true, failures: []int{}, stored: []int{7, 8}},
+		{name: "atomic with an id in use", vehicles: []*domain.Vehicle{fresh(7), fresh(1), fresh(8)}, atomic: true, failures: []int{1}, stored: []int{}},
+		{name: "atomic with a repeated id", vehicles: []*domain.Vehicle{fresh(7), fresh(8), fresh(7)}, atomic: true, failures: []int{2}, stored: []int{}},
+		{name: "partial", vehicles: []*domain.Vehicle{fresh(7), fresh(8)}, failures: []int{}, stored: []int{7, 8}},
+		{name: "partial with ids in use", vehicles: []*domain.Vehicle{fresh(7), fresh(1), fresh(8), fresh(2)}, failures: []int{1, 3}, stored: []int{7, 8}},
+		{name: "partial with a repeated id", vehicles: []*domain.Vehicle{fresh(7), fresh(8), fresh(7)}, failures: []int{2}, stored: []int{7, 8}},
+		{name: "empty", vehicles: []*domain.Vehicle{}, atomic: true, failures: []int{}, stored: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			failures, err := sv.Batch(tt.vehicles, tt.atomic)
+			if err != nil {
+				t.Fatalf("Batch returned %v", err)
+			}
+			indexes := make([]int, 0, len(failures))
+			for _, f := range failures {
+				indexes = append(indexes, f.Index)
+				if f.Id != tt.vehicles[f.Index].Id || !errors.Is(f.Err, ErrServiceVIdInUse) {
+					t.Errorf("Batch returned the failure %+v, want id %d in use", f, tt.vehicles[f.Index].Id)
+				}
+			}
+			if !reflect.DeepEqual(indexes, tt.failures) {
+				t.Errorf("Batch failed at %v, want %v", indexes, tt.failures)
+			}
+
+			vehicles, err := rp.GetAll()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored := make([]int, 0)
+			for _, v := range vehicles {
+				if _, ok := vehiclesTest[v.Id]; !ok {
+					stored = append(stored, v.Id)
+				}
+			}
+			sort.Ints(stored)
+			if !reflect.DeepEqual(stored, tt.stored) || len(vehicles) != len(vehiclesTest)+len(tt.stored) {
+				t.Errorf("the batch stored %v, want %v", stored, tt.stored)
+			}
+		})
+	}
+}
@@ -11,6 +11,8 @@ func ErrorAdapter(err error) error {
 		return ErrServiceVehicleNotFound
 	case errors.Is(err, repository.ErrRepositoryIdInUse):
 		return ErrServiceVIdInUse
+	case errors.Is(err, repository.ErrRepositoryVehicleVersionConflict):
+		return ErrServiceVehicleVersionConflict
 	default:
 		return ErrServiceVehicleInternal
 	}
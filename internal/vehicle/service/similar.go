@@ -0,0 +1,146 @@
+package service
+
+import (
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// SimilarNumericFields are the numeric fields compared to find similar vehicles.
+	SimilarNumericFields = []domain.VehicleField{
+		domain.VehicleFieldYear, domain.VehicleFieldMaxSpeed, domain.VehicleFieldPassengers,
+		domain.VehicleFieldHeight, domain.VehicleFieldWidth, domain.VehicleFieldWeight,
+	}
+	// SimilarCategoricalFields are the text fields compared to find similar vehicles, matching or not.
+	SimilarCategoricalFields = []domain.VehicleField{domain.VehicleFieldFuelType, domain.VehicleFieldTransmission}
+)
+
+const (
+	// SimilarK is the default number of similar vehicles.
+	SimilarK = 5
+	// SimilarMaxK is the greatest number of similar vehicles.
+	SimilarMaxK = 100
+)
+
+// ParseSimilarWeights returns the weights of a comma separated list of field:weight pairs, e.g. "year:2,fuel_type:0".
+// The fields left out weigh 1, so that an empty list weighs all of them the same.
+func ParseSimilarWeights(list string) (weights map[domain.VehicleField]float64, err error) {
+	weights = make(map[domain.VehicleField]float64)
+	for _, f := range SimilarNumericFields {
+		weights[f] = 1
+	}
+	for _, f := range SimilarCategoricalFields {
+		weights[f] = 1
+	}
+	if strings.TrimSpace(list) == "" {
+		return
+	}
+
+	for _, part := range strings.Split(list, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			err = fmt.Errorf("%w. weight %q must be field:weight", ErrServiceVehicleInvalidSimilar, strings.TrimSpace(part))
+			return
+		}
+		field, ok := domain.ParseVehicleField(strings.TrimSpace(name))
+		if _, weighted := weights[field]; !ok || !weighted {
+			err = fmt.Errorf("%w. unknown weight field %q, expected one of %s, %s", ErrServiceVehicleInvalidSimilar,
+				strings.TrimSpace(name), joinFields(SimilarNumericFields), joinFields(SimilarCategoricalFields))
+			return
+		}
+		w, errParse := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if errParse != nil || !(w >= 0) || math.IsInf(w, 0) {
+			err = fmt.Errorf("%w. weight of %s must be a non-negative number, got %q", ErrServiceVehicleInvalidSimilar, field, strings.TrimSpace(value))
+			return
+		}
+		weights[field] = w
+	}
+	return
+}
+
+// GetSimilar returns the vehicles nearest to the one with the id, the nearest first.
+//
+// The distance is the square root of the weighted sum of the squared differences of the numeric fields, each
+// divided by the spread of the field across the fleet so that years and kilograms weigh alike, plus the weights
+// of the categorical fields that don't match, ignoring case.
+func (s *ServiceVehicleDefault) GetSimilar(id int, opts domain.SimilarOptions) (results []domain.SimilarResult, err error) {
+	if err = validateSimilarOptions(opts); err != nil {
+		return
+	}
+
+	vehicles, err := s.GetAll()
+	if err != nil {
+		return
+	}
+	var target *domain.Vehicle
+	for _, v := range vehicles {
+		if v.Id == id {
+			target = v
+			break
+		}
+	}
+	if target == nil {
+		err = ErrServiceVehicleNotFound
+		return
+	}
+
+	spreads := make(map[domain.VehicleField]float64, len(SimilarNumericFields))
+	for _, field := range SimilarNumericFields {
+		least, greatest := math.Inf(1), math.Inf(-1)
+		for _, v := range vehicles {
+			least = math.Min(least, v.Number(field))
+			greatest = math.Max(greatest, v.Number(field))
+		}
+		spreads[field] = greatest - least
+	}
+
+	results = make([]domain.SimilarResult, 0, len(vehicles)-1)
+	for _, v := range vehicles {
+		if v.Id == id {
+			continue
+		}
+		var sum float64
+		for _, field := range SimilarNumericFields {
+			if spreads[field] == 0 {
+				// every vehicle has the same value
+				continue
+			}
+			d := (v.Number(field) - target.Number(field)) / spreads[field]
+			sum += opts.Weights[field] * d * d
+		}
+		for _, field := range SimilarCategoricalFields {
+			if !strings.EqualFold(v.Text(field), target.Text(field)) {
+				sum += opts.Weights[field]
+			}
+		}
+		results = append(results, domain.SimilarResult{Vehicle: v, Distance: math.Sqrt(sum)})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		return a.Distance < b.Distance || a.Distance == b.Distance && a.Vehicle.Id < b.Vehicle.Id
+	})
+	if len(results) > opts.K {
+		results = results[:opts.K]
+	}
+	return
+}
+
+// validateSimilarOptions checks the options, which may not come from ParseSimilarWeights.
+func validateSimilarOptions(opts domain.SimilarOptions) error {
+	if opts.K <= 0 || opts.K > SimilarMaxK {
+		return fmt.Errorf("%w. k must be from 1 to %d", ErrServiceVehicleInvalidSimilar, SimilarMaxK)
+	}
+	for field, w := range opts.Weights {
+		if !containsField(SimilarNumericFields, field) && !containsField(SimilarCategoricalFields, field) {
+			return fmt.Errorf("%w. %s can't be weighted", ErrServiceVehicleInvalidSimilar, field)
+		}
+		if w < 0 || math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("%w. weight of %s must be a non-negative number", ErrServiceVehicleInvalidSimilar, field)
+		}
+	}
+	return nil
+}
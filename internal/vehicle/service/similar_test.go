@@ -0,0 +1,141 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/vehicle/repository"
+	"math"
+	"reflect"
+	"testing"
+)
+
+// weightsTest returns the weights of the fields given, the rest weighing nothing.
+func weightsTest(fields ...domain.VehicleField) map[domain.VehicleField]float64 {
+	weights := make(map[domain.VehicleField]float64)
+	for _, f := range fields {
+		weights[f] = 1
+	}
+	return weights
+}
+
+func TestParseSimilarWeights(t *testing.T) {
+	tests := []struct {
+		name string
+		list string
+		// want are the weights other than 1.
+		want map[domain.VehicleField]float64
+		err  error
+	}{
+		{name: "empty", list: " ", want: map[domain.VehicleField]float64{}},
+		{name: "weights", list: "year:2, fuel_type : 0", want: map[domain.VehicleField]float64{domain.VehicleFieldYear: 2, domain.VehicleFieldFuelType: 0}},
+		{name: "no weight", list: "year", err: ErrServiceVehicleInvalidSimilar},
+		{name: "unknown field", list: "wheels:1", err: ErrServiceVehicleInvalidSimilar},
+		{name: "field not compared", list: "color:1", err: ErrServiceVehicleInvalidSimilar},
+		{name: "negative weight", list: "year:-1", err: ErrServiceVehicleInvalidSimilar},
+		{name: "infinite weight", list: "year:Inf", err: ErrServiceVehicleInvalidSimilar},
+		{name: "weight not a number", list: "year:NaN", err: ErrServiceVehicleInvalidSimilar},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights, err := ParseSimilarWeights(tt.list)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseSimilarWeights returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(weights) != len(SimilarNumericFields)+len(SimilarCategoricalFields) {
+				t.Errorf("ParseSimilarWeights returned %d weights, want one for every field compared", len(weights))
+			}
+			for field, w := range weights {
+				want, ok := tt.want[field]
+				if !ok {
+					want = 1
+				}
+				if w != want {
+					t.Errorf("the weight of %s is %v, want %v", field, w, want)
+				}
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetSimilar(t *testing.T) {
+	tests := []struct {
+		name string
+		// write changes the vehicles before the search.
+		write func(rp *repository.RepositoryVehicleInMemory) error
+		id    int
+		opts  domain.SimilarOptions
+		// ids are the similar vehicles, distances their distances.
+		ids       []int
+		distances []float64
+		err       error
+	}{
+		{
+			// the differences of years are divided by the spread of 21 years from 1999 to 2020
+			name: "numeric field", id: 1, opts: domain.SimilarOptions{K: 5, Weights: weightsTest(domain.VehicleFieldYear)},
+			ids: []int{6, 2, 4, 3, 5}, distances: []float64{2.0 / 21, 4.0 / 21, 9.0 / 21, 14.0 / 21, 19.0 / 21},
+		},
+		{
+			name: "categorical fields", id: 1,
+			opts: domain.SimilarOptions{K: 3, Weights: weightsTest(domain.VehicleFieldFuelType, domain.VehicleFieldTransmission)},
+			ids:  []int{6, 2, 3}, distances: []float64{0, 1, 1},
+		},
+		{
+			name: "weighted fields", id: 4,
+			opts: domain.SimilarOptions{K: 2, Weights: map[domain.VehicleField]float64{domain.VehicleFieldYear: 4, domain.VehicleFieldTransmission: 1}},
+			ids:  []int{3, 2}, distances: []float64{math.Sqrt(4 * 25.0 / 441), math.Sqrt(4*25.0/441 + 1)},
+		},
+		{
+			// every vehicle left has 5 passengers, which are then left out of the distance
+			name: "field with no spread", id: 1,
+			write: func(rp *repository.RepositoryVehicleInMemory) error {
+				if err := rp.Delete(3, 0); err != nil {
+					return err
+				}
+				return rp.Delete(6, 0)
+			},
+			opts: domain.SimilarOptions{K: 5, Weights: weightsTest(domain.VehicleFieldPassengers)},
+			ids:  []int{2, 4, 5}, distances: []float64{0, 0, 0},
+		},
+		{name: "missing vehicle", id: 99, opts: domain.SimilarOptions{K: 5}, err: ErrServiceVehicleNotFound},
+		{name: "no k", id: 1, opts: domain.SimilarOptions{}, err: ErrServiceVehicleInvalidSimilar},
+		{name: "k too big", id: 1, opts: domain.SimilarOptions{K: SimilarMaxK + 1}, err: ErrServiceVehicleInvalidSimilar},
+		{name: "field not compared", id: 1, opts: domain.SimilarOptions{K: 1, Weights: weightsTest(domain.VehicleFieldColor)}, err: ErrServiceVehicleInvalidSimilar},
+		{
+			name: "weight not a number", id: 1,
+			opts: domain.SimilarOptions{K: 1, Weights: map[domain.VehicleField]float64{domain.VehicleFieldYear: math.NaN()}},
+			err:  ErrServiceVehicleInvalidSimilar,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, rp := newServiceTest(t)
+			if tt.write != nil {
+				if err := tt.write(rp); err != nil {
+					t.Fatal(err)
+				}
+			}
+			results, err := sv.GetSimilar(tt.id, tt.opts)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetSimilar returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			ids := make([]int, 0, len(results))
+			for _, r := range results {
+				ids = append(ids, r.Vehicle.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.ids) {
+				t.Fatalf("GetSimilar returned %v, want %v", ids, tt.ids)
+			}
+			for i, r := range results {
+				if math.Abs(r.Distance-tt.distances[i]) > 1e-9 {
+					t.Errorf("the distance of vehicle %d is %v, want %v", r.Vehicle.Id, r.Distance, tt.distances[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	// StatisticsGroupByFields are the fields the statistics can be grouped by.
+	StatisticsGroupByFields = []domain.VehicleField{
+		domain.VehicleFieldBrand, domain.VehicleFieldModel, domain.VehicleFieldYear,
+		domain.VehicleFieldColor, domain.VehicleFieldFuelType, domain.VehicleFieldTransmission,
+	}
+	// StatisticsFields are the fields the statistics summarize, all of them by default.
+	StatisticsFields = []domain.VehicleField{
+		domain.VehicleFieldMaxSpeed, domain.VehicleFieldPassengers, domain.VehicleFieldWeight,
+		domain.VehicleFieldHeight, domain.VehicleFieldWidth,
+	}
+	// StatisticsPercentiles are the percentiles computed by default.
+	StatisticsPercentiles = []float64{25, 75, 90, 95, 99}
+)
+
+// ParseStatisticsOptions returns the statistics options of comma separated lists of group by fields,
+// summarized fields and percentiles, e.g. "brand,year", "max_speed,weight" and "10,50,99.9".
+// The fields and percentiles left empty take the defaults StatisticsFields and StatisticsPercentiles.
+func ParseStatisticsOptions(groupBy string, fields string, percentiles string) (opts domain.StatisticsOptions, err error) {
+	if opts.GroupBy, err = parseStatisticsFields(groupBy, "group by", StatisticsGroupByFields); err != nil {
+		return
+	}
+	if opts.Fields, err = parseStatisticsFields(fields, "statistics", StatisticsFields); err != nil {
+		return
+	}
+	if len(opts.Fields) == 0 {
+		opts.Fields = StatisticsFields
+	}
+
+	opts.Percentiles = StatisticsPercentiles
+	if strings.TrimSpace(percentiles) != "" {
+		opts.Percentiles = nil
+		for _, part := range strings.Split(percentiles, ",") {
+			p, errParse := strconv.ParseFloat(strings.TrimSpace(part), 64)
+			if errParse != nil || p < 0 || p > 100 {
+				err = fmt.Errorf("%w. percentile %q must be a number from 0 to 100", ErrServiceVehicleInvalidStatistics, strings.TrimSpace(part))
+				return
+			}
+			opts.Percentiles = append(opts.Percentiles, p)
+		}
+	}
+	return
+}
+
+// parseStatisticsFields returns the fields of a comma separated list, which must be among the allowed ones.
+func parseStatisticsFields(list string, kind string, allowed []domain.VehicleField) (fields []domain.VehicleField, err error) {
+	if strings.TrimSpace(list) == "" {
+		return
+	}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		field, ok := domain.ParseVehicleField(name)
+		if !ok || !containsField(allowed, field) {
+			err = fmt.Errorf("%w. unknown %s field %q, expected one of %s", ErrServiceVehicleInvalidStatistics, kind, name, joinFields(allowed))
+			return
+		}
+		if containsField(fields, field) {
+			err = fmt.Errorf("%w. %s field %s is repeated", ErrServiceVehicleInvalidStatistics, kind, field)
+			return
+		}
+		fields = append(fields, field)
+	}
+	return
+}
+
+// GetStatistics returns the statistics of the vehicles that match a filter expression, all of them if it is empty.
+func (s *ServiceVehicleDefault) GetStatistics(filter string, opts domain.StatisticsOptions) (st domain.Statistics, err error) {
+	if err = validateStatisticsOptions(opts); err != nil {
+		return
+	}
+
+	var vehicles []*domain.Vehicle
+	if strings.TrimSpace(filter) == "" {
+		vehicles, err = s.GetAll()
+	} else {
+		vehicles, err = s.GetByFilter(filter)
+	}
+	if err != nil {
+		return
+	}
+	return computeStatistics(vehicles, opts), nil
+}
+
+// GetAverageByBrands returns the average of a numeric field of the vehicles of every brand, in the same order.
+// Brands are matched ignoring case, and the ones with no vehicles have a count of zero, unless none has vehicles
+// and ErrServiceVehicleNotFound is returned.
+func (s *ServiceVehicleDefault) GetAverageByBrands(field domain.VehicleField, brands []string) (averages []domain.Average, err error) {
+	if !field.IsNumeric() || field == domain.VehicleFieldId {
+		err = fmt.Errorf("%w. %q is not a numeric field", ErrServiceVehicleInvalidStatistics, field)
+		return
+	}
+	if len(brands) == 0 {
+		err = fmt.Errorf("%w. no brand to average", ErrServiceVehicleInvalidStatistics)
+		return
+	}
+
+	found := false
+	averages = make([]domain.Average, 0, len(brands))
+	for _, brand := range brands {
+		vehicles, errGet := s.rp.GetByBrand(brand)
+		if errGet != nil {
+			if errGet = s.errAdapter(errGet); !errors.Is(errGet, ErrServiceVehicleNotFound) {
+				err = errGet
+				return
+			}
+		}
+
+		average := domain.Average{Brand: brand, Field: field, Count: len(vehicles), Models: make([]string, 0)}
+		var sum float64
+		models := make(map[string]bool)
+		for _, v := range vehicles {
+			average.Brand = v.Attributes.Brand
+			sum += v.Number(field)
+			if !models[v.Attributes.Model] {
+				models[v.Attributes.Model] = true
+				average.Models = append(average.Models, v.Attributes.Model)
+			}
+		}
+		if average.Count > 0 {
+			found = true
+			average.Average = sum / float64(average.Count)
+		}
+		sort.Strings(average.Models)
+		averages = append(averages, average)
+	}
+	if !found {
+		err = ErrServiceVehicleNotFound
+	}
+	return
+}
+
+// validateStatisticsOptions checks the options, which may not come from ParseStatisticsOptions.
+func validateStatisticsOptions(opts domain.StatisticsOptions) error {
+	for _, field := range opts.GroupBy {
+		if !containsField(StatisticsGroupByFields, field) {
+			return fmt.Errorf("%w. the statistics can't be grouped by %s", ErrServiceVehicleInvalidStatistics, field)
+		}
+	}
+	if len(opts.Fields) == 0 {
+		return fmt.Errorf("%w. no field to summarize", ErrServiceVehicleInvalidStatistics)
+	}
+	for _, field := range opts.Fields {
+		if !containsField(StatisticsFields, field) {
+			return fmt.Errorf("%w. %s can't be summarized", ErrServiceVehicleInvalidStatistics, field)
+		}
+	}
+	for _, p := range opts.Percentiles {
+		if p < 0 || p > 100 {
+			return fmt.Errorf("%w. percentile %v must be from 0 to 100", ErrServiceVehicleInvalidStatistics, p)
+		}
+	}
+	return nil
+}
+
+// computeStatistics groups the vehicles and summarizes the fields of every group.
+func computeStatistics(vehicles []*domain.Vehicle, opts domain.StatisticsOptions) domain.Statistics {
+	keys := make([]domain.SortKey, 0, len(opts.GroupBy))
+	for _, field := range opts.GroupBy {
+		keys = append(keys, domain.SortKey{Field: field})
+	}
+
+	// the vehicles are grouped by the values of the group by fields, without the id sortValues appends
+	groups := make(map[string][]*domain.Vehicle)
+	values := make(map[string][]any)
+	for _, v := range vehicles {
+		vs := sortValues(v, keys)
+		vs = vs[:len(vs)-1]
+		k := fmt.Sprintf("%#v", vs)
+		if _, ok := groups[k]; !ok {
+			values[k] = vs
+		}
+		groups[k] = append(groups[k], v)
+	}
+	order := make([]string, 0, len(groups))
+	for k := range groups {
+		order = append(order, k)
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return compareSortValues(values[order[i]], values[order[j]], keys) < 0
+	})
+
+	st := domain.Statistics{Options: opts, Count: len(vehicles), Groups: make([]domain.StatisticsGroup, 0, len(order))}
+	for _, k := range order {
+		group := domain.StatisticsGroup{
+			Key:       make(map[domain.VehicleField]any, len(opts.GroupBy)),
+			Count:     len(groups[k]),
+			Summaries: make(map[domain.VehicleField]domain.Summary, len(opts.Fields)),
+		}
+		for i, field := range opts.GroupBy {
+			group.Key[field] = values[k][i]
+		}
+		for _, field := range opts.Fields {
+			numbers := make([]float64, 0, len(groups[k]))
+			for _, v := range groups[k] {
+				numbers = append(numbers, v.Number(field))
+			}
+			group.Summaries[field] = summarize(numbers, opts.Percentiles)
+		}
+		st.Groups = append(st.Groups, group)
+	}
+	return st
+}
+
+// summarize returns the summary of the numbers, reordering them.
+func summarize(numbers []float64, percentiles []float64) (s domain.Summary) {
+	s.Count = len(numbers)
+	s.Percentiles = make([]float64, len(percentiles))
+	if s.Count == 0 {
+		return
+	}
+
+	sort.Float64s(numbers)
+	var sum float64
+	for _, n := range numbers {
+		sum += n
+	}
+	s.Min = numbers[0]
+	s.Max = numbers[len(numbers)-1]
+	s.Mean = sum / float64(s.Count)
+	s.Median = percentile(numbers, 50)
+	for i, p := range percentiles {
+		s.Percentiles[i] = percentile(numbers, p)
+	}
+	return
+}
+
+// percentile returns the p-th percentile of the sorted numbers, interpolating linearly between the closest ranks.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo, hi := int(math.Floor(rank)), int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+// containsField reports whether the field is in fields.
+func containsField(fields []domain.VehicleField, field domain.VehicleField) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// joinFields returns the fields separated by commas.
+func joinFields(fields []domain.VehicleField) string {
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		names = append(names, string(f))
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestParseStatisticsOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		groupBy     string
+		fields      string
+		percentiles string
+		want        domain.StatisticsOptions
+		err         error
+	}{
+		{
+			name: "defaults",
+			want: domain.StatisticsOptions{Fields: StatisticsFields, Percentiles: StatisticsPercentiles},
+		},
+		{
+			name: "every option", groupBy: "brand, year", fields: "max_speed,weight", percentiles: "10, 50,99.9",
+			want: domain.StatisticsOptions{
+				GroupBy:     []domain.VehicleField{domain.VehicleFieldBrand, domain.VehicleFieldYear},
+				Fields:      []domain.VehicleField{domain.VehicleFieldMaxSpeed, domain.VehicleFieldWeight},
+				Percentiles: []float64{10, 50, 99.9},
+			},
+		},
+		{name: "unknown group by field", groupBy: "wheels", err: ErrServiceVehicleInvalidStatistics},
+		{name: "numeric group by field", groupBy: "weight", err: ErrServiceVehicleInvalidStatistics},
+		{name: "repeated group by field", groupBy: "brand,brand", err: ErrServiceVehicleInvalidStatistics},
+		{name: "text field", fields: "color", err: ErrServiceVehicleInvalidStatistics},
+		{name: "empty field in the list", fields: "weight,", err: ErrServiceVehicleInvalidStatistics},
+		{name: "percentile over 100", percentiles: "50,101", err: ErrServiceVehicleInvalidStatistics},
+		{name: "negative percentile", percentiles: "-1", err: ErrServiceVehicleInvalidStatistics},
+		{name: "percentile not a number", percentiles: "half", err: ErrServiceVehicleInvalidStatistics},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := ParseStatisticsOptions(tt.groupBy, tt.fields, tt.percentiles)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("ParseStatisticsOptions returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(opts, tt.want) {
+				t.Errorf("ParseStatisticsOptions returned %+v, want %+v", opts, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name        string
+		numbers     []float64
+		percentiles []float64
+		want        domain.Summary
+	}{
+		{
+			name: "no numbers", numbers: nil, percentiles: []float64{50},
+			want: domain.Summary{Percentiles: []float64{0}},
+		},
+		{
+			name: "single number", numbers: []float64{7}, percentiles: []float64{0, 99},
+			want: domain.Summary{Count: 1, Min: 7, Max: 7, Mean: 7, Median: 7, Percentiles: []float64{7, 7}},
+		},
+		{
+			name: "odd count", numbers: []float64{250, 180, 200}, percentiles: []float64{0, 25, 100},
+			want: domain.Summary{Count: 3, Min: 180, Max: 250, Mean: 210, Median: 200, Percentiles: []float64{180, 190, 250}},
+		},
+		{
+			// the median and the percentiles interpolate between the closest ranks
+			name: "even count", numbers: []float64{4, 1, 3, 2}, percentiles: []float64{50, 90},
+			want: domain.Summary{Count: 4, Min: 1, Max: 4, Mean: 2.5, Median: 2.5, Percentiles: []float64{2.5, 3.7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := summarize(tt.numbers, tt.percentiles)
+			for i := range got.Percentiles {
+				// 3.7 is not exact in binary
+				got.Percentiles[i] = math.Round(got.Percentiles[i]*1e9) / 1e9
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("summarize returned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetStatistics(t *testing.T) {
+	speed := []domain.VehicleField{domain.VehicleFieldMaxSpeed}
+	tests := []struct {
+		name   string
+		filter string
+		opts   domain.StatisticsOptions
+		// count is the number of vehicles, keys and counts the key and the number of vehicles of every group.
+		count  int
+		keys   []map[domain.VehicleField]any
+		counts []int
+		// max are the greatest max speeds of the groups.
+		max []float64
+		err error
+	}{
+		{
+			name: "single group", opts: domain.StatisticsOptions{Fields: speed},
+			count: 6, keys: []map[domain.VehicleField]any{{}}, counts: []int{6}, max: []float64{250},
+		},
+		{
+			name: "grouped by brand", opts: domain.StatisticsOptions{GroupBy: []domain.VehicleField{domain.VehicleFieldBrand}, Fields: speed},
+			count: 6,
+			keys: []map[domain.VehicleField]any{
+				{domain.VehicleFieldBrand: "Fiat"}, {domain.VehicleFieldBrand: "Ford"}, {domain.VehicleFieldBrand: "Toyota"},
+			},
+			counts: []int{1, 3, 2}, max: []float64{150, 250, 190},
+		},
+		{
+			name: "grouped by two fields", filter: "color = Red",
+			opts:  domain.StatisticsOptions{GroupBy: []domain.VehicleField{domain.VehicleFieldBrand, domain.VehicleFieldTransmission}, Fields: speed},
+			count: 3,
+			keys: []map[domain.VehicleField]any{
+				{domain.VehicleFieldBrand: "Fiat", domain.VehicleFieldTransmission: "manual"},
+				{domain.VehicleFieldBrand: "Ford", domain.VehicleFieldTransmission: "automatic"},
+				{domain.VehicleFieldBrand: "Ford", domain.VehicleFieldTransmission: "manual"},
+			},
+			counts: []int{1, 1, 1}, max: []float64{150, 250, 180},
+		},
+		{
+			name: "numeric key", filter: "brand = Toyota", opts: domain.StatisticsOptions{GroupBy: []domain.VehicleField{domain.VehicleFieldYear}, Fields: speed},
+			count:  2,
+			keys:   []map[domain.VehicleField]any{{domain.VehicleFieldYear: float64(2010)}, {domain.VehicleFieldYear: float64(2020)}},
+			counts: []int{1, 1}, max: []float64{190, 170},
+		},
+		{
+			name: "no match", filter: "brand = Seat", opts: domain.StatisticsOptions{Fields: speed},
+			err: ErrServiceVehicleNotFound,
+		},
+		{name: "no fields", opts: domain.StatisticsOptions{}, err: ErrServiceVehicleInvalidStatistics},
+		{
+			name: "text field", opts: domain.StatisticsOptions{Fields: []domain.VehicleField{domain.VehicleFieldColor}},
+			err: ErrServiceVehicleInvalidStatistics,
+		},
+		{
+			name: "numeric group by field", opts: domain.StatisticsOptions{GroupBy: []domain.VehicleField{domain.VehicleFieldWeight}, Fields: speed},
+			err: ErrServiceVehicleInvalidStatistics,
+		},
+		{
+			name: "percentile out of range", opts: domain.StatisticsOptions{Fields: speed, Percentiles: []float64{200}},
+			err: ErrServiceVehicleInvalidStatistics,
+		},
+		{name: "wrong filter", filter: "brand = ", opts: domain.StatisticsOptions{Fields: speed}, err: ErrServiceVehicleInvalidFilter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, _ := newServiceTest(t)
+			st, err := sv.GetStatistics(tt.filter, tt.opts)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetStatistics returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if st.Count != tt.count {
+				t.Errorf("GetStatistics counted %d vehicles, want %d", st.Count, tt.count)
+			}
+			keys := make([]map[domain.VehicleField]any, 0, len(st.Groups))
+			counts := make([]int, 0, len(st.Groups))
+			max := make([]float64, 0, len(st.Groups))
+			for _, g := range st.Groups {
+				keys = append(keys, g.Key)
+				counts = append(counts, g.Count)
+				max = append(max, g.Summaries[domain.VehicleFieldMaxSpeed].Max)
+			}
+			if !reflect.DeepEqual(keys, tt.keys) {
+				t.Errorf("GetStatistics returned the groups %v, want %v", keys, tt.keys)
+			}
+			if !reflect.DeepEqual(counts, tt.counts) || !reflect.DeepEqual(max, tt.max) {
+				t.Errorf("GetStatistics returned counts %v and max %v, want %v and %v", counts, max, tt.counts, tt.max)
+			}
+		})
+	}
+}
+
+func TestServiceVehicleDefault_GetAverageByBrands(t *testing.T) {
+	tests := []struct {
+		name   string
+		field  domain.VehicleField
+		brands []string
+		want   []domain.Average
+		err    error
+	}{
+		{
+			name: "several brands", field: domain.VehicleFieldMaxSpeed, brands: []string{"ford", "Toyota"},
+			want: []domain.Average{
+				{Brand: "Ford", Field: domain.VehicleFieldMaxSpeed, Average: 210, Count: 3, Models: []string{"Fiesta", "Focus", "Mustang"}},
+				{Brand: "Toyota", Field: domain.VehicleFieldMaxSpeed, Average: 180, Count: 2, Models: []string{"Corolla", "Hilux"}},
+			},
+		},
+		{
+			// a brand with no vehicles keeps the name requested
+			name: "brand with no vehicles", field: domain.VehicleFieldPassengers, brands: []string{"Seat", "FIAT"},
+			want: []domain.Average{
+				{Brand: "Seat", Field: domain.VehicleFieldPassengers, Models: []string{}},
+				{Brand: "Fiat", Field: domain.VehicleFieldPassengers, Average: 4, Count: 1, Models: []string{"Panda"}},
+			},
+		},
+		{name: "no brand with vehicles", field: domain.VehicleFieldWeight, brands: []string{"Seat", "Kia"}, err: ErrServiceVehicleNotFound},
+		{name: "no brands", field: domain.VehicleFieldWeight, err: ErrServiceVehicleInvalidStatistics},
+		{name: "text field", field: domain.VehicleFieldColor, brands: []string{"Ford"}, err: ErrServiceVehicleInvalidStatistics},
+		{name: "id", field: domain.VehicleFieldId, brands: []string{"Ford"}, err: ErrServiceVehicleInvalidStatistics},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sv, _ := newServiceTest(t)
+			averages, err := sv.GetAverageByBrands(tt.field, tt.brands)
+			if !errors.Is(err, tt.err) || (tt.err == nil && err != nil) {
+				t.Fatalf("GetAverageByBrands returned %v, want %v", err, tt.err)
+			}
+			if err == nil && !reflect.DeepEqual(averages, tt.want) {
+				t.Errorf("GetAverageByBrands returned %+v, want %+v", averages, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"hash/crc32"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrWALInternal is returned when an internal error occurs.
+	ErrWALInternal = errors.New("wal: internal error")
+
+	// ErrWALCorrupted is returned when the log contains a record that can't be read.
+	ErrWALCorrupted = errors.New("wal: corrupted log")
+)
+
+// Operation is the kind of mutation stored in a record.
+type Operation string
+
+const (
+	OperationPost      Operation = "post"
+	OperationPut       Operation = "put"
+	OperationPatchFuel Operation = "patch_fuel"
+	OperationDelete    Operation = "delete"
+	// OperationTx groups the records of a transaction, applied all or none.
+	OperationTx Operation = "tx"
+	// OperationAbort marks the record with sequence Ref as not applied.
+	OperationAbort Operation = "abort"
+)
+
+// Record is an entry of the log.
+type Record struct {
+	// Seq is the sequence number of the record, assigned by Append.
+	Seq uint64 `json:"seq"`
+	// Op is the operation of the record.
+	Op Operation `json:"op"`
+	// Id is the identifier of the vehicle.
+	Id int `json:"id,omitempty"`
+	// Attributes are the attributes of the vehicle for post and put.
+	Attributes *domain.VehicleAttributes `json:"attributes,omitempty"`
+	// FuelType is the fuel type for patch_fuel.
+	FuelType string `json:"fuel_type,omitempty"`
+	// Ref is the sequence of the record aborted by an abort record.
+	Ref uint64 `json:"ref,omitempty"`
+	// Records are the post, put and delete records of a tx record, with no sequence.
+	Records []Record `json:"records,omitempty"`
+}
+
+// Log is an append-only file of records.
+// Each record is written in its own line as the hex crc32 of its json followed by the json,
+// so a record torn by a crash is detected when the log is opened.
+type Log struct {
+	// mu guards f and seq.
+	mu sync.Mutex
+	// f is the log file.
+	f *os.File
+	// seq is the sequence of the last record.
+	seq uint64
+}
+
+// Open opens the log at path, creating it if needed, and returns the records it contains.
+// If the last record is incomplete or corrupted, Open fails with ErrWALCorrupted unless recover is set,
+// in which case the record is discarded and the file is truncated to the last valid record.
+// Corruption before the last record is never recovered.
+func Open(path string, recover bool) (l *Log, records []Record, err error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+
+	// read records
+	var offset int64
+	var seq uint64
+	rd := bufio.NewReader(f)
+	for {
+		var line []byte
+		line, err = rd.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			f.Close()
+			err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+			return
+		}
+		if len(line) == 0 {
+			break
+		}
+
+		r, errDecode := decode(line)
+		if errDecode != nil {
+			// only a broken tail can be recovered
+			_, errPeek := rd.Peek(1)
+			if !recover || errPeek != io.EOF {
+				f.Close()
+				err = fmt.Errorf("%w. offset %d: %v", ErrWALCorrupted, offset, errDecode)
+				return
+			}
+			if err = f.Truncate(offset); err != nil {
+				f.Close()
+				err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+				return
+			}
+			break
+		}
+
+		records = append(records, r)
+		seq = r.Seq
+		offset += int64(len(line))
+		if err == io.EOF {
+			break
+		}
+	}
+
+	if _, err = f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+
+	l = &Log{f: f, seq: seq}
+	err = nil
+	return
+}
+
+// Append writes the record at the end of the log and syncs it to disk.
+// It returns the sequence assigned to the record.
+func (l *Log) Append(r Record) (seq uint64, err error) {
+	if seq, err = l.Write(r); err != nil {
+		return
+	}
+	err = l.Sync()
+	return
+}
+
+// Write writes the record at the end of the log without syncing it to disk, which Sync does.
+// It returns the sequence assigned to the record.
+func (l *Log) Write(r Record) (seq uint64, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	r.Seq = l.seq + 1
+	line, err := encode(r)
+	if err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+	if _, err = l.f.Write(line); err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+
+	l.seq = r.Seq
+	seq = r.Seq
+	return
+}
+
+// Sync flushes the records written to disk.
+func (l *Log) Sync() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err := l.f.Sync(); err != nil {
+		return fmt.Errorf("%w. %v", ErrWALInternal, err)
+	}
+	return nil
+}
+
+// Reset removes every record of the log, once they are part of a snapshot.
+func (l *Log) Reset() (err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if err = l.f.Truncate(0); err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+	if _, err = l.f.Seek(0, io.SeekStart); err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+	if err = l.f.Sync(); err != nil {
+		err = fmt.Errorf("%w. %v", ErrWALInternal, err)
+		return
+	}
+	return
+}
+
+// Close closes the log file.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.f.Close()
+}
+
+// Committed returns the records that were not aborted, without the abort records.
+func Committed(records []Record) []Record {
+	aborted := make(map[uint64]bool)
+	for _, r := range records {
+		if r.Op == OperationAbort {
+			aborted[r.Ref] = true
+		}
+	}
+
+	committed := make([]Record, 0, len(records))
+	for _, r := range records {
+		if r.Op == OperationAbort || aborted[r.Seq] {
+			continue
+		}
+		committed = append(committed, r)
+	}
+	return committed
+}
+
+// encode returns the line of a record.
+func encode(r Record) ([]byte, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+
+	line := make([]byte, 0, len(b)+10)
+	line = fmt.Appendf(line, "%08x ", crc32.ChecksumIEEE(b))
+	line = append(line, b...)
+	line = append(line, '\n')
+	return line, nil
+}
+
+// decode returns the record of a line, checking it is complete and its checksum.
+func decode(line []byte) (r Record, err error) {
+	if !bytes.HasSuffix(line, []byte("\n")) {
+		err = errors.New("incomplete record")
+		return
+	}
+	line = bytes.TrimSuffix(line, []byte("\n"))
+
+	checksum, b, found := bytes.Cut(line, []byte(" "))
+	if !found {
+		err = errors.New("missing checksum")
+		return
+	}
+	sum, err := strconv.ParseUint(string(checksum), 16, 32)
+	if err != nil {
+		return
+	}
+	if uint32(sum) != crc32.ChecksumIEEE(b) {
+		err = errors.New("checksum mismatch")
+		return
+	}
+
+	err = json.Unmarshal(b, &r)
+	return
+}
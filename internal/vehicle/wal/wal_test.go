@@ -0,0 +1,174 @@
+package wal
+
+import (
+	"bytes"
+	"errors"
+	"github.com/abrahamkarina/code-review-exercise-one/internal/domain"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// recordsTest are the records of the logs of the tests, before Append assigns their sequence.
+var recordsTest = []Record{
+	{Op: OperationPost, Id: 1, Attributes: &domain.VehicleAttributes{Brand: "Ford", Model: "Ka", Year: 2001, FuelType: "gas"}},
+	{Op: OperationPatchFuel, Id: 1, FuelType: "diesel"},
+	{Op: OperationTx, Records: []Record{
+		{Op: OperationPut, Id: 1, Attributes: &domain.VehicleAttributes{Brand: "Ford", Model: "Fiesta", Year: 2002}},
+		{Op: OperationDelete, Id: 2},
+	}},
+	{Op: OperationAbort, Ref: 3},
+}
+
+// writeLogTest writes the records to a new log and returns its path.
+func writeLogTest(t *testing.T, records []Record) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vehicles.wal")
+	l, _, err := Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	for _, r := range records {
+		if _, err := l.Append(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return path
+}
+
+// sequencedTest returns the records with the sequences Append assigns them.
+func sequencedTest(records []Record) []Record {
+	sequenced := make([]Record, len(records))
+	for i, r := range records {
+		r.Seq = uint64(i + 1)
+		sequenced[i] = r
+	}
+	return sequenced
+}
+
+func TestOpen(t *testing.T) {
+	lastLine := func(b []byte) int {
+		return bytes.LastIndexByte(b[:len(b)-1], '\n') + 1
+	}
+	tests := []struct {
+		name string
+		// damage changes the content of the log with the records.
+		damage  func(b []byte) []byte
+		recover bool
+		// want is the number of records read, err the error of Open.
+		want int
+		err  error
+	}{
+		{name: "round trip", damage: func(b []byte) []byte { return b }, want: 4},
+		{name: "empty log", damage: func(b []byte) []byte { return nil }, want: 0},
+		{name: "torn last record", damage: func(b []byte) []byte { return b[:len(b)-5] }, err: ErrWALCorrupted},
+		{name: "torn last record recovered", damage: func(b []byte) []byte { return b[:len(b)-5] }, recover: true, want: 3},
+		{name: "last record without newline recovered", damage: func(b []byte) []byte { return b[:len(b)-1] }, recover: true, want: 3},
+		{name: "corrupted last record recovered", damage: func(b []byte) []byte {
+			b[lastLine(b)+12] ^= 1
+			return b
+		}, recover: true, want: 3},
+		{name: "corrupted record before the last one", damage: func(b []byte) []byte {
+			b[12] ^= 1
+			return b
+		}, recover: true, err: ErrWALCorrupted},
+		{name: "missing checksum", damage: func(b []byte) []byte {
+			return append(b, []byte("{\"seq\":5}\n")...)
+		}, err: ErrWALCorrupted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeLogTest(t, recordsTest)
+			b, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.damage(b), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			l, records, err := Open(path, tt.recover)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Open returned %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			want := sequencedTest(recordsTest)[:tt.want]
+			if len(records) != 0 || len(want) != 0 {
+				if !reflect.DeepEqual(records, want) {
+					t.Fatalf("Open read %+v, want %+v", records, want)
+				}
+			}
+
+			// the log is writable after the last record read, with the next sequence
+			seq, err := l.Append(Record{Op: OperationDelete, Id: 9})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if seq != uint64(tt.want+1) {
+				t.Errorf("Append returned sequence %d, want %d", seq, tt.want+1)
+			}
+			l.Close()
+			l, records, err = Open(path, false)
+			if err != nil {
+				t.Fatalf("reopening the log: %v", err)
+			}
+			l.Close()
+			if len(records) != tt.want+1 || records[tt.want].Id != 9 {
+				t.Errorf("reopening the log read %+v", records)
+			}
+		})
+	}
+}
+
+func TestLog_Reset(t *testing.T) {
+	path := writeLogTest(t, recordsTest)
+	l, _, err := Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.Append(Record{Op: OperationDelete, Id: 9}); err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+
+	l, records, err := Open(path, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Close()
+	if len(records) != 1 || records[0].Id != 9 {
+		t.Fatalf("the log has %+v after the reset, want only the record appended after it", records)
+	}
+}
+
+func TestCommitted(t *testing.T) {
+	post := func(seq uint64) Record { return Record{Seq: seq, Op: OperationPost, Id: int(seq)} }
+	abort := func(seq uint64, ref uint64) Record { return Record{Seq: seq, Op: OperationAbort, Ref: ref} }
+	tx := Record{Seq: 2, Op: OperationTx, Records: []Record{{Op: OperationPost, Id: 7}, {Op: OperationDelete, Id: 8}}}
+
+	tests := []struct {
+		name    string
+		records []Record
+		want    []Record
+	}{
+		{name: "no records", records: nil, want: []Record{}},
+		{name: "no aborts", records: []Record{post(1), tx, post(3)}, want: []Record{post(1), tx, post(3)}},
+		{name: "aborted transaction", records: []Record{post(1), tx, abort(3, 2), post(4)}, want: []Record{post(1), post(4)}},
+		{name: "aborted last record", records: []Record{post(1), post(2), abort(3, 2)}, want: []Record{post(1)}},
+		{name: "abort of an unknown record", records: []Record{post(1), abort(2, 9)}, want: []Record{post(1)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Committed(tt.records); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Committed returned %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}